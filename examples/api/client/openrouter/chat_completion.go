@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 type (
@@ -16,27 +18,116 @@ type (
 			Role    string `json:"role"`
 			Content string `json:"content"`
 		} `json:"messages"`
+		ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+		Stream         bool            `json:"stream,omitempty"`
+		Usage          *UsageOptions   `json:"usage,omitempty"`
+	}
+
+	// UsageOptions asks OpenRouter to include the generation cost in the usage block.
+	UsageOptions struct {
+		Include bool `json:"include"`
 	}
 
 	OpenRouterResponse struct {
+		Model   string `json:"model"`
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage Usage `json:"usage"`
+	}
+
+	// Usage is the token and cost accounting of a single completion.
+	Usage struct {
+		PromptTokens     int     `json:"prompt_tokens"`
+		CompletionTokens int     `json:"completion_tokens"`
+		TotalTokens      int     `json:"total_tokens"`
+		Cost             float64 `json:"cost"`
 	}
 
 	DataResponse struct {
 		ServiceID   uint8  `json:"service_id"`
 		ServiceName string `json:"service_name"`
+
+		// Model and Usage come from the OpenRouter response, not from the model output
+		Model string `json:"-"`
+		Usage Usage  `json:"-"`
+	}
+
+	// APIError is a non-200 answer from OpenRouter.
+	APIError struct {
+		StatusCode int
+		Body       string
 	}
 )
 
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// unsupportedResponseFormat reports whether the request was refused because
+// the model or its providers do not support structured outputs.
+func (e *APIError) unsupportedResponseFormat() bool {
+	if e.StatusCode != http.StatusBadRequest && e.StatusCode != http.StatusNotFound {
+		return false
+	}
+
+	body := strings.ToLower(e.Body)
+	for _, hint := range []string{"response_format", "json_schema", "structured output", "requested parameters"} {
+		if strings.Contains(body, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatCompletion classifies the intent, asking for a reply matching
+// ServiceSchema. When the model refuses structured outputs the request is
+// sent again without them, and from then on the client relies on the repair
+// step of ParseDataResponse. When the reply cannot be parsed the returned
+// response still carries Model and Usage, since the generation was billed.
 func (c *Client) ChatCompletion(ctx context.Context, intent string) (*DataResponse, error) {
-	url := c.baseURL + "/chat/completions"
+	requestBody := c.newCompletionRequest(intent)
+
+	resp, err := c.open(ctx, &requestBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+
+	var openRouterResp OpenRouterResponse
+	if err := json.Unmarshal(body, &openRouterResp); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %v. body: %s", err, string(body))
+	}
+
+	if len(openRouterResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
 
+	model, usage := openRouterResp.Model, openRouterResp.Usage
+	if model == "" {
+		model = requestBody.Model
+	}
+
+	data, err := ParseDataResponse(openRouterResp.Choices[0].Message.Content, Services)
+	if err != nil {
+		return &DataResponse{Model: model, Usage: usage}, err
+	}
+
+	data.Model, data.Usage = model, usage
+	return data, nil
+}
+
+func (c *Client) newCompletionRequest(intent string) OpenRouterRequest {
 	requestBody := OpenRouterRequest{
 		Model: "<definir_modelo>",
+		Usage: &UsageOptions{Include: true},
 		Messages: []struct {
 			Role    string `json:"role"`
 			Content string `json:"content"`
@@ -54,6 +145,32 @@ func (c *Client) ChatCompletion(ctx context.Context, intent string) (*DataRespon
 		},
 	}
 
+	if !c.noStructuredOutputs.Load() {
+		requestBody.ResponseFormat = ServiceResponseFormat(Services)
+	}
+
+	return requestBody
+}
+
+// open sends the request and returns the response once OpenRouter accepted it
+// with a 200, dropping response_format when the model refuses it. The caller
+// closes the body.
+func (c *Client) open(ctx context.Context, requestBody *OpenRouterRequest) (*http.Response, error) {
+	resp, err := c.send(ctx, requestBody)
+
+	var apiErr *APIError
+	if requestBody.ResponseFormat != nil && errors.As(err, &apiErr) && apiErr.unsupportedResponseFormat() {
+		c.noStructuredOutputs.Store(true)
+		requestBody.ResponseFormat = nil
+		resp, err = c.send(ctx, requestBody)
+	}
+
+	return resp, err
+}
+
+func (c *Client) send(ctx context.Context, requestBody *OpenRouterRequest) (*http.Response, error) {
+	url := c.baseURL + "/chat/completions"
+
 	jsonBody, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %v", err)
@@ -70,30 +187,16 @@ func (c *Client) ChatCompletion(ctx context.Context, intent string) (*DataRespon
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %v", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response: %v", err)
-	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var openRouterResp OpenRouterResponse
-	if err := json.Unmarshal(body, &openRouterResp); err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %v. body: %s", err, string(body))
-	}
-
-	if len(openRouterResp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in response")
-	}
+		defer resp.Body.Close()
 
-	var dataRes DataResponse
-	if err := json.Unmarshal([]byte(openRouterResp.Choices[0].Message.Content), &dataRes); err != nil {
-		return nil, fmt.Errorf("error unmarshaling data response: %v. content: %s", err, openRouterResp.Choices[0].Message.Content)
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response: %v", err)
+		}
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
-	return &dataRes, nil
+	return resp, nil
 }
@@ -3,6 +3,7 @@ package openrouter
 import (
 	"context"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +13,9 @@ type Client struct {
 	baseURL string
 	client  *http.Client
 	doFunc  func(c *Client, req *http.Request) (*http.Response, error)
+
+	// noStructuredOutputs is set once the model refuses response_format
+	noStructuredOutputs atomic.Bool
 }
 
 func NewClient(baseURL string, opts ...Option) *Client {
@@ -33,8 +37,10 @@ func NewClient(baseURL string, opts ...Option) *Client {
 	return c
 }
 
+// Do runs the request through the option chain. ctx replaces the request
+// context, so cancelling it aborts the request, retries and waits included.
 func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
-	resp, err := c.doFunc(c, req)
+	resp, err := c.doFunc(c, req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,220 @@
+package openrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Service is an entry of the service catalog the intents are classified into.
+type Service struct {
+	ID   uint8
+	Name string
+}
+
+// Services is the service catalog.
+var Services = []Service{
+	{1, "Consulta Limite / Vencimento do cartão / Melhor dia de compra"},
+	{2, "Segunda via de boleto de acordo"},
+	{3, "Segunda via de Fatura"},
+	{4, "Status de Entrega do Cartão"},
+	{5, "Status de cartão"},
+	{6, "Solicitação de aumento de limite"},
+	{7, "Cancelamento de cartão"},
+	{8, "Telefones de seguradoras"},
+	{9, "Desbloqueio de Cartão"},
+	{10, "Esqueceu senha / Troca de senha"},
+	{11, "Perda e roubo"},
+	{12, "Consulta do Saldo"},
+	{13, "Pagamento de contas"},
+	{14, "Reclamações"},
+	{15, "Atendimento humano"},
+	{16, "Token de proposta"},
+}
+
+type (
+	// ResponseFormat is the OpenRouter structured outputs request field.
+	ResponseFormat struct {
+		Type       string      `json:"type"`
+		JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+	}
+
+	JSONSchema struct {
+		Name   string         `json:"name"`
+		Strict bool           `json:"strict"`
+		Schema map[string]any `json:"schema"`
+	}
+)
+
+// ServiceSchema returns the JSON Schema of a classification: service_id is
+// one of the catalog IDs and service_name one of the catalog names. The
+// pairing of the two is checked by ParseDataResponse.
+func ServiceSchema(services []Service) map[string]any {
+	ids := make([]int, len(services))
+	names := make([]string, len(services))
+	pairs := make([]string, len(services))
+	for i, s := range services {
+		ids[i], names[i] = int(s.ID), s.Name
+		pairs[i] = fmt.Sprintf("%d = %s", s.ID, s.Name)
+	}
+
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"service_id": map[string]any{
+				"type":        "integer",
+				"enum":        ids,
+				"description": "ID of the service: " + strings.Join(pairs, "; "),
+			},
+			"service_name": map[string]any{
+				"type":        "string",
+				"enum":        names,
+				"description": "Name of the service, matching service_id",
+			},
+		},
+		"required":             []string{"service_id", "service_name"},
+		"additionalProperties": false,
+	}
+}
+
+// ServiceResponseFormat asks for a reply matching ServiceSchema.
+func ServiceResponseFormat(services []Service) *ResponseFormat {
+	return &ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &JSONSchema{
+			Name:   "service_classification",
+			Strict: true,
+			Schema: ServiceSchema(services),
+		},
+	}
+}
+
+// ParseDataResponse validates the model output against ServiceSchema. Output
+// that does not validate, e.g. from a model without structured outputs, goes
+// through a repair step that looks for a service ID or name in the text.
+func ParseDataResponse(content string, services []Service) (*DataResponse, error) {
+	data, err := validateDataResponse([]byte(content), services)
+	if err == nil {
+		return data, nil
+	}
+
+	if repaired, ok := repairDataResponse(content, services); ok {
+		return repaired, nil
+	}
+
+	return nil, fmt.Errorf("invalid data response: %v. content: %s", err, content)
+}
+
+// validateDataResponse checks that content is exactly an object with a
+// catalog service_id and its matching service_name.
+func validateDataResponse(content []byte, services []Service) (*DataResponse, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"service_id", "service_name"} {
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("missing property %q", name)
+		}
+	}
+
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected properties in %s", content)
+	}
+
+	var id uint8
+	if err := json.Unmarshal(fields["service_id"], &id); err != nil {
+		return nil, fmt.Errorf("service_id: %v", err)
+	}
+
+	var name string
+	if err := json.Unmarshal(fields["service_name"], &name); err != nil {
+		return nil, fmt.Errorf("service_name: %v", err)
+	}
+
+	service, ok := findService(services, int(id))
+	if !ok {
+		return nil, fmt.Errorf("service_id %d is not in the catalog", id)
+	}
+
+	if name != service.Name {
+		return nil, fmt.Errorf("service_name %q does not match service_id %d", name, id)
+	}
+
+	return &DataResponse{ServiceID: service.ID, ServiceName: service.Name}, nil
+}
+
+var (
+	jsonObjectRe = regexp.MustCompile(`(?s)\{.*?\}`)
+	serviceIDRe  = regexp.MustCompile(`(?i)\b(?:service_id|id|serviço|servico)["']?\s*[:=\-]?\s*(\d{1,2})\b`)
+)
+
+// repairDataResponse recovers the classification from free text: an embedded
+// JSON object, a labelled ID such as "ID: 3" or a catalog name. The name
+// always comes from the catalog.
+func repairDataResponse(content string, services []Service) (*DataResponse, bool) {
+	for _, obj := range jsonObjectRe.FindAllString(content, -1) {
+		var data struct {
+			ServiceID   json.Number `json:"service_id"`
+			ServiceName string      `json:"service_name"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader([]byte(obj)))
+		decoder.UseNumber()
+		if decoder.Decode(&data) != nil {
+			continue
+		}
+
+		if id, err := strconv.Atoi(data.ServiceID.String()); err == nil {
+			if service, ok := findService(services, id); ok {
+				return &DataResponse{ServiceID: service.ID, ServiceName: service.Name}, true
+			}
+		}
+
+		if service, ok := findServiceByName(services, data.ServiceName); ok {
+			return &DataResponse{ServiceID: service.ID, ServiceName: service.Name}, true
+		}
+	}
+
+	if m := serviceIDRe.FindStringSubmatch(content); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		if service, ok := findService(services, id); ok {
+			return &DataResponse{ServiceID: service.ID, ServiceName: service.Name}, true
+		}
+	}
+
+	// The longest name wins, in case one name is contained in another
+	var best *Service
+	lower := strings.ToLower(content)
+	for i, s := range services {
+		if strings.Contains(lower, strings.ToLower(s.Name)) && (best == nil || len(s.Name) > len(best.Name)) {
+			best = &services[i]
+		}
+	}
+	if best != nil {
+		return &DataResponse{ServiceID: best.ID, ServiceName: best.Name}, true
+	}
+
+	return nil, false
+}
+
+func findService(services []Service, id int) (Service, bool) {
+	for _, s := range services {
+		if int(s.ID) == id {
+			return s, true
+		}
+	}
+	return Service{}, false
+}
+
+func findServiceByName(services []Service, name string) (Service, bool) {
+	for _, s := range services {
+		if strings.EqualFold(strings.TrimSpace(name), s.Name) {
+			return s, true
+		}
+	}
+	return Service{}, false
+}
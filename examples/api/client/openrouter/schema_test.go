@@ -0,0 +1,189 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServiceSchema(t *testing.T) {
+	raw, err := json.Marshal(ServiceResponseFormat(Services))
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+
+	var format struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Strict bool `json:"strict"`
+			Schema struct {
+				Properties struct {
+					ServiceID struct {
+						Enum []int `json:"enum"`
+					} `json:"service_id"`
+					ServiceName struct {
+						Enum []string `json:"enum"`
+					} `json:"service_name"`
+				} `json:"properties"`
+				Required             []string `json:"required"`
+				AdditionalProperties bool     `json:"additionalProperties"`
+			} `json:"schema"`
+		} `json:"json_schema"`
+	}
+	if err := json.Unmarshal(raw, &format); err != nil {
+		t.Fatalf("Unmarshal() error: %v\n%s", err, raw)
+	}
+
+	if format.Type != "json_schema" || !format.JSONSchema.Strict {
+		t.Errorf("format = %s, want a strict json_schema", raw)
+	}
+
+	schema := format.JSONSchema.Schema
+	ids, names := schema.Properties.ServiceID.Enum, schema.Properties.ServiceName.Enum
+	if len(ids) != 16 || ids[0] != 1 || ids[15] != 16 {
+		t.Errorf("service_id enum = %v, want 1..16", ids)
+	}
+	if len(names) != 16 || names[2] != "Segunda via de Fatura" {
+		t.Errorf("service_name enum = %v, want the catalog names", names)
+	}
+	if len(schema.Required) != 2 || schema.AdditionalProperties {
+		t.Errorf("required = %v, additionalProperties = %v", schema.Required, schema.AdditionalProperties)
+	}
+}
+
+func TestParseDataResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    uint8
+		wantErr bool
+	}{
+		{name: "valid", content: `{"service_id": 3, "service_name": "Segunda via de Fatura"}`, want: 3},
+		{name: "name mismatch keeps the id", content: `{"service_id": 3, "service_name": "Pagamento de contas"}`, want: 3},
+		{name: "code fence", content: "```json\n{\"service_id\": 12, \"service_name\": \"Consulta do Saldo\"}\n```", want: 12},
+		{name: "extra properties", content: `{"service_id": 7, "service_name": "Cancelamento de cartão", "confidence": 0.9}`, want: 7},
+		{name: "prose id", content: "ID: 3", want: 3},
+		{name: "prose service", content: "Esse é o serviço 11, perda e roubo.", want: 11},
+		{name: "name only", content: "O cliente quer o Desbloqueio de Cartão", want: 9},
+		{name: "id outside the catalog", content: `{"service_id": 42, "service_name": "Outro"}`, wantErr: true},
+		{name: "no classification", content: "não sei", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDataResponse(tt.content, Services)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDataResponse() = %+v, want error", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseDataResponse() error: %v", err)
+			}
+
+			service, _ := findService(Services, int(tt.want))
+			if got.ServiceID != tt.want || got.ServiceName != service.Name {
+				t.Errorf("ParseDataResponse() = %+v, want %d %q", got, tt.want, service.Name)
+			}
+		})
+	}
+}
+
+// completionServer answers with content, or refuses response_format with a 400 when rejectSchema is set
+func completionServer(t *testing.T, content string, rejectSchema bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var withSchema atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResponseFormat *ResponseFormat `json:"response_format"`
+			Usage          *UsageOptions   `json:"usage"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Usage == nil || !req.Usage.Include {
+			t.Errorf("usage = %+v, want the cost included", req.Usage)
+		}
+
+		if req.ResponseFormat != nil {
+			withSchema.Add(1)
+			if req.ResponseFormat.Type != "json_schema" || req.ResponseFormat.JSONSchema == nil {
+				t.Errorf("response_format = %+v, want json_schema", req.ResponseFormat)
+			}
+			if rejectSchema {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": {"message": "response_format json_schema is not supported by this model"}}`))
+				return
+			}
+		}
+
+		encoded, _ := json.Marshal(content)
+		_, _ = fmt.Fprintf(w, `{"model": "openai/gpt-4o-mini", "choices": [{"message": {"content": %s}}], "usage": {"prompt_tokens": 320, "completion_tokens": 12, "total_tokens": 332, "cost": 0.0000552}}`, encoded)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &withSchema
+}
+
+func TestChatCompletion_StructuredOutput(t *testing.T) {
+	srv, withSchema := completionServer(t, `{"service_id": 16, "service_name": "Token de proposta"}`, false)
+	c := NewClient(srv.URL)
+
+	got, err := c.ChatCompletion(context.Background(), "não recebi o token")
+	if err != nil {
+		t.Fatalf("ChatCompletion() error: %v", err)
+	}
+
+	if got.ServiceID != 16 || got.ServiceName != "Token de proposta" {
+		t.Errorf("ChatCompletion() = %+v, want service 16", got)
+	}
+	if got.Model != "openai/gpt-4o-mini" || got.Usage.TotalTokens != 332 || got.Usage.Cost != 0.0000552 {
+		t.Errorf("model = %q, usage = %+v, want the usage block of the response", got.Model, got.Usage)
+	}
+	if withSchema.Load() != 1 {
+		t.Errorf("requests with response_format = %d, want 1", withSchema.Load())
+	}
+}
+
+func TestChatCompletion_UsageOfUnparsedReply(t *testing.T) {
+	srv, _ := completionServer(t, "não sei", false)
+	c := NewClient(srv.URL)
+
+	got, err := c.ChatCompletion(context.Background(), "???")
+	if err == nil {
+		t.Fatalf("ChatCompletion() = %+v, want a parse error", got)
+	}
+
+	// The generation was billed even though it did not classify the intent
+	if got == nil || got.Usage.PromptTokens != 320 || got.Usage.CompletionTokens != 12 {
+		t.Errorf("ChatCompletion() = %+v, want the usage of the billed reply", got)
+	}
+}
+
+func TestChatCompletion_FallsBackWithoutSchema(t *testing.T) {
+	srv, withSchema := completionServer(t, "ID: 3, Nome: Segunda via de Fatura", true)
+	c := NewClient(srv.URL)
+
+	for i := 0; i < 2; i++ {
+		got, err := c.ChatCompletion(context.Background(), "segunda via da fatura")
+		if err != nil {
+			t.Fatalf("ChatCompletion() #%d error: %v", i+1, err)
+		}
+		if got.ServiceID != 3 || !strings.HasPrefix(got.ServiceName, "Segunda via de Fatura") {
+			t.Errorf("ChatCompletion() #%d = %+v, want service 3", i+1, got)
+		}
+	}
+
+	// The refusal is remembered, so the second call goes straight to free text
+	if withSchema.Load() != 1 {
+		t.Errorf("requests with response_format = %d, want 1", withSchema.Load())
+	}
+}
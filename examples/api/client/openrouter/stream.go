@@ -0,0 +1,194 @@
+package openrouter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// StreamStats times a streamed completion.
+	StreamStats struct {
+		// TimeToFirstToken is the time from sending the request to the first content token.
+		TimeToFirstToken time.Duration
+		// Duration is the time until the service ID was decided or the stream ended.
+		Duration time.Duration
+		// EarlyStop is set when the stream was closed before the model finished.
+		EarlyStop bool
+	}
+
+	streamChunk struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+		// Usage is only sent in the last chunk
+		Usage *Usage `json:"usage,omitempty"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+)
+
+// ChatCompletionStream is ChatCompletion over server-sent events. The content
+// is parsed as it arrives and the stream is closed as soon as it holds a
+// complete catalog service ID, so the tokens after it are never waited for.
+// OpenRouter sends the usage block in the last chunk only, so the Usage of an
+// early stopped stream is zero.
+func (c *Client) ChatCompletionStream(ctx context.Context, intent string) (*DataResponse, *StreamStats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	requestBody := c.newCompletionRequest(intent)
+	requestBody.Stream = true
+
+	start := time.Now()
+	stats := &StreamStats{}
+
+	resp, err := c.open(ctx, &requestBody)
+	if err != nil {
+		return nil, stats, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var decided Service
+	model, usage := requestBody.Model, Usage{}
+	err = readEvents(resp.Body, func(data []byte) (bool, error) {
+		var chunk streamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return false, fmt.Errorf("error unmarshaling stream chunk: %v. data: %s", err, data)
+		}
+		if chunk.Error != nil {
+			return false, fmt.Errorf("stream error: %s", chunk.Error.Message)
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return true, nil
+		}
+
+		if stats.TimeToFirstToken == 0 {
+			stats.TimeToFirstToken = time.Since(start)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+
+		var ok bool
+		decided, ok = decidedServiceID(content.String(), Services, false)
+		stats.EarlyStop = ok
+		return !ok, nil
+	})
+	stats.Duration = time.Since(start)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	if stats.EarlyStop {
+		return &DataResponse{ServiceID: decided.ID, ServiceName: decided.Name, Model: model}, stats, nil
+	}
+
+	// The stream ended: a trailing "1" can no longer become "16"
+	if service, ok := decidedServiceID(content.String(), Services, true); ok {
+		return &DataResponse{ServiceID: service.ID, ServiceName: service.Name, Model: model, Usage: usage}, stats, nil
+	}
+
+	data, err := ParseDataResponse(content.String(), Services)
+	if err != nil {
+		return &DataResponse{Model: model, Usage: usage}, stats, err
+	}
+
+	data.Model, data.Usage = model, usage
+	return data, stats, nil
+}
+
+// readEvents calls handle with the data of every server-sent event until the
+// [DONE] event, the end of the body or handle returning false.
+func readEvents(body io.Reader, handle func(data []byte) (bool, error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	var data []byte
+	dispatch := func() (bool, error) {
+		if len(data) == 0 {
+			return true, nil
+		}
+		defer func() { data = data[:0] }()
+
+		if bytes.Equal(data, []byte("[DONE]")) {
+			return false, nil
+		}
+		return handle(data)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		switch {
+		case len(line) == 0:
+			if more, err := dispatch(); !more || err != nil {
+				return err
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(line[len("data:"):], []byte(" "))...)
+		}
+		// Comments such as ": OPENROUTER PROCESSING" and other fields are ignored
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stream: %v", err)
+	}
+
+	_, err := dispatch()
+	return err
+}
+
+// leadingIDRe matches a reply that is a bare number or a service_id property.
+var leadingIDRe = regexp.MustCompile(`^\s*["'` + "`" + `]?(\d+)|"service_id"\s*:\s*"?(\d+)`)
+
+// decidedServiceID reports whether content already holds a complete catalog
+// service ID. The digits must be followed by a terminator, a quote, "}" or
+// whitespace (or "," in a service_id property), so prose such as "2a via" or
+// "1. O cliente" is not taken for an ID. Digits at the end of the content are
+// complete only once the stream has ended.
+func decidedServiceID(content string, services []Service, final bool) (Service, bool) {
+	m := leadingIDRe.FindStringSubmatchIndex(content)
+	if m == nil {
+		return Service{}, false
+	}
+
+	start, end, terminators := m[2], m[3], "\"'`} \t\r\n"
+	if start < 0 {
+		start, end, terminators = m[4], m[5], "\",} \t\r\n"
+	}
+
+	if end == len(content) {
+		if !final {
+			return Service{}, false
+		}
+	} else if !strings.ContainsRune(terminators, rune(content[end])) {
+		return Service{}, false
+	}
+
+	id, err := strconv.Atoi(content[start:end])
+	if err != nil {
+		return Service{}, false
+	}
+
+	return findService(services, id)
+}
@@ -0,0 +1,177 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// sseServer streams tokens as chat completion chunks, then holds the stream
+// open for hold unless the client goes away first. closed receives whether
+// the client disconnected early.
+func sseServer(t *testing.T, tokens []string, hold time.Duration) (*httptest.Server, chan bool) {
+	t.Helper()
+
+	closed := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			t.Errorf("stream = %v (%v), want true", req.Stream, err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+
+		_, _ = fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+		flusher.Flush()
+
+		for _, token := range tokens {
+			encoded, _ := json.Marshal(token)
+			_, _ = fmt.Fprintf(w, "data: {\"choices\": [{\"delta\": {\"content\": %s}}]}\n\n", encoded)
+			flusher.Flush()
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		select {
+		case <-r.Context().Done():
+			closed <- true
+			return
+		case <-time.After(hold):
+		}
+
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+		closed <- false
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, closed
+}
+
+func TestChatCompletionStream_StopsAtServiceID(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		want   uint8
+	}{
+		{name: "bare id", tokens: []string{"3", "\n", "explanation"}, want: 3},
+		{name: "two digit id", tokens: []string{"1", "6", " ", "token"}, want: 16},
+		{name: "single digit", tokens: []string{"9", "\n"}, want: 9},
+		{name: "quoted id", tokens: []string{`"`, `7`, `"`}, want: 7},
+		{name: "schema reply", tokens: []string{`{"service_id"`, `: 1`, `2`, `, "service_name": "Consulta do Saldo"}`}, want: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, closed := sseServer(t, tt.tokens, 2*time.Second)
+			c := NewClient(srv.URL)
+
+			data, stats, err := c.ChatCompletionStream(context.Background(), "intent")
+			if err != nil {
+				t.Fatalf("ChatCompletionStream() error: %v", err)
+			}
+
+			service, _ := findService(Services, int(tt.want))
+			if data.ServiceID != tt.want || data.ServiceName != service.Name {
+				t.Errorf("ChatCompletionStream() = %+v, want %d %q", data, tt.want, service.Name)
+			}
+
+			if !stats.EarlyStop || stats.TimeToFirstToken <= 0 || stats.Duration >= time.Second {
+				t.Errorf("stats = %+v, want an early stop with a TTFT", stats)
+			}
+
+			select {
+			case early := <-closed:
+				if !early {
+					t.Error("server finished the stream, want it closed by the client")
+				}
+			case <-time.After(time.Second):
+				t.Error("stream still open after the service ID was decided")
+			}
+		})
+	}
+}
+
+func TestChatCompletionStream_EndOfStream(t *testing.T) {
+	// "1" is only complete once the stream ends without a second digit
+	srv, _ := sseServer(t, []string{"1"}, 0)
+	c := NewClient(srv.URL)
+
+	data, stats, err := c.ChatCompletionStream(context.Background(), "intent")
+	if err != nil {
+		t.Fatalf("ChatCompletionStream() error: %v", err)
+	}
+
+	if data.ServiceID != 1 || stats.EarlyStop {
+		t.Errorf("ChatCompletionStream() = %+v, %+v, want service 1 at the end of the stream", data, stats)
+	}
+}
+
+func TestChatCompletionStream_RepairsProse(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		want   uint8
+	}{
+		{name: "service name", tokens: []string{"O serviço ", "é o ", "Perda e roubo"}, want: 11},
+		// A leading number followed by a letter or a dot is prose, not an ID
+		{name: "ordinal prefix", tokens: []string{"2", "a via ", "da fatura: ", "Segunda via de Fatura"}, want: 3},
+		{name: "numbered list", tokens: []string{"1", ". O cliente ", "quer o ", "Desbloqueio de Cartão"}, want: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := sseServer(t, tt.tokens, 0)
+			c := NewClient(srv.URL)
+
+			data, stats, err := c.ChatCompletionStream(context.Background(), "intent")
+			if err != nil {
+				t.Fatalf("ChatCompletionStream() error: %v", err)
+			}
+
+			if data.ServiceID != tt.want || stats.EarlyStop {
+				t.Errorf("ChatCompletionStream() = %+v, %+v, want service %d at the end of the stream", data, stats, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecidedServiceID(t *testing.T) {
+	tests := []struct {
+		content string
+		final   bool
+		want    uint8
+		ok      bool
+	}{
+		{content: "1", ok: false},
+		{content: "1", final: true, want: 1, ok: true},
+		{content: "10", ok: false},
+		{content: "10 ", want: 10, ok: true},
+		{content: "2\n", want: 2, ok: true},
+		{content: " \"7", ok: false},
+		{content: " \"7\"", want: 7, ok: true},
+		{content: "5}", want: 5, ok: true},
+		{content: `{"service_id": 1`, ok: false},
+		{content: `{"service_id": 1,`, want: 1, ok: true},
+		{content: `{"service_id": "12"`, want: 12, ok: true},
+		{content: "2a via", ok: false},
+		{content: "2a", final: true, ok: false},
+		{content: "1. O cliente", ok: false},
+		{content: "3,", ok: false},
+		{content: "0 ", ok: false},
+		{content: "42 ", ok: false},
+		{content: "ID", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := decidedServiceID(tt.content, Services, tt.final)
+		if ok != tt.ok || got.ID != tt.want {
+			t.Errorf("decidedServiceID(%q, %v) = %d, %v, want %d, %v", tt.content, tt.final, got.ID, ok, tt.want, tt.ok)
+		}
+	}
+}
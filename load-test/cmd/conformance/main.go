@@ -0,0 +1,368 @@
+// Command conformance verifies that a service follows the README contract of
+// /api/find-service and /api/healthz: status codes, Content-Type, the success
+// flag, the data and error fields and the canonical service names.
+//
+//	go run ./cmd/conformance -url http://localhost:18020
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// canonicalServices are the 16 services listed in the README
+var canonicalServices = map[int]string{
+	1:  "Consulta Limite / Vencimento do cartão / Melhor dia de compra",
+	2:  "Segunda via de boleto de acordo",
+	3:  "Segunda via de Fatura",
+	4:  "Status de Entrega do Cartão",
+	5:  "Status de cartão",
+	6:  "Solicitação de aumento de limite",
+	7:  "Cancelamento de cartão",
+	8:  "Telefones de seguradoras",
+	9:  "Desbloqueio de Cartão",
+	10: "Esqueceu senha / Troca de senha",
+	11: "Perda e roubo",
+	12: "Consulta do Saldo",
+	13: "Pagamento de contas",
+	14: "Reclamações",
+	15: "Atendimento humano",
+	16: "Token de proposta",
+}
+
+type (
+	// CheckResult is the outcome of a single contract check
+	CheckResult struct {
+		Name   string `json:"name"`
+		Passed bool   `json:"passed"`
+		Detail string `json:"detail,omitempty"`
+	}
+
+	// Report is the JSON output of a conformance run
+	Report struct {
+		URL       string        `json:"url"`
+		Timestamp string        `json:"timestamp"`
+		Passed    int           `json:"passed"`
+		Failed    int           `json:"failed"`
+		Checks    []CheckResult `json:"checks"`
+	}
+
+	// httpResult is a raw response captured for the checks
+	httpResult struct {
+		status      int
+		contentType string
+		body        []byte
+		err         error
+	}
+
+	checker struct {
+		client  *http.Client
+		baseURL string
+		checks  []CheckResult
+	}
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:18020", "Base URL of the service under test")
+	csvFile := flag.String("csv", "../assets/intents_pre_loaded.csv", "Intents CSV; the first intent of each service is used to probe service names")
+	timeout := flag.Duration("timeout", 20*time.Second, "HTTP client timeout per request")
+	output := flag.String("output", "", "Optional JSON report file")
+	flag.Parse()
+
+	probes, err := readProbes(*csvFile)
+	if err != nil {
+		fmt.Printf("Error reading CSV: %v\n", err)
+		os.Exit(1)
+	}
+
+	c := &checker{
+		client:  &http.Client{Timeout: *timeout},
+		baseURL: strings.TrimSuffix(*baseURL, "/"),
+	}
+
+	c.checkHealthz()
+	c.checkMethods()
+	c.checkMalformedBody()
+	c.checkEmptyIntent()
+
+	for id := 1; id <= len(canonicalServices); id++ {
+		if intent, ok := probes[id]; ok {
+			c.checkFindService(id, intent)
+		}
+	}
+
+	report := Report{
+		URL:       c.baseURL,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Checks:    c.checks,
+	}
+
+	for _, check := range c.checks {
+		mark := "✅"
+		if check.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+			mark = "❌"
+		}
+
+		fmt.Printf("%s %s", mark, check.Name)
+		if check.Detail != "" {
+			fmt.Printf(" - %s", check.Detail)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", report.Passed, report.Failed)
+
+	if *output != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*output, data, 0644)
+		}
+
+		if err != nil {
+			fmt.Printf("Error saving report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// readProbes returns the first intent of every service in the CSV
+func readProbes(filename string) (map[int]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = ';'
+
+	probes := make(map[int]string)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 3 || record[0] == "service_id" {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid service_id %q: %w", record[0], err)
+		}
+
+		if _, ok := probes[id]; !ok {
+			probes[id] = strings.TrimSpace(record[2])
+		}
+	}
+
+	return probes, nil
+}
+
+func (c *checker) do(method, path, body string) httpResult {
+	req, err := http.NewRequest(method, c.baseURL+path, strings.NewReader(body))
+	if err != nil {
+		return httpResult{err: err}
+	}
+
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return httpResult{err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+
+	return httpResult{
+		status:      resp.StatusCode,
+		contentType: resp.Header.Get("Content-Type"),
+		body:        data,
+		err:         err,
+	}
+}
+
+func (c *checker) record(name string, passed bool, detail string, args ...any) {
+	if passed {
+		detail = ""
+	} else if len(args) > 0 {
+		detail = fmt.Sprintf(detail, args...)
+	}
+
+	c.checks = append(c.checks, CheckResult{Name: name, Passed: passed, Detail: detail})
+}
+
+// checkContentType verifies the response is declared as application/json
+func (c *checker) checkContentType(name string, res httpResult) {
+	mediaType, _, _ := mime.ParseMediaType(res.contentType)
+	c.record(name+": Content-Type is application/json", mediaType == "application/json",
+		"got %q", res.contentType)
+}
+
+// decodeObject parses the body as a JSON object keeping raw field values, so
+// the checks can tell a boolean from a string
+func decodeObject(body []byte) (map[string]json.RawMessage, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(bytes.TrimSpace(body), &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (c *checker) checkHealthz() {
+	const name = "GET /api/healthz"
+
+	res := c.do(http.MethodGet, "/api/healthz", "")
+	if res.err != nil {
+		c.record(name+": responds", false, "%v", res.err)
+		return
+	}
+
+	c.record(name+": status 200", res.status == http.StatusOK, "got %d", res.status)
+	c.checkContentType(name, res)
+
+	var body struct {
+		Status string `json:"status"`
+	}
+	err := json.Unmarshal(res.body, &body)
+	c.record(name+`: body is {"status":"ok"}`, err == nil && body.Status == "ok", "got %s", truncate(res.body))
+}
+
+func (c *checker) checkMethods() {
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		name := method + " /api/find-service"
+
+		res := c.do(method, "/api/find-service", "")
+		if res.err != nil {
+			c.record(name+": responds", false, "%v", res.err)
+			continue
+		}
+
+		c.record(name+": status 405", res.status == http.StatusMethodNotAllowed, "got %d", res.status)
+	}
+}
+
+func (c *checker) checkMalformedBody() {
+	c.checkRejected("POST /api/find-service malformed JSON", `{"intent": `)
+}
+
+func (c *checker) checkEmptyIntent() {
+	c.checkRejected("POST /api/find-service empty intent", `{"intent": ""}`)
+	c.checkRejected("POST /api/find-service blank intent", `{"intent": "   "}`)
+	c.checkRejected("POST /api/find-service missing intent", `{}`)
+}
+
+// checkRejected verifies a bad request answers 400 with success false and an error string
+func (c *checker) checkRejected(name, body string) {
+	res := c.do(http.MethodPost, "/api/find-service", body)
+	if res.err != nil {
+		c.record(name+": responds", false, "%v", res.err)
+		return
+	}
+
+	c.record(name+": status 400", res.status == http.StatusBadRequest, "got %d", res.status)
+	c.checkContentType(name, res)
+
+	obj, err := decodeObject(res.body)
+	if err != nil {
+		c.record(name+": body is a JSON object", false, "%v: %s", err, truncate(res.body))
+		return
+	}
+
+	c.record(name+": success is false", string(obj["success"]) == "false", "got %s", rawOrMissing(obj["success"]))
+
+	var errMsg string
+	err = json.Unmarshal(obj["error"], &errMsg)
+	c.record(name+": error is a non-empty string", err == nil && strings.TrimSpace(errMsg) != "",
+		"got %s", rawOrMissing(obj["error"]))
+}
+
+// checkFindService verifies a valid request answers 200 with a well-formed
+// data object carrying a canonical service name
+func (c *checker) checkFindService(expectedID int, intent string) {
+	name := fmt.Sprintf("POST /api/find-service service %d", expectedID)
+
+	payload, _ := json.Marshal(map[string]string{"intent": intent})
+
+	res := c.do(http.MethodPost, "/api/find-service", string(payload))
+	if res.err != nil {
+		c.record(name+": responds", false, "%v", res.err)
+		return
+	}
+
+	c.record(name+": status 200", res.status == http.StatusOK, "got %d", res.status)
+	c.checkContentType(name, res)
+
+	obj, err := decodeObject(res.body)
+	if err != nil {
+		c.record(name+": body is a JSON object", false, "%v: %s", err, truncate(res.body))
+		return
+	}
+
+	c.record(name+": success is true", string(obj["success"]) == "true", "got %s", rawOrMissing(obj["success"]))
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(obj["data"], &data); err != nil || data == nil {
+		c.record(name+": data is an object", false, "got %s", rawOrMissing(obj["data"]))
+		return
+	}
+
+	var id int
+	idErr := json.Unmarshal(data["service_id"], &id)
+	c.record(name+": data.service_id is an integer between 1 and 16", idErr == nil && canonicalServices[id] != "",
+		"got %s", rawOrMissing(data["service_id"]))
+
+	var serviceName string
+	nameErr := json.Unmarshal(data["service_name"], &serviceName)
+	c.record(name+": data.service_name is a string", nameErr == nil, "got %s", rawOrMissing(data["service_name"]))
+
+	if idErr == nil && nameErr == nil && canonicalServices[id] != "" {
+		c.record(name+": data.service_name is canonical for the returned ID", serviceName == canonicalServices[id],
+			"ID %d named %q, expected %q", id, serviceName, canonicalServices[id])
+	}
+
+	if raw, ok := obj["error"]; ok {
+		var errMsg string
+		json.Unmarshal(raw, &errMsg)
+		c.record(name+": error is empty on success", string(raw) == "null" || errMsg == "", "got %s", raw)
+	}
+}
+
+func rawOrMissing(raw json.RawMessage) string {
+	if raw == nil {
+		return "<missing>"
+	}
+	return string(raw)
+}
+
+func truncate(body []byte) string {
+	const maxLen = 200
+	if len(body) > maxLen {
+		return string(body[:maxLen]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,197 @@
+// Command corpus derives an adversarial intent corpus from an intents CSV.
+//
+// Every record is rewritten with each perturbation (typos, accent stripping,
+// casing, abbreviations, filler words, code-switching, prompt injection,
+// emoji, huge input) and labelled with its original service. Inputs a service
+// should refuse (empty, emoji-only, off-topic foreign text, bare prompt
+// injection) are added with service_id 0, meaning an expected rejection.
+//
+// The output keeps the load tester CSV layout plus a perturbation column:
+//
+//	service_id;service_name;intent;perturbation
+//
+// and is run with:
+//
+//	go run ./cmd/corpus -input ../assets/intents_pre_loaded.csv -output robustness.csv
+//	go run . robustness.csv http://localhost:18020/api/find-service report.json
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Record is an intents CSV row
+	Record struct {
+		ServiceID   int
+		ServiceName string
+		Intent      string
+	}
+
+	// Case is a generated corpus row. ServiceID 0 means the service is
+	// expected to reject the intent.
+	Case struct {
+		Record
+		Perturbation string
+	}
+)
+
+func main() {
+	input := flag.String("input", "../assets/intents_pre_loaded.csv", "Intents CSV to derive the corpus from")
+	output := flag.String("output", "robustness.csv", "Output CSV file")
+	seed := flag.Uint64("seed", 1, "Random seed, so the same input always yields the same corpus")
+	types := flag.String("types", "", "Comma-separated perturbations to generate (default: all)")
+	original := flag.Bool("original", true, "Include the unmodified records as the \"original\" baseline")
+	hugeSize := flag.Int("huge-size", 8192, "Approximate size in bytes of huge inputs")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run ./cmd/corpus [flags]")
+		fmt.Printf("\nPerturbations: %s\n\n", strings.Join(perturbationNames(), ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	selected, err := selectPerturbations(*types)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	records, err := readRecords(*input)
+	if err != nil {
+		fmt.Printf("Error reading CSV: %v\n", err)
+		os.Exit(1)
+	}
+
+	g := &generator{
+		rng:      rand.New(rand.NewPCG(*seed, *seed)),
+		hugeSize: *hugeSize,
+	}
+
+	var cases []Case
+	if *original {
+		for _, r := range records {
+			cases = append(cases, Case{Record: r, Perturbation: "original"})
+		}
+	}
+
+	cases = append(cases, g.Generate(records, selected)...)
+
+	if err := writeCases(*output, cases); err != nil {
+		fmt.Printf("Error writing corpus: %v\n", err)
+		os.Exit(1)
+	}
+
+	counts := make(map[string]int)
+	for _, c := range cases {
+		counts[c.Perturbation]++
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Printf("%-18s %d\n", name, counts[name])
+	}
+
+	fmt.Printf("\n%d cases saved to %s\n", len(cases), *output)
+}
+
+func selectPerturbations(types string) ([]perturbation, error) {
+	if types == "" {
+		return perturbations, nil
+	}
+
+	var selected []perturbation
+	for name := range strings.SplitSeq(types, ",") {
+		name = strings.TrimSpace(name)
+
+		i := slices.IndexFunc(perturbations, func(p perturbation) bool { return p.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown perturbation %q (available: %s)", name, strings.Join(perturbationNames(), ", "))
+		}
+
+		selected = append(selected, perturbations[i])
+	}
+
+	return selected, nil
+}
+
+func readRecords(filename string) ([]Record, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(row) < 3 || row[0] == "service_id" {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(row[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid service_id %q: %w", row[0], err)
+		}
+
+		records = append(records, Record{
+			ServiceID:   id,
+			ServiceName: strings.TrimSpace(row[1]),
+			Intent:      strings.TrimSpace(row[2]),
+		})
+	}
+
+	return records, nil
+}
+
+func writeCases(filename string, cases []Case) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Comma = ';'
+
+	if err := w.Write([]string{"service_id", "service_name", "intent", "perturbation"}); err != nil {
+		return err
+	}
+
+	for _, c := range cases {
+		err := w.Write([]string{strconv.Itoa(c.ServiceID), c.ServiceName, c.Intent, c.Perturbation})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
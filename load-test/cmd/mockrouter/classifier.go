@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Service is one of the services listed in the intents CSV
+type Service struct {
+	ID   int
+	Name string
+}
+
+// KeywordClassifier is a deterministic bag-of-words classifier built from the
+// intents CSV. Every token scores the services it appears in by its count,
+// weighted by how few services share it.
+type KeywordClassifier struct {
+	services map[int]Service
+	weights  map[string]map[int]float64
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// stopwords are ignored by the classifier
+var stopwords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "de": true, "da": true, "do": true,
+	"das": true, "dos": true, "e": true, "em": true, "no": true, "na": true, "um": true,
+	"uma": true, "para": true, "pra": true, "por": true, "com": true, "meu": true,
+	"minha": true, "que": true, "eu": true, "quero": true, "me": true, "se": true,
+}
+
+// tokenize lowercases, strips accents and splits text into words
+func tokenize(text string) []string {
+	text = accentReplacer.Replace(strings.ToLower(text))
+
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := fields[:0]
+	for _, f := range fields {
+		if !stopwords[f] {
+			tokens = append(tokens, f)
+		}
+	}
+
+	return tokens
+}
+
+// NewKeywordClassifier reads a service_id;service_name;intent CSV
+func NewKeywordClassifier(filename string) (*KeywordClassifier, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = ';'
+
+	c := &KeywordClassifier{
+		services: make(map[int]Service),
+		weights:  make(map[string]map[int]float64),
+	}
+
+	counts := make(map[string]map[int]int)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 3 || record[0] == "service_id" {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid service_id %q: %w", record[0], err)
+		}
+
+		c.services[id] = Service{ID: id, Name: strings.TrimSpace(record[1])}
+
+		for _, token := range tokenize(record[2]) {
+			if counts[token] == nil {
+				counts[token] = make(map[int]int)
+			}
+			counts[token][id]++
+		}
+	}
+
+	if len(c.services) == 0 {
+		return nil, fmt.Errorf("no intents found in %s", filename)
+	}
+
+	for token, byService := range counts {
+		// tokens shared by many services are weaker evidence
+		idf := math.Log(1 + float64(len(c.services))/float64(len(byService)))
+
+		c.weights[token] = make(map[int]float64, len(byService))
+		for id, n := range byService {
+			c.weights[token][id] = float64(n) * idf
+		}
+	}
+
+	return c, nil
+}
+
+// Classify returns the best matching service, or false when no token of the
+// text is known
+func (c *KeywordClassifier) Classify(text string) (Service, bool) {
+	scores := make(map[int]float64)
+	for _, token := range tokenize(text) {
+		for id, w := range c.weights[token] {
+			scores[id] += w
+		}
+	}
+
+	if len(scores) == 0 {
+		return Service{}, false
+	}
+
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+
+	// ties are broken by the lowest ID to keep answers deterministic
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	return c.services[ids[0]], true
+}
+
+// Service returns the service with the given ID
+func (c *KeywordClassifier) Service(id int) (Service, bool) {
+	s, ok := c.services[id]
+	return s, ok
+}
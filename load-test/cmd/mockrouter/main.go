@@ -0,0 +1,377 @@
+// Command mockrouter is an offline stand-in for the OpenRouter API. It answers
+// /chat/completions from scripted rules or a keyword classifier built from the
+// intents CSV, and can inject latency, 429s, 5xx, malformed JSON and empty
+// choices. Point a client's base URL at it, e.g. http://localhost:8089/api/v1:
+//
+//	go run ./cmd/mockrouter -rules cmd/mockrouter/rules.example.json -rate-429 0.1
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// ChatMessage is a single message of a chat completion request
+	ChatMessage struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+
+	// ChatRequest is the subset of the OpenRouter request the mock understands
+	ChatRequest struct {
+		Model    string        `json:"model"`
+		Messages []ChatMessage `json:"messages"`
+	}
+
+	// ChatResponse mirrors the OpenRouter chat completion response
+	ChatResponse struct {
+		ID      string       `json:"id"`
+		Object  string       `json:"object"`
+		Created int64        `json:"created"`
+		Model   string       `json:"model"`
+		Choices []ChatChoice `json:"choices"`
+		Usage   ChatUsage    `json:"usage"`
+	}
+
+	ChatChoice struct {
+		Index   int `json:"index"`
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	}
+
+	ChatUsage struct {
+		PromptTokens     int     `json:"prompt_tokens"`
+		CompletionTokens int     `json:"completion_tokens"`
+		TotalTokens      int     `json:"total_tokens"`
+		Cost             float64 `json:"cost"`
+	}
+
+	// FaultRates are the probabilities, between 0 and 1, of injecting each fault
+	FaultRates struct {
+		RateLimit   float64
+		ServerError float64
+		Malformed   float64
+		Empty       float64
+	}
+
+	// Server is the mock OpenRouter API
+	Server struct {
+		classifier   *KeywordClassifier
+		rules        []Rule
+		extract      *regexp.Regexp
+		format       string
+		latency      time.Duration
+		jitter       time.Duration
+		faults       FaultRates
+		costPerToken float64
+		limit        float64
+
+		mu       sync.Mutex
+		rng      *rand.Rand
+		requests int
+		usage    float64
+	}
+)
+
+func main() {
+	addr := flag.String("addr", ":8089", "Address to listen on")
+	intentsPath := flag.String("intents", "../assets/intents_pre_loaded.csv", "Intents CSV used by the keyword classifier")
+	rulesPath := flag.String("rules", "", "Optional JSON file with scripted rules, evaluated before the classifier")
+	extract := flag.String("extract", "", "Optional regexp whose first group extracts the intent from the last user message")
+	format := flag.String("format", "json", "Answer format: json ({\"service_id\",\"service_name\"}) or id (bare number)")
+	latency := flag.Duration("latency", 0, "Base latency added to every completion")
+	jitter := flag.Duration("jitter", 0, "Random extra latency up to this value")
+	seed := flag.Uint64("seed", 1, "Seed for jitter and fault injection")
+	costPerToken := flag.Float64("cost-per-token", 0.00000015, "Cost in USD charged per token and reported by /key")
+	limit := flag.Float64("limit", 3, "Credit limit in USD reported by /key")
+
+	var faults FaultRates
+	flag.Float64Var(&faults.RateLimit, "rate-429", 0, "Probability of answering 429 Too Many Requests")
+	flag.Float64Var(&faults.ServerError, "rate-5xx", 0, "Probability of answering 502 Bad Gateway")
+	flag.Float64Var(&faults.Malformed, "rate-malformed", 0, "Probability of answering a malformed JSON body")
+	flag.Float64Var(&faults.Empty, "rate-empty", 0, "Probability of answering with empty choices")
+	flag.Parse()
+
+	if *format != "json" && *format != "id" {
+		fmt.Printf("Error: unknown format %q\n", *format)
+		os.Exit(1)
+	}
+
+	classifier, err := NewKeywordClassifier(*intentsPath)
+	if err != nil {
+		fmt.Printf("Error loading intents: %v\n", err)
+		os.Exit(1)
+	}
+
+	server := &Server{
+		classifier:   classifier,
+		format:       *format,
+		latency:      *latency,
+		jitter:       *jitter,
+		faults:       faults,
+		costPerToken: *costPerToken,
+		limit:        *limit,
+		rng:          rand.New(rand.NewPCG(*seed, *seed)),
+	}
+
+	if *rulesPath != "" {
+		server.rules, err = loadRules(*rulesPath)
+		if err != nil {
+			fmt.Printf("Error loading rules: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *extract != "" {
+		server.extract, err = regexp.Compile(*extract)
+		if err != nil {
+			fmt.Printf("Error compiling extract regexp: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	mux := http.NewServeMux()
+
+	// clients use https://openrouter.ai/api/v1 as base URL, so both the bare
+	// and the prefixed paths are served
+	for _, prefix := range []string{"", "/api/v1"} {
+		mux.HandleFunc("POST "+prefix+"/chat/completions", server.handleChatCompletions)
+		mux.HandleFunc("GET "+prefix+"/key", server.handleKey)
+	}
+
+	log.Printf("Mock OpenRouter listening on %s with %d rules", *addr, len(server.rules))
+
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		fmt.Printf("Server failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	prompt, intent := s.intentFromMessages(req.Messages)
+
+	fault, delay := s.drawFault()
+
+	var content string
+
+	rule, matched := matchRule(s.rules, intent)
+	if matched {
+		delay += rule.delay
+		if rule.Fault != faultNone {
+			fault = rule.Fault
+		}
+		content = rule.Content
+		if content == "" {
+			service, _ := s.classifier.Service(rule.ServiceID)
+			content = s.render(service)
+		}
+	} else {
+		service, _ := s.classifier.Classify(intent)
+		content = s.render(service)
+	}
+
+	log.Printf("model=%s fault=%q delay=%s intent=%q answer=%q", req.Model, fault, delay, intent, content)
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	switch fault {
+	case faultRateLimit:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+		return
+	case faultServerError:
+		writeError(w, http.StatusBadGateway, "Upstream provider error")
+		return
+	case faultMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"gen-mock","choices":[{"message":{"content":`)
+		return
+	}
+
+	usage := ChatUsage{
+		PromptTokens:     estimateTokens(prompt),
+		CompletionTokens: estimateTokens(content),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	usage.Cost = float64(usage.TotalTokens) * s.costPerToken
+
+	s.mu.Lock()
+	s.requests++
+	s.usage += usage.Cost
+	n := s.requests
+	s.mu.Unlock()
+
+	resp := ChatResponse{
+		ID:      "gen-mock-" + strconv.Itoa(n),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+		Choices: []ChatChoice{},
+		Usage:   usage,
+	}
+
+	if fault != faultEmpty {
+		choice := ChatChoice{FinishReason: "stop"}
+		choice.Message.Role = "assistant"
+		choice.Message.Content = content
+		resp.Choices = append(resp.Choices, choice)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	usage := s.usage
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": map[string]any{
+			"label":           "mock",
+			"usage":           usage,
+			"limit":           s.limit,
+			"limit_remaining": s.limit - usage,
+			"is_free_tier":    false,
+		},
+	})
+}
+
+// intentFromMessages returns the whole prompt, used for token accounting, and
+// the intent to classify: the last user message, optionally narrowed by the
+// -extract regexp
+func (s *Server) intentFromMessages(messages []ChatMessage) (string, string) {
+	var prompt strings.Builder
+	var intent string
+
+	for _, m := range messages {
+		text := messageText(m.Content)
+		prompt.WriteString(text)
+		prompt.WriteString("\n")
+
+		if m.Role == "user" {
+			intent = text
+		}
+	}
+
+	if s.extract != nil {
+		if match := s.extract.FindStringSubmatch(intent); len(match) > 1 {
+			intent = match[1]
+		}
+	}
+
+	return prompt.String(), strings.TrimSpace(intent)
+}
+
+// messageText accepts both plain string contents and the array of parts format
+func messageText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, p := range parts {
+		if p.Type == "text" {
+			sb.WriteString(p.Text)
+		}
+	}
+
+	return sb.String()
+}
+
+// render formats the answer content. An unknown service renders as ID 0.
+func (s *Server) render(service Service) string {
+	if s.format == "id" {
+		return strconv.Itoa(service.ID)
+	}
+
+	data, _ := json.Marshal(map[string]any{
+		"service_id":   service.ID,
+		"service_name": service.Name,
+	})
+
+	return string(data)
+}
+
+// drawFault picks an injected fault and the latency of the response
+func (s *Server) drawFault() (string, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delay := s.latency
+	if s.jitter > 0 {
+		delay += time.Duration(s.rng.Int64N(int64(s.jitter)))
+	}
+
+	roll := s.rng.Float64()
+
+	for _, f := range []struct {
+		fault string
+		rate  float64
+	}{
+		{faultRateLimit, s.faults.RateLimit},
+		{faultServerError, s.faults.ServerError},
+		{faultMalformed, s.faults.Malformed},
+		{faultEmpty, s.faults.Empty},
+	} {
+		if roll < f.rate {
+			return f.fault, delay
+		}
+		roll -= f.rate
+	}
+
+	return faultNone, delay
+}
+
+// estimateTokens approximates the token count as one token per four bytes
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// writeError answers with the OpenRouter error body shape
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": message,
+		},
+	})
+}
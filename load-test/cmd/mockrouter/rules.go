@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Faults that can be injected into a response
+const (
+	faultNone        = ""
+	faultRateLimit   = "429"
+	faultServerError = "5xx"
+	faultMalformed   = "malformed"
+	faultEmpty       = "empty"
+)
+
+type (
+	// RulesFile is the JSON document loaded with -rules
+	RulesFile struct {
+		Rules []Rule `json:"rules"`
+	}
+
+	// Rule scripts the answer for intents matching a regular expression.
+	// Rules are evaluated in order and the first match wins.
+	Rule struct {
+		Match     string `json:"match"`
+		ServiceID int    `json:"service_id,omitempty"`
+		// Content replaces the whole assistant message when set
+		Content string `json:"content,omitempty"`
+		// Fault is one of "429", "5xx", "malformed" or "empty"
+		Fault string `json:"fault,omitempty"`
+		// Delay is a Go duration such as "1500ms"
+		Delay string `json:"delay,omitempty"`
+
+		re    *regexp.Regexp
+		delay time.Duration
+	}
+)
+
+func validFault(fault string) bool {
+	switch fault {
+	case faultNone, faultRateLimit, faultServerError, faultMalformed, faultEmpty:
+		return true
+	}
+	return false
+}
+
+// loadRules reads and compiles a rules file
+func loadRules(filename string) ([]Rule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var file RulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	for i := range file.Rules {
+		r := &file.Rules[i]
+
+		r.re, err = regexp.Compile(r.Match)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid match: %w", i, err)
+		}
+
+		if !validFault(r.Fault) {
+			return nil, fmt.Errorf("rule %d: unknown fault %q", i, r.Fault)
+		}
+
+		if r.Delay != "" {
+			r.delay, err = time.ParseDuration(r.Delay)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: invalid delay: %w", i, err)
+			}
+		}
+	}
+
+	return file.Rules, nil
+}
+
+// matchRule returns the first rule matching the intent
+func matchRule(rules []Rule, intent string) (*Rule, bool) {
+	for i := range rules {
+		if rules[i].re.MatchString(intent) {
+			return &rules[i], true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,553 @@
+// Command orchestrator runs the load test against every participant: it starts
+// each service, waits for /api/healthz, runs both datasets, captures logs and
+// resource usage and writes a JSON summary that can be used to resume a run.
+//
+//	go run ./cmd/orchestrator -only crew-das-closures,trovoes-da-taxa
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Participant status values in the summary
+const (
+	statusPassed    = "passed"
+	statusUnhealthy = "unhealthy"
+	statusFailed    = "failed"
+)
+
+type (
+	// Participant is a participant directory under participantes
+	Participant struct {
+		Name string
+		Dir  string
+	}
+
+	// Dataset is a CSV the load tester runs, saved as <Name>.json
+	Dataset struct {
+		Name string
+		CSV  string
+	}
+
+	// Backoff configures the healthz polling
+	Backoff struct {
+		Attempts   int
+		Interval   time.Duration
+		Multiplier float64
+		MaxWait    time.Duration
+		Timeout    time.Duration
+	}
+
+	// Config holds the orchestrator flags
+	Config struct {
+		ParticipantsPath string
+		LoadTestDir      string
+		SummaryPath      string
+		Datasets         []Dataset
+		Only             []string
+		Skip             []string
+		Parallel         int
+		BasePort         int
+		Resume           bool
+		Health           Backoff
+		StatsInterval    time.Duration
+		LoadTestArgs     []string
+	}
+
+	// Summary is the structured result of a whole run
+	Summary struct {
+		StartedAt    string                `json:"started_at"`
+		FinishedAt   string                `json:"finished_at,omitempty"`
+		Participants []*ParticipantSummary `json:"participants"`
+	}
+
+	// ParticipantSummary is the outcome for a single participant
+	ParticipantSummary struct {
+		Name           string    `json:"name"`
+		Status         string    `json:"status"`
+		Error          string    `json:"error,omitempty"`
+		Port           int       `json:"port"`
+		HealthAttempts int       `json:"health_attempts"`
+		StartedAt      string    `json:"started_at"`
+		DurationMs     int64     `json:"duration_ms"`
+		Tests          []TestRun `json:"tests,omitempty"`
+		PeakCPUPercent float64   `json:"peak_cpu_percent"`
+		PeakMemoryMiB  float64   `json:"peak_memory_mib"`
+	}
+
+	// TestRun is the outcome of one dataset
+	TestRun struct {
+		Dataset      string `json:"dataset"`
+		Report       string `json:"report"`
+		Log          string `json:"log"`
+		DurationMs   int64  `json:"duration_ms"`
+		Error        string `json:"error,omitempty"`
+		TotalSuccess int    `json:"total_success"`
+		TotalFailed  int    `json:"total_failed"`
+	}
+)
+
+// ResultsDir is where reports and logs for the participant are written
+func (p Participant) ResultsDir() string {
+	return filepath.Join(p.Dir, "results")
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func main() {
+	var cfg Config
+
+	flag.StringVar(&cfg.ParticipantsPath, "path", "../participantes", "Path to participantes folder")
+	flag.StringVar(&cfg.LoadTestDir, "loadtest", ".", "Directory of the load tester module")
+	flag.StringVar(&cfg.SummaryPath, "summary", "summary.json", "Summary file, also read by -resume")
+	datasets := flag.String("datasets", "93=../assets/intents_pre_loaded.csv,80=../assets/extra_intents.csv", "Comma separated name=csv datasets")
+	only := flag.String("only", "", "Comma separated participants to run (default all)")
+	skip := flag.String("skip", "", "Comma separated participants to skip")
+	flag.IntVar(&cfg.Parallel, "parallel", 1, "Participants tested concurrently (local runner only)")
+	flag.IntVar(&cfg.BasePort, "port", 18020, "Service port; with -parallel each slot uses port+slot")
+	flag.BoolVar(&cfg.Resume, "resume", false, "Skip participants that already passed in the existing summary")
+	runnerName := flag.String("runner", "docker", "How to start services: docker (compose) or local")
+	localCmd := flag.String("local-cmd", "exec go run .", "Shell command starting a service with the local runner; receives PORT")
+	flag.IntVar(&cfg.Health.Attempts, "health-attempts", 5, "Maximum healthz attempts")
+	flag.DurationVar(&cfg.Health.Interval, "health-interval", 2*time.Second, "Wait before the first healthz retry")
+	flag.Float64Var(&cfg.Health.Multiplier, "health-backoff", 2, "Multiplier applied to the wait after each failed attempt")
+	flag.DurationVar(&cfg.Health.MaxWait, "health-max-wait", 30*time.Second, "Maximum wait between healthz attempts")
+	flag.DurationVar(&cfg.Health.Timeout, "health-timeout", 5*time.Second, "Timeout of each healthz request")
+	flag.DurationVar(&cfg.StatsInterval, "stats-interval", 2*time.Second, "Resource usage sampling interval during tests")
+	loadTestArgs := flag.String("loadtest-args", "", "Extra space separated flags passed to the load tester, e.g. \"-workers 10\"")
+	flag.Parse()
+
+	for _, d := range splitList(*datasets) {
+		name, csv, ok := strings.Cut(d, "=")
+		if !ok {
+			fmt.Printf("Error: invalid dataset %q, expected name=csv\n", d)
+			os.Exit(1)
+		}
+		cfg.Datasets = append(cfg.Datasets, Dataset{Name: name, CSV: csv})
+	}
+
+	cfg.Only = splitList(*only)
+	cfg.Skip = splitList(*skip)
+	cfg.LoadTestArgs = strings.Fields(*loadTestArgs)
+
+	var runner ServiceRunner
+	switch *runnerName {
+	case "docker":
+		if cfg.Parallel > 1 {
+			fmt.Println("Error: -parallel requires -runner local, compose files publish a fixed port")
+			os.Exit(1)
+		}
+		runner = dockerRunner{}
+	case "local":
+		runner = newLocalRunner(*localCmd)
+	default:
+		fmt.Printf("Error: unknown runner %q\n", *runnerName)
+		os.Exit(1)
+	}
+
+	if cfg.Parallel < 1 {
+		cfg.Parallel = 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, cfg, runner); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg Config, runner ServiceRunner) error {
+	participants, err := discoverParticipants(cfg.ParticipantsPath, cfg.Only, cfg.Skip)
+	if err != nil {
+		return err
+	}
+
+	summary := &Summary{StartedAt: time.Now().Format(time.RFC3339)}
+
+	if cfg.Resume {
+		previous, err := readSummary(cfg.SummaryPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read summary for resume: %w", err)
+		}
+
+		if previous != nil {
+			summary, participants = resumeSummary(previous, participants)
+		}
+	}
+
+	fmt.Printf("Testing %d participants\n", len(participants))
+
+	loadTestBin, cleanup, err := buildLoadTester(ctx, cfg.LoadTestDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	slots := make(chan int, cfg.Parallel)
+	for i := range cfg.Parallel {
+		slots <- i
+	}
+
+	for _, p := range participants {
+		slot := <-slots
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Go(func() {
+			defer func() { slots <- slot }()
+
+			result := testParticipant(ctx, cfg, runner, loadTestBin, p, cfg.BasePort+slot)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			summary.Participants = slices.DeleteFunc(summary.Participants, func(s *ParticipantSummary) bool {
+				return s.Name == p.Name
+			})
+			summary.Participants = append(summary.Participants, result)
+
+			// the summary is saved after every participant so interrupted runs can resume
+			if err := writeSummary(cfg.SummaryPath, summary); err != nil {
+				fmt.Printf("Error saving summary: %v\n", err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	summary.FinishedAt = time.Now().Format(time.RFC3339)
+	if err := writeSummary(cfg.SummaryPath, summary); err != nil {
+		return err
+	}
+
+	fmt.Printf("Summary saved to %s\n", cfg.SummaryPath)
+
+	return ctx.Err()
+}
+
+// discoverParticipants lists participant directories, applying -only and -skip
+func discoverParticipants(basePath string, only, skip []string) ([]Participant, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var participants []Participant
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		if len(only) > 0 && !slices.Contains(only, name) {
+			continue
+		}
+
+		if slices.Contains(skip, name) {
+			continue
+		}
+
+		participants = append(participants, Participant{
+			Name: name,
+			Dir:  filepath.Join(basePath, name),
+		})
+	}
+
+	for _, name := range only {
+		if !slices.ContainsFunc(participants, func(p Participant) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("participant %q not found in %s", name, basePath)
+		}
+	}
+
+	return participants, nil
+}
+
+// resumeSummary keeps the participants that already passed and returns the
+// ones still to be tested
+func resumeSummary(previous *Summary, participants []Participant) (*Summary, []Participant) {
+	passed := make(map[string]bool)
+	for _, s := range previous.Participants {
+		if s.Status == statusPassed {
+			passed[s.Name] = true
+		}
+	}
+
+	pending := slices.DeleteFunc(participants, func(p Participant) bool {
+		if passed[p.Name] {
+			fmt.Printf("Skipping %s, already passed\n", p.Name)
+			return true
+		}
+		return false
+	})
+
+	previous.FinishedAt = ""
+
+	return previous, pending
+}
+
+// buildLoadTester compiles the load tester once instead of go run per dataset
+func buildLoadTester(ctx context.Context, dir string) (string, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "loadtest")
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	bin := filepath.Join(tmpDir, "loadtest")
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", bin, ".")
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to build load tester: %w", err)
+	}
+
+	return bin, cleanup, nil
+}
+
+func testParticipant(ctx context.Context, cfg Config, runner ServiceRunner, loadTestBin string, p Participant, port int) *ParticipantSummary {
+	start := time.Now()
+
+	result := &ParticipantSummary{
+		Name:      p.Name,
+		Port:      port,
+		StartedAt: start.Format(time.RFC3339),
+	}
+
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+		fmt.Printf("[%s] finished: %s\n", p.Name, result.Status)
+	}()
+
+	fmt.Printf("[%s] starting on port %d...\n", p.Name, port)
+
+	if err := os.MkdirAll(p.ResultsDir(), 0755); err != nil {
+		result.Status = statusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	// a previous run may have been interrupted
+	runner.Stop(context.WithoutCancel(ctx), p)
+
+	defer func() {
+		if err := runner.Stop(context.WithoutCancel(ctx), p); err != nil {
+			fmt.Printf("[%s] error stopping service: %v\n", p.Name, err)
+		}
+	}()
+
+	if err := runner.Start(ctx, p, port); err != nil {
+		result.Status = statusFailed
+		result.Error = err.Error()
+		captureLogs(ctx, runner, p)
+		return result
+	}
+
+	baseURL := fmt.Sprintf("http://localhost:%d", port)
+
+	attempts, err := waitHealthy(ctx, baseURL+"/api/healthz", cfg.Health)
+	result.HealthAttempts = attempts
+	if err != nil {
+		result.Status = statusUnhealthy
+		result.Error = err.Error()
+		captureLogs(ctx, runner, p)
+		writeErrorLog(p, baseURL, attempts)
+		return result
+	}
+
+	sampler := startSampler(ctx, runner, p, cfg.StatsInterval)
+
+	result.Status = statusPassed
+	for _, dataset := range cfg.Datasets {
+		test := runDataset(ctx, cfg, loadTestBin, p, dataset, baseURL+"/api/find-service")
+		if test.Error != "" {
+			result.Status = statusFailed
+			result.Error = fmt.Sprintf("dataset %s: %s", dataset.Name, test.Error)
+		}
+		result.Tests = append(result.Tests, test)
+	}
+
+	result.PeakCPUPercent, result.PeakMemoryMiB = sampler.Stop()
+
+	captureLogs(ctx, runner, p)
+
+	return result
+}
+
+// waitHealthy polls url with exponential backoff until it answers 200
+func waitHealthy(ctx context.Context, url string, b Backoff) (int, error) {
+	client := &http.Client{Timeout: b.Timeout}
+	wait := b.Interval
+
+	var lastErr error
+	for attempt := 1; attempt <= b.Attempts; attempt++ {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return attempt, nil
+			}
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+
+		lastErr = err
+		fmt.Printf("healthz attempt %d of %d failed: %v\n", attempt, b.Attempts, err)
+
+		if attempt == b.Attempts {
+			break
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		}
+
+		wait = min(time.Duration(float64(wait)*b.Multiplier), b.MaxWait)
+	}
+
+	return b.Attempts, fmt.Errorf("healthz did not respond after %d attempts: %w", b.Attempts, lastErr)
+}
+
+// runDataset runs the load tester for a dataset, logging to its own file
+func runDataset(ctx context.Context, cfg Config, loadTestBin string, p Participant, dataset Dataset, endpoint string) (test TestRun) {
+	start := time.Now()
+
+	test = TestRun{
+		Dataset: dataset.Name,
+		Report:  filepath.Join(p.ResultsDir(), dataset.Name+".json"),
+		Log:     filepath.Join(p.ResultsDir(), "test-"+dataset.Name+".logs"),
+	}
+
+	defer func() {
+		test.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	fmt.Printf("[%s] running dataset %s...\n", p.Name, dataset.Name)
+
+	logFile, err := os.Create(test.Log)
+	if err != nil {
+		test.Error = err.Error()
+		return test
+	}
+	defer logFile.Close()
+
+	csvPath, err := filepath.Abs(filepath.Join(cfg.LoadTestDir, dataset.CSV))
+	if err != nil {
+		test.Error = err.Error()
+		return test
+	}
+
+	args := append([]string{}, cfg.LoadTestArgs...)
+	args = append(args,
+		"-results", filepath.Join(p.ResultsDir(), dataset.Name+".results.jsonl"),
+		csvPath, endpoint, test.Report,
+	)
+
+	cmd := exec.CommandContext(ctx, loadTestBin, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Run(); err != nil {
+		test.Error = err.Error()
+		return test
+	}
+
+	var report struct {
+		TotalSuccess int `json:"total_success"`
+		TotalFailed  int `json:"total_failed"`
+	}
+
+	data, err := os.ReadFile(test.Report)
+	if err == nil {
+		err = json.Unmarshal(data, &report)
+	}
+
+	if err != nil {
+		test.Error = fmt.Sprintf("failed to read report: %v", err)
+		return test
+	}
+
+	test.TotalSuccess = report.TotalSuccess
+	test.TotalFailed = report.TotalFailed
+
+	return test
+}
+
+// captureLogs saves the service logs next to the results
+func captureLogs(ctx context.Context, runner ServiceRunner, p Participant) {
+	file, err := os.Create(filepath.Join(p.ResultsDir(), "service-output.logs"))
+	if err != nil {
+		fmt.Printf("[%s] error creating log file: %v\n", p.Name, err)
+		return
+	}
+	defer file.Close()
+
+	if err := runner.Logs(context.WithoutCancel(ctx), p, file); err != nil {
+		fmt.Printf("[%s] error capturing logs: %v\n", p.Name, err)
+	}
+}
+
+// writeErrorLog keeps the message run.sh used to leave for unhealthy services
+func writeErrorLog(p Participant, baseURL string, attempts int) {
+	now := time.Now().Format(time.UnixDate)
+	msg := fmt.Sprintf("[%s] Seu backend não respondeu nenhuma das %d tentativas de GET para %s/api/healthz. Teste abortado.\n"+
+		"[%s] Inspecione o arquivo docker-compose.logs para mais informações.\n", now, attempts, baseURL, now)
+
+	if err := os.WriteFile(filepath.Join(p.Dir, "error.logs"), []byte(msg), 0644); err != nil {
+		fmt.Printf("[%s] error writing error.logs: %v\n", p.Name, err)
+	}
+}
+
+func readSummary(path string) (*Summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+func writeSummary(path string, summary *Summary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type (
+	// ServiceRunner starts and stops a participant's service
+	ServiceRunner interface {
+		// Start builds and starts the service listening on port
+		Start(ctx context.Context, p Participant, port int) error
+		// Logs writes the service logs to w
+		Logs(ctx context.Context, p Participant, w io.Writer) error
+		// Stats samples resource usage of the running service
+		Stats(ctx context.Context, p Participant) ([]ResourceStats, error)
+		// Stop stops the service and removes its resources
+		Stop(ctx context.Context, p Participant) error
+	}
+
+	// ResourceStats is one resource usage sample of a container or process
+	ResourceStats struct {
+		Time       string  `json:"time"`
+		Name       string  `json:"name"`
+		CPUPercent float64 `json:"cpu_percent"`
+		MemoryMiB  float64 `json:"memory_mib"`
+	}
+
+	// dockerRunner runs services with docker compose. Compose files publish a
+	// fixed port, so only one participant can run at a time.
+	dockerRunner struct{}
+
+	// localRunner runs services with a shell command in the participant
+	// directory, passing the port through the PORT environment variable
+	localRunner struct {
+		command string
+
+		mu    sync.Mutex
+		procs map[string]*exec.Cmd
+	}
+)
+
+func newLocalRunner(command string) *localRunner {
+	return &localRunner{
+		command: command,
+		procs:   make(map[string]*exec.Cmd),
+	}
+}
+
+// runIn runs a command in dir, appending its combined output to w
+func runIn(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	return cmd.Run()
+}
+
+func (dockerRunner) Start(ctx context.Context, p Participant, port int) error {
+	logFile, err := os.Create(filepath.Join(p.ResultsDir(), "docker-compose.logs"))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	if err := runIn(ctx, p.Dir, logFile, "docker", "compose", "up", "--build", "--wait", "-d"); err != nil {
+		return fmt.Errorf("docker compose up: %w", err)
+	}
+
+	return nil
+}
+
+func (dockerRunner) Logs(ctx context.Context, p Participant, w io.Writer) error {
+	return runIn(ctx, p.Dir, w, "docker", "compose", "logs", "--no-color", "--timestamps")
+}
+
+func (dockerRunner) Stats(ctx context.Context, p Participant) ([]ResourceStats, error) {
+	var ids bytes.Buffer
+	if err := runIn(ctx, p.Dir, &ids, "docker", "compose", "ps", "-q"); err != nil {
+		return nil, fmt.Errorf("docker compose ps: %w", err)
+	}
+
+	containers := strings.Fields(ids.String())
+	if len(containers) == 0 {
+		return nil, nil
+	}
+
+	var out bytes.Buffer
+	args := append([]string{"stats", "--no-stream", "--format", "{{json .}}"}, containers...)
+	if err := runIn(ctx, p.Dir, &out, "docker", args...); err != nil {
+		return nil, fmt.Errorf("docker stats: %w", err)
+	}
+
+	now := time.Now().Format(time.RFC3339Nano)
+
+	var stats []ResourceStats
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var raw struct {
+			Name     string `json:"Name"`
+			CPUPerc  string `json:"CPUPerc"`
+			MemUsage string `json:"MemUsage"`
+		}
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			continue
+		}
+
+		stats = append(stats, ResourceStats{
+			Time:       now,
+			Name:       raw.Name,
+			CPUPercent: parsePercent(raw.CPUPerc),
+			MemoryMiB:  parseMemoryMiB(raw.MemUsage),
+		})
+	}
+
+	return stats, nil
+}
+
+func (dockerRunner) Stop(ctx context.Context, p Participant) error {
+	if err := runIn(ctx, p.Dir, io.Discard, "docker", "compose", "down", "-v", "--remove-orphans"); err != nil {
+		return fmt.Errorf("docker compose down: %w", err)
+	}
+
+	return runIn(ctx, p.Dir, io.Discard, "docker", "compose", "rm", "-s", "-v", "-f")
+}
+
+func (r *localRunner) Start(ctx context.Context, p Participant, port int) error {
+	logFile, err := os.Create(filepath.Join(p.ResultsDir(), "service.logs"))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("sh", "-c", r.command)
+	cmd.Dir = p.Dir
+	cmd.Env = append(os.Environ(), "PORT="+strconv.Itoa(port))
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// run in its own process group so Stop also kills children such as go run builds
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return fmt.Errorf("start %q: %w", r.command, err)
+	}
+
+	go func() {
+		cmd.Wait()
+		logFile.Close()
+	}()
+
+	r.mu.Lock()
+	r.procs[p.Name] = cmd
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Logs is a no-op: the service output is already written to service.logs
+func (r *localRunner) Logs(ctx context.Context, p Participant, w io.Writer) error {
+	return nil
+}
+
+// Stats reads the resident memory of the process group leader from /proc
+func (r *localRunner) Stats(ctx context.Context, p Participant) ([]ResourceStats, error) {
+	r.mu.Lock()
+	cmd, ok := r.procs[p.Name]
+	r.mu.Unlock()
+
+	if !ok || cmd.Process == nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", cmd.Process.Pid))
+	if err != nil {
+		return nil, nil
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "VmRSS:"); ok {
+			kb, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(rest), " kB"), 64)
+
+			return []ResourceStats{{
+				Time:      time.Now().Format(time.RFC3339Nano),
+				Name:      p.Name,
+				MemoryMiB: kb / 1024,
+			}}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *localRunner) Stop(ctx context.Context, p Participant) error {
+	r.mu.Lock()
+	cmd, ok := r.procs[p.Name]
+	delete(r.procs, p.Name)
+	r.mu.Unlock()
+
+	if !ok || cmd.Process == nil {
+		return nil
+	}
+
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// parsePercent parses docker values such as "12.34%"
+func parsePercent(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	return v
+}
+
+// parseMemoryMiB parses the usage part of docker values such as "50.2MiB / 128MiB"
+func parseMemoryMiB(s string) float64 {
+	usage, _, _ := strings.Cut(s, "/")
+	usage = strings.TrimSpace(usage)
+
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"GiB", 1024},
+		{"MiB", 1},
+		{"KiB", 1.0 / 1024},
+		{"GB", 1e9 / (1 << 20)},
+		{"MB", 1e6 / (1 << 20)},
+		{"kB", 1e3 / (1 << 20)},
+		{"B", 1.0 / (1 << 20)},
+	}
+
+	for _, u := range units {
+		if num, ok := strings.CutSuffix(usage, u.suffix); ok {
+			v, _ := strconv.ParseFloat(num, 64)
+			return v * u.factor
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sampler periodically records resource usage to results/stats.jsonl
+type sampler struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	peakCPU float64
+	peakMem float64
+}
+
+func startSampler(ctx context.Context, runner ServiceRunner, p Participant, interval time.Duration) *sampler {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s := &sampler{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+
+		file, err := os.Create(filepath.Join(p.ResultsDir(), "stats.jsonl"))
+		if err != nil {
+			fmt.Printf("[%s] error creating stats file: %v\n", p.Name, err)
+			return
+		}
+		defer file.Close()
+
+		enc := json.NewEncoder(file)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			stats, err := runner.Stats(ctx, p)
+			if err != nil && ctx.Err() == nil {
+				fmt.Printf("[%s] error sampling stats: %v\n", p.Name, err)
+			}
+
+			for _, st := range stats {
+				enc.Encode(st)
+				s.peakCPU = max(s.peakCPU, st.CPUPercent)
+				s.peakMem = max(s.peakMem, st.MemoryMiB)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Stop ends sampling and returns the peak CPU percentage and memory in MiB
+func (s *sampler) Stop() (float64, float64) {
+	s.cancel()
+	<-s.done
+
+	return s.peakCPU, s.peakMem
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const detailPagesDir = "participants"
+
+type (
+	// serviceRow compares one service across both tests
+	serviceRow struct {
+		ServiceID   int
+		ServiceName string
+		Test93      *ServiceStats
+		Test80      *ServiceStats
+	}
+
+	// histogramBar is a coarse latency bucket ready for rendering
+	histogramBar struct {
+		Label   string
+		Count   int
+		Percent float64
+	}
+
+	// detailPageData feeds detailTemplate
+	detailPageData struct {
+		Participant ParticipantResult
+		Rank        int
+		Profile     ScoringProfile
+		Services    []serviceRow
+		Histogram93 []histogramBar
+		Histogram80 []histogramBar
+		ReportPage  string
+		GeneratedAt string
+	}
+)
+
+// detailPage returns the drill-down page path relative to the main report
+func detailPage(name string) string {
+	return detailPagesDir + "/" + url.PathEscape(name) + ".html"
+}
+
+// generateDetailPages writes one drill-down page per participant next to the main report
+func generateDetailPages(participants []ParticipantResult, profile ScoringProfile, outputPath string) error {
+	dir := filepath.Join(filepath.Dir(outputPath), detailPagesDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create pages directory: %w", err)
+	}
+
+	funcMap := template.FuncMap{
+		"formatTime": formatTime,
+		"percent": func(v float64) string {
+			return fmt.Sprintf("%.1f%%", v*100)
+		},
+	}
+
+	tmpl := template.Must(template.New("detail").Funcs(funcMap).Parse(detailTemplate))
+
+	for i, p := range participants {
+		data := detailPageData{
+			Participant: p,
+			Rank:        i + 1,
+			Profile:     profile,
+			Services:    serviceRows(p),
+			Histogram93: coarseHistogram(p.Test93),
+			Histogram80: coarseHistogram(p.Test80),
+			ReportPage:  "../" + url.PathEscape(filepath.Base(outputPath)),
+			GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		}
+
+		if err := writeDetailPage(tmpl, data, filepath.Join(dir, p.Name+".html")); err != nil {
+			return fmt.Errorf("participant %s: %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func writeDetailPage(tmpl *template.Template, data detailPageData, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, data)
+}
+
+// serviceRows merges the per-service stats of both tests, if any
+func serviceRows(p ParticipantResult) []serviceRow {
+	var rows []serviceRow
+
+	index := make(map[int]int)
+
+	add := func(test *TestResult, set func(row *serviceRow, s *ServiceStats)) {
+		if test == nil {
+			return
+		}
+
+		for i := range test.ServiceStats {
+			s := &test.ServiceStats[i]
+
+			idx, ok := index[s.ServiceID]
+			if !ok {
+				idx = len(rows)
+				index[s.ServiceID] = idx
+				rows = append(rows, serviceRow{ServiceID: s.ServiceID, ServiceName: s.ServiceName})
+			}
+
+			set(&rows[idx], s)
+		}
+	}
+
+	add(p.Test93, func(row *serviceRow, s *ServiceStats) { row.Test93 = s })
+	add(p.Test80, func(row *serviceRow, s *ServiceStats) { row.Test80 = s })
+
+	return rows
+}
+
+// coarseHistogram regroups the fine-grained latency histogram into
+// power-of-two millisecond buckets
+func coarseHistogram(test *TestResult) []histogramBar {
+	if test == nil || test.Latency == nil || len(test.Latency.Histogram) == 0 {
+		return nil
+	}
+
+	counts := make(map[int]int)
+	maxExp, total := 0, 0
+
+	for _, b := range test.Latency.Histogram {
+		exp := 0
+		if b.UpperMs > 1 {
+			exp = int(math.Ceil(math.Log2(b.UpperMs)))
+		}
+
+		counts[exp] += b.Count
+		total += b.Count
+		maxExp = max(maxExp, exp)
+	}
+
+	bars := make([]histogramBar, 0, maxExp+1)
+	for exp := 0; exp <= maxExp; exp++ {
+		label := fmt.Sprintf("≤ %dms", 1<<exp)
+
+		bars = append(bars, histogramBar{
+			Label:   label,
+			Count:   counts[exp],
+			Percent: float64(counts[exp]) / float64(total) * 100,
+		})
+	}
+
+	return bars
+}
+
+const detailTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Participant.Name}} - Load Test Details</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            padding: 20px;
+            min-height: 100vh;
+        }
+
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background: white;
+            border-radius: 12px;
+            box-shadow: 0 20px 60px rgba(0,0,0,0.3);
+            overflow: hidden;
+        }
+
+        .header {
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            color: white;
+            padding: 40px;
+            text-align: center;
+        }
+
+        .header h1 { font-size: 2.2em; margin-bottom: 10px; }
+        .header a { color: white; opacity: 0.9; }
+
+        .section { padding: 30px 40px; border-bottom: 3px solid #e9ecef; }
+        .section h2 { color: #495057; margin-bottom: 20px; font-size: 1.5em; }
+
+        table {
+            width: 100%;
+            border-collapse: collapse;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+        }
+
+        th {
+            background: #667eea;
+            color: white;
+            padding: 12px;
+            text-align: left;
+            font-size: 0.85em;
+            text-transform: uppercase;
+        }
+
+        td { padding: 12px; border-bottom: 1px solid #e9ecef; color: #495057; }
+        .na { color: #adb5bd; }
+        .low { color: #dc3545; font-weight: 600; }
+
+        .histograms {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(400px, 1fr));
+            gap: 30px;
+        }
+
+        .bar-row { display: flex; align-items: center; margin: 4px 0; font-size: 0.9em; }
+        .bar-label { width: 100px; color: #6c757d; }
+        .bar { background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); height: 18px; border-radius: 3px; margin-right: 8px; }
+        .bar-count { color: #495057; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>#{{.Rank}} {{.Participant.Name}}</h1>
+            <div>Score: {{printf "%.2f" .Participant.Score}} points ({{.Profile.Name}} profile)</div>
+            <div>Generated at: {{.GeneratedAt}} · <a href="{{.ReportPage}}">Back to rankings</a></div>
+        </div>
+
+        <div class="section">
+            <h2>Test 93 vs Test 80</h2>
+            <table>
+                <thead>
+                    <tr><th>Metric</th><th>Test 93</th><th>Test 80</th></tr>
+                </thead>
+                <tbody>
+                    {{$t93 := .Participant.Test93}}
+                    {{$t80 := .Participant.Test80}}
+                    <tr>
+                        <td>Total Requests</td>
+                        <td>{{if $t93}}{{$t93.TotalRequests}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                        <td>{{if $t80}}{{$t80.TotalRequests}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                    </tr>
+                    <tr>
+                        <td>Success</td>
+                        <td>{{if $t93}}{{$t93.TotalSuccess}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                        <td>{{if $t80}}{{$t80.TotalSuccess}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                    </tr>
+                    <tr>
+                        <td>Failed</td>
+                        <td>{{if $t93}}{{$t93.TotalFailed}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                        <td>{{if $t80}}{{$t80.TotalFailed}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                    </tr>
+                    <tr>
+                        <td>Success Rate</td>
+                        <td>{{if $t93}}{{printf "%.1f" $t93.SuccessRate}}%{{else}}<span class="na">N/A</span>{{end}}</td>
+                        <td>{{if $t80}}{{printf "%.1f" $t80.SuccessRate}}%{{else}}<span class="na">N/A</span>{{end}}</td>
+                    </tr>
+                    <tr>
+                        <td>Average Time</td>
+                        <td>{{formatTime .Participant.AvgTime93}}</td>
+                        <td>{{formatTime .Participant.AvgTime80}}</td>
+                    </tr>
+                    <tr>
+                        <td>P50 / P95 / P99</td>
+                        <td>{{if and $t93 $t93.Latency}}{{formatTime $t93.Latency.P50Ms}} / {{formatTime $t93.Latency.P95Ms}} / {{formatTime $t93.Latency.P99Ms}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                        <td>{{if and $t80 $t80.Latency}}{{formatTime $t80.Latency.P50Ms}} / {{formatTime $t80.Latency.P95Ms}} / {{formatTime $t80.Latency.P99Ms}}{{else}}<span class="na">N/A</span>{{end}}</td>
+                    </tr>
+                </tbody>
+            </table>
+        </div>
+
+        <div class="section">
+            <h2>Per-Service Accuracy</h2>
+            {{if .Services}}
+            <table>
+                <thead>
+                    <tr>
+                        <th>ID</th>
+                        <th>Service</th>
+                        <th>Recall (93)</th>
+                        <th>Precision (93)</th>
+                        <th>Recall (80)</th>
+                        <th>Precision (80)</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Services}}
+                    <tr>
+                        <td>{{.ServiceID}}</td>
+                        <td>{{.ServiceName}}</td>
+                        {{with .Test93}}
+                        <td{{if lt .Recall 1.0}} class="low"{{end}}>{{percent .Recall}} ({{.TruePositives}}/{{.Support}})</td>
+                        <td{{if lt .Precision 1.0}} class="low"{{end}}>{{percent .Precision}}</td>
+                        {{else}}
+                        <td class="na">N/A</td><td class="na">N/A</td>
+                        {{end}}
+                        {{with .Test80}}
+                        <td{{if lt .Recall 1.0}} class="low"{{end}}>{{percent .Recall}} ({{.TruePositives}}/{{.Support}})</td>
+                        <td{{if lt .Precision 1.0}} class="low"{{end}}>{{percent .Precision}}</td>
+                        {{else}}
+                        <td class="na">N/A</td><td class="na">N/A</td>
+                        {{end}}
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+            {{else}}
+            <p class="na">No per-service results available. Re-run the load tester to produce them.</p>
+            {{end}}
+        </div>
+
+        <div class="section">
+            <h2>Latency Distribution</h2>
+            <div class="histograms">
+                <div>
+                    <h3>Test 93</h3>
+                    {{range .Histogram93}}
+                    <div class="bar-row">
+                        <span class="bar-label">{{.Label}}</span>
+                        <span class="bar" style="width: {{printf "%.1f" .Percent}}%;"></span>
+                        <span class="bar-count">{{.Count}}</span>
+                    </div>
+                    {{else}}
+                    <p class="na">No latency histogram available</p>
+                    {{end}}
+                </div>
+                <div>
+                    <h3>Test 80</h3>
+                    {{range .Histogram80}}
+                    <div class="bar-row">
+                        <span class="bar-label">{{.Label}}</span>
+                        <span class="bar" style="width: {{printf "%.1f" .Percent}}%;"></span>
+                        <span class="bar-count">{{.Count}}</span>
+                    </div>
+                    {{else}}
+                    <p class="na">No latency histogram available</p>
+                    {{end}}
+                </div>
+            </div>
+        </div>
+    </div>
+</body>
+</html>`
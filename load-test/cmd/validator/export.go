@@ -0,0 +1,345 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats
+const (
+	formatHTML     = "html"
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatMarkdown = "md"
+)
+
+type (
+	// RankingSnapshot is the JSON export of a ranking, also read back by -diff
+	RankingSnapshot struct {
+		GeneratedAt  string              `json:"generated_at"`
+		Profile      ScoringProfile      `json:"profile"`
+		Participants []RankedParticipant `json:"participants"`
+	}
+
+	// RankedParticipant is a participant result with its position in the ranking
+	RankedParticipant struct {
+		Rank int `json:"rank"`
+		ParticipantResult
+	}
+
+	// RankChange describes how a participant moved between two snapshots.
+	// Ranks are 0 when the participant is missing from a snapshot.
+	RankChange struct {
+		Name       string  `json:"name"`
+		OldRank    int     `json:"old_rank"`
+		NewRank    int     `json:"new_rank"`
+		Movement   int     `json:"movement"` // positive means moved up
+		OldScore   float64 `json:"old_score"`
+		NewScore   float64 `json:"new_score"`
+		ScoreDelta float64 `json:"score_delta"`
+		Status     string  `json:"status"` // new, removed or empty
+	}
+)
+
+func validFormat(format string) bool {
+	switch format {
+	case formatHTML, formatJSON, formatCSV, formatMarkdown:
+		return true
+	}
+	return false
+}
+
+func newRankingSnapshot(participants []ParticipantResult, profile ScoringProfile) RankingSnapshot {
+	snapshot := RankingSnapshot{
+		GeneratedAt:  time.Now().Format(time.RFC3339),
+		Profile:      profile,
+		Participants: make([]RankedParticipant, len(participants)),
+	}
+
+	for i, p := range participants {
+		snapshot.Participants[i] = RankedParticipant{Rank: i + 1, ParticipantResult: p}
+	}
+
+	return snapshot
+}
+
+// exportRanking writes the ranking in a machine-readable format
+func exportRanking(participants []ParticipantResult, profile ScoringProfile, format, outputPath string) error {
+	snapshot := newRankingSnapshot(participants, profile)
+
+	return writeOutput(outputPath, func(w io.Writer) error {
+		switch format {
+		case formatJSON:
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(snapshot)
+		case formatCSV:
+			return writeRankingCSV(w, snapshot)
+		case formatMarkdown:
+			return writeRankingMarkdown(w, snapshot)
+		}
+
+		return fmt.Errorf("unsupported format %q", format)
+	})
+}
+
+// writeOutput writes to outputPath, or to stdout when it is empty
+func writeOutput(outputPath string, write func(w io.Writer) error) error {
+	if outputPath == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	return write(file)
+}
+
+func successRate(t *TestResult) string {
+	if t == nil {
+		return ""
+	}
+	return strconv.FormatFloat(t.SuccessRate, 'f', 2, 64)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func writeRankingCSV(w io.Writer, snapshot RankingSnapshot) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{
+		"rank", "name", "score", "total_success", "total_failed",
+		"success_rate_93", "success_rate_80", "avg_time_93_ms", "avg_time_80_ms",
+		"p95_time_93_ms", "p95_time_80_ms",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range snapshot.Participants {
+		err := cw.Write([]string{
+			strconv.Itoa(p.Rank),
+			p.Name,
+			formatFloat(p.Score),
+			strconv.Itoa(p.TotalSuccess),
+			strconv.Itoa(p.TotalFailed),
+			successRate(p.Test93),
+			successRate(p.Test80),
+			formatFloat(p.AvgTime93),
+			formatFloat(p.AvgTime80),
+			formatFloat(p.P95Time93),
+			formatFloat(p.P95Time80),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeRankingMarkdown(w io.Writer, snapshot RankingSnapshot) error {
+	var sb strings.Builder
+
+	sb.WriteString("## 🏅 Rankings\n\n")
+	fmt.Fprintf(&sb, "Profile `%s`: `%s`\n\n", snapshot.Profile.Name, snapshot.Profile.FormulaText())
+	sb.WriteString("| Rank | Participant | Success | Failed | Avg Time (93) | Avg Time (80) | P95 (93) | P95 (80) | Score |\n")
+	sb.WriteString("|---:|---|---:|---:|---:|---:|---:|---:|---:|\n")
+
+	for _, p := range snapshot.Participants {
+		fmt.Fprintf(&sb, "| %d | %s | %d | %d | %s | %s | %s | %s | %.2f |\n",
+			p.Rank, p.Name, p.TotalSuccess, p.TotalFailed,
+			formatTime(p.AvgTime93), formatTime(p.AvgTime80),
+			formatTime(p.P95Time93), formatTime(p.P95Time80), p.Score)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func readRankingSnapshot(path string) (*RankingSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot RankingSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	return &snapshot, nil
+}
+
+// runDiff compares the old snapshot with the against snapshot, or with the
+// ranking computed from the participants folder when against is empty
+func runDiff(oldPath, againstPath, participantesPath string, profile ScoringProfile, format, outputPath string) error {
+	if format == formatHTML {
+		return fmt.Errorf("format %q is not supported with -diff", format)
+	}
+
+	oldSnapshot, err := readRankingSnapshot(oldPath)
+	if err != nil {
+		return err
+	}
+
+	var newSnapshot *RankingSnapshot
+	if againstPath != "" {
+		newSnapshot, err = readRankingSnapshot(againstPath)
+		if err != nil {
+			return err
+		}
+	} else {
+		participants, err := rankParticipants(participantesPath, profile)
+		if err != nil {
+			return err
+		}
+
+		snapshot := newRankingSnapshot(participants, profile)
+		newSnapshot = &snapshot
+	}
+
+	changes := diffRankings(oldSnapshot, newSnapshot)
+
+	return writeOutput(outputPath, func(w io.Writer) error {
+		switch format {
+		case formatJSON:
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(changes)
+		case formatCSV:
+			return writeDiffCSV(w, changes)
+		}
+
+		return writeDiffMarkdown(w, changes)
+	})
+}
+
+// diffRankings lists every participant of both snapshots ordered by new rank,
+// followed by the removed ones
+func diffRankings(oldSnapshot, newSnapshot *RankingSnapshot) []RankChange {
+	old := make(map[string]RankedParticipant, len(oldSnapshot.Participants))
+	for _, p := range oldSnapshot.Participants {
+		old[p.Name] = p
+	}
+
+	changes := make([]RankChange, 0, len(newSnapshot.Participants))
+	seen := make(map[string]bool, len(newSnapshot.Participants))
+
+	for _, p := range newSnapshot.Participants {
+		seen[p.Name] = true
+
+		change := RankChange{
+			Name:     p.Name,
+			NewRank:  p.Rank,
+			NewScore: p.Score,
+		}
+
+		if prev, ok := old[p.Name]; ok {
+			change.OldRank = prev.Rank
+			change.OldScore = prev.Score
+			change.Movement = prev.Rank - p.Rank
+			change.ScoreDelta = p.Score - prev.Score
+		} else {
+			change.Status = "new"
+		}
+
+		changes = append(changes, change)
+	}
+
+	var removed []RankChange
+	for _, p := range oldSnapshot.Participants {
+		if !seen[p.Name] {
+			removed = append(removed, RankChange{
+				Name:     p.Name,
+				OldRank:  p.Rank,
+				OldScore: p.Score,
+				Status:   "removed",
+			})
+		}
+	}
+
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].OldRank < removed[j].OldRank
+	})
+
+	return append(changes, removed...)
+}
+
+func writeDiffCSV(w io.Writer, changes []RankChange) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{"name", "old_rank", "new_rank", "movement", "old_score", "new_score", "score_delta", "status"})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		err := cw.Write([]string{
+			c.Name,
+			strconv.Itoa(c.OldRank),
+			strconv.Itoa(c.NewRank),
+			strconv.Itoa(c.Movement),
+			formatFloat(c.OldScore),
+			formatFloat(c.NewScore),
+			formatFloat(c.ScoreDelta),
+			c.Status,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeDiffMarkdown(w io.Writer, changes []RankChange) error {
+	var sb strings.Builder
+
+	sb.WriteString("## 📈 Ranking Changes\n\n")
+	sb.WriteString("| Rank | Participant | Movement | Score | Δ Score |\n")
+	sb.WriteString("|---:|---|:---:|---:|---:|\n")
+
+	for _, c := range changes {
+		switch c.Status {
+		case "new":
+			fmt.Fprintf(&sb, "| %d | %s | 🆕 | %.2f | |\n", c.NewRank, c.Name, c.NewScore)
+		case "removed":
+			fmt.Fprintf(&sb, "| - | %s | ❌ was #%d | | |\n", c.Name, c.OldRank)
+		default:
+			fmt.Fprintf(&sb, "| %d | %s | %s | %.2f | %+.2f |\n",
+				c.NewRank, c.Name, movementText(c.Movement), c.NewScore, c.ScoreDelta)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func movementText(movement int) string {
+	switch {
+	case movement > 0:
+		return fmt.Sprintf("⬆️ %d", movement)
+	case movement < 0:
+		return fmt.Sprintf("⬇️ %d", -movement)
+	}
+	return "="
+}
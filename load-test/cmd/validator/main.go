@@ -25,31 +25,113 @@ type TestResult struct {
 	FastestTime   string  `json:"fastest_time"`
 	SlowestTime   string  `json:"slowest_time"`
 	AverageTime   string  `json:"average_time"`
+
+	// Latency, ServiceStats and ConfusionMatrix are only present in results
+	// produced by newer load tester versions
+	Latency         *LatencyStats  `json:"latency,omitempty"`
+	ServiceStats    []ServiceStats `json:"service_stats,omitempty"`
+	ConfusionMatrix [][]int        `json:"confusion_matrix,omitempty"`
+}
+
+// ServiceStats represents per-service classification metrics of a test run
+type ServiceStats struct {
+	ServiceID      int     `json:"service_id"`
+	ServiceName    string  `json:"service_name"`
+	Support        int     `json:"support"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Unclassified   int     `json:"unclassified"`
+	Errors         int     `json:"errors"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+}
+
+// LatencyStats represents the latency distribution of a test run in milliseconds
+type LatencyStats struct {
+	Count     int               `json:"count"`
+	MinMs     float64           `json:"min_ms"`
+	MaxMs     float64           `json:"max_ms"`
+	MeanMs    float64           `json:"mean_ms"`
+	StdDevMs  float64           `json:"stddev_ms"`
+	P50Ms     float64           `json:"p50_ms"`
+	P90Ms     float64           `json:"p90_ms"`
+	P95Ms     float64           `json:"p95_ms"`
+	P99Ms     float64           `json:"p99_ms"`
+	P999Ms    float64           `json:"p999_ms"`
+	Histogram []HistogramBucket `json:"histogram,omitempty"`
+}
+
+// HistogramBucket counts latencies up to UpperMs (exclusive of the previous bucket)
+type HistogramBucket struct {
+	UpperMs float64 `json:"upper_ms"`
+	Count   int     `json:"count"`
 }
 
 // ParticipantResult holds combined results for a participant
 type ParticipantResult struct {
-	Name         string
-	Test93       *TestResult
-	Test80       *TestResult
-	TotalSuccess int
-	TotalFailed  int
-	AvgTime93    float64 // in milliseconds
-	AvgTime80    float64 // in milliseconds
-	Score        float64
+	Name         string      `json:"name"`
+	Test93       *TestResult `json:"test_93,omitempty"`
+	Test80       *TestResult `json:"test_80,omitempty"`
+	TotalSuccess int         `json:"total_success"`
+	TotalFailed  int         `json:"total_failed"`
+	AvgTime93    float64     `json:"avg_time_93_ms"` // in milliseconds
+	AvgTime80    float64     `json:"avg_time_80_ms"` // in milliseconds
+	P95Time93    float64     `json:"p95_time_93_ms"` // in milliseconds, 0 when not available
+	P95Time80    float64     `json:"p95_time_80_ms"` // in milliseconds, 0 when not available
+	Score        float64     `json:"score"`
 }
 
 func main() {
 	participantesPath := flag.String("path", "../../participantes", "Path to participantes folder")
-	outputPath := flag.String("output", "results.html", "Output HTML file path")
+	outputPath := flag.String("output", "", "Output file path (default results.<format>; stdout for -diff)")
+	format := flag.String("format", formatHTML, "Output format: html, json, csv or md (-diff supports json, csv and md)")
+	profileName := flag.String("profile", "default", "Scoring profile: a built-in name (default, accuracy-first, latency-first) or a JSON or YAML (.yaml, .yml) file")
+	diffPath := flag.String("diff", "", "Previous JSON ranking snapshot; reports rank movements and score deltas against the current ranking")
+	againstPath := flag.String("against", "", "JSON ranking snapshot compared with -diff instead of the current results")
 	flag.Parse()
 
+	formatSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "format" {
+			formatSet = true
+		}
+	})
+
+	if !validFormat(*format) {
+		fmt.Printf("Error: unknown format %q\n", *format)
+		os.Exit(1)
+	}
+
+	profile, err := loadScoringProfile(*profileName)
+	if err != nil {
+		fmt.Printf("Error loading scoring profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *diffPath != "" {
+		if !formatSet {
+			*format = formatMarkdown
+		}
+
+		if err := runDiff(*diffPath, *againstPath, *participantesPath, profile, *format, *outputPath); err != nil {
+			fmt.Printf("Error comparing rankings: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *outputPath == "" {
+		*outputPath = "results." + *format
+	}
+
 	if _, err := os.Stat(*participantesPath); os.IsNotExist(err) {
 		fmt.Printf("Error: Path '%s' does not exist\n", *participantesPath)
 		os.Exit(1)
 	}
 
-	participants, err := readAllParticipants(*participantesPath)
+	participants, err := rankParticipants(*participantesPath, profile)
 	if err != nil {
 		fmt.Printf("Error reading participants: %v\n", err)
 		os.Exit(1)
@@ -60,26 +142,50 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Calculate scores and rank
-	for i := range participants {
-		participants[i].Score = calculateScore(&participants[i])
-	}
+	if *format != formatHTML {
+		if err := exportRanking(participants, profile, *format, *outputPath); err != nil {
+			fmt.Printf("Error exporting ranking: %v\n", err)
+			os.Exit(1)
+		}
 
-	// Sort by score (higher is better)
-	sort.Slice(participants, func(i, j int) bool {
-		return participants[i].Score > participants[j].Score
-	})
+		fmt.Printf("✅ Ranking exported successfully: %s\n", *outputPath)
+		return
+	}
 
 	// Generate HTML report
-	err = generateHTMLReport(participants, *outputPath)
+	err = generateHTMLReport(participants, profile, *outputPath)
 	if err != nil {
 		fmt.Printf("Error generating HTML report: %v\n", err)
 		os.Exit(1)
 	}
 
+	err = generateDetailPages(participants, profile, *outputPath)
+	if err != nil {
+		fmt.Printf("Error generating participant pages: %v\n", err)
+		os.Exit(1)
+	}
+
 	fmt.Printf("✅ Report generated successfully: %s\n", *outputPath)
 }
 
+// rankParticipants reads all results, scores them and sorts by score (higher is better)
+func rankParticipants(basePath string, profile ScoringProfile) ([]ParticipantResult, error) {
+	participants, err := readAllParticipants(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range participants {
+		participants[i].Score = profile.Score(&participants[i])
+	}
+
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].Score > participants[j].Score
+	})
+
+	return participants, nil
+}
+
 func readAllParticipants(basePath string) ([]ParticipantResult, error) {
 	var participants []ParticipantResult
 
@@ -123,12 +229,20 @@ func readAllParticipants(basePath string) ([]ParticipantResult, error) {
 			participant.TotalSuccess += test93.TotalSuccess
 			participant.TotalFailed += test93.TotalFailed
 			participant.AvgTime93 = parseTimeMs(test93.AverageTime)
+
+			if test93.Latency != nil {
+				participant.P95Time93 = test93.Latency.P95Ms
+			}
 		}
 
 		if test80 != nil {
 			participant.TotalSuccess += test80.TotalSuccess
 			participant.TotalFailed += test80.TotalFailed
 			participant.AvgTime80 = parseTimeMs(test80.AverageTime)
+
+			if test80.Latency != nil {
+				participant.P95Time80 = test80.Latency.P95Ms
+			}
 		}
 
 		participants = append(participants, participant)
@@ -164,49 +278,24 @@ func parseTimeMs(timeStr string) float64 {
 	return val
 }
 
-// calculateScore computes a ranking score based on success rate, failures, and response time
-func calculateScore(p *ParticipantResult) float64 {
-	// Scoring weights (adjusted for realistic values)
-	const (
-		successWeight = 10.0 // Each success worth 10 points
-		failureWeight = 50.0 // Each failure costs 50 points
-		timeWeight    = 0.01 // Time penalty: avg_time (in ms) / 100
-	)
-
-	score := 0.0
-
-	// Higher success count is better
-	score += float64(p.TotalSuccess) * successWeight
-
-	// Lower failure count is better (subtract from score)
-	score -= float64(p.TotalFailed) * failureWeight
-
-	// Lower average time is better
-	// Calculate average of both tests (in milliseconds)
-	avgTime := (p.AvgTime93 + p.AvgTime80) / 2.0
-	if avgTime > 0 {
-		// Time penalty scaled down to not dominate the score
-		score -= avgTime * timeWeight
-	}
-
-	return score
-}
-
-func generateHTMLReport(participants []ParticipantResult, outputPath string) error {
+func generateHTMLReport(participants []ParticipantResult, profile ScoringProfile, outputPath string) error {
 	funcMap := template.FuncMap{
 		"add": func(a, b int) int {
 			return a + b
 		},
 		"formatTime": formatTime,
+		"detailPage": detailPage,
 	}
 
 	tmpl := template.Must(template.New("report").Funcs(funcMap).Parse(htmlTemplate))
 
 	data := struct {
 		Participants []ParticipantResult
+		Profile      ScoringProfile
 		GeneratedAt  string
 	}{
 		Participants: participants,
+		Profile:      profile,
 		GeneratedAt:  time.Now().Format("2006-01-02 15:04:05"),
 	}
 
@@ -403,6 +492,11 @@ const htmlTemplate = `<!DOCTYPE html>
             font-weight: 600;
             color: #495057;
             font-size: 1.1em;
+            text-decoration: none;
+        }
+
+        .participant-card-header h3 a {
+            color: inherit;
         }
 
         .metric {
@@ -540,17 +634,18 @@ const htmlTemplate = `<!DOCTYPE html>
         <div class="scoring-info">
             <h2>📊 Scoring Criteria</h2>
             <div class="scoring-formula">
-                <strong>Formula:</strong> <code>Score = (Total_Success × 10.0) - (Total_Failed × 50.0) - (Avg_Time_ms × 0.01)</code>
+                <strong>Profile:</strong> <code>{{.Profile.Name}}</code><br><br>
+                <strong>Formula:</strong> <code>{{.Profile.FormulaText}}</code>
             </div>
             <div class="criteria-list">
                 <div class="criteria-item">
-                    <strong>✅ Success Weight:</strong> +10.0 points per success
+                    <strong>✅ Success Weight:</strong> +{{printf "%.2f" .Profile.SuccessWeight}} points per success{{if eq .Profile.Formula "rate"}} percentage point{{end}}
                 </div>
                 <div class="criteria-item penalty">
-                    <strong>❌ Failure Penalty:</strong> -50.0 points per failure
+                    <strong>❌ Failure Penalty:</strong> -{{printf "%.2f" .Profile.FailureWeight}} points per failure{{if eq .Profile.Formula "rate"}} percentage point{{end}}
                 </div>
                 <div class="criteria-item time">
-                    <strong>⏱️ Time Penalty:</strong> -0.01 points per millisecond
+                    <strong>⏱️ Time Penalty:</strong> -{{.Profile.TimeWeight}} points per millisecond ({{.Profile.TimeMetric}})
                 </div>
             </div>
         </div>
@@ -566,6 +661,8 @@ const htmlTemplate = `<!DOCTYPE html>
                         <th>Total Failed</th>
                         <th>Avg Time (93)</th>
                         <th>Avg Time (80)</th>
+                        <th>P95 (93)</th>
+                        <th>P95 (80)</th>
                         <th>Final Score</th>
                     </tr>
                 </thead>
@@ -583,11 +680,13 @@ const htmlTemplate = `<!DOCTYPE html>
                                 <span class="rank-badge rank-other">{{add $index 1}}</span>
                             {{end}}
                         </td>
-                        <td><span class="participant-name">{{$p.Name}}</span></td>
+                        <td><a class="participant-name" href="{{detailPage $p.Name}}">{{$p.Name}}</a></td>
                         <td><span class="metric success">{{$p.TotalSuccess}}</span></td>
                         <td><span class="metric failed">{{$p.TotalFailed}}</span></td>
                         <td>{{formatTime $p.AvgTime93}}</td>
                         <td>{{formatTime $p.AvgTime80}}</td>
+                        <td>{{formatTime $p.P95Time93}}</td>
+                        <td>{{formatTime $p.P95Time80}}</td>
                         <td><span class="score">{{printf "%.2f" $p.Score}}</span></td>
                     </tr>
                     {{end}}
@@ -608,7 +707,7 @@ const htmlTemplate = `<!DOCTYPE html>
                         {{else}}
                             <span class="rank-badge rank-other">{{add $index 1}}</span>
                         {{end}}
-                        <h3>{{$p.Name}}</h3>
+                        <h3><a href="{{detailPage $p.Name}}">{{$p.Name}}</a></h3>
                     </div>
 
                     <div class="test-results">
@@ -643,6 +742,20 @@ const htmlTemplate = `<!DOCTYPE html>
                                 <span class="test-stat-label">Slowest Time:</span>
                                 <span class="test-stat-value">{{$p.Test93.SlowestTime}}</span>
                             </div>
+                            {{with $p.Test93.Latency}}
+                            <div class="test-stat">
+                                <span class="test-stat-label">P50 / P90:</span>
+                                <span class="test-stat-value">{{formatTime .P50Ms}} / {{formatTime .P90Ms}}</span>
+                            </div>
+                            <div class="test-stat">
+                                <span class="test-stat-label">P95 / P99 / P99.9:</span>
+                                <span class="test-stat-value">{{formatTime .P95Ms}} / {{formatTime .P99Ms}} / {{formatTime .P999Ms}}</span>
+                            </div>
+                            <div class="test-stat">
+                                <span class="test-stat-label">Std Deviation:</span>
+                                <span class="test-stat-value">{{formatTime .StdDevMs}}</span>
+                            </div>
+                            {{end}}
                         </div>
                         {{else}}
                         <div class="test-result-box no-data">
@@ -682,6 +795,20 @@ const htmlTemplate = `<!DOCTYPE html>
                                 <span class="test-stat-label">Slowest Time:</span>
                                 <span class="test-stat-value">{{$p.Test80.SlowestTime}}</span>
                             </div>
+                            {{with $p.Test80.Latency}}
+                            <div class="test-stat">
+                                <span class="test-stat-label">P50 / P90:</span>
+                                <span class="test-stat-value">{{formatTime .P50Ms}} / {{formatTime .P90Ms}}</span>
+                            </div>
+                            <div class="test-stat">
+                                <span class="test-stat-label">P95 / P99 / P99.9:</span>
+                                <span class="test-stat-value">{{formatTime .P95Ms}} / {{formatTime .P99Ms}} / {{formatTime .P999Ms}}</span>
+                            </div>
+                            <div class="test-stat">
+                                <span class="test-stat-label">Std Deviation:</span>
+                                <span class="test-stat-value">{{formatTime .StdDevMs}}</span>
+                            </div>
+                            {{end}}
                         </div>
                         {{else}}
                         <div class="test-result-box no-data">
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Time metrics a scoring profile can penalize
+const (
+	timeMetricMean = "mean"
+	timeMetricP50  = "p50"
+	timeMetricP90  = "p90"
+	timeMetricP95  = "p95"
+	timeMetricP99  = "p99"
+)
+
+// ScoringProfile holds the weights and formula used to rank participants
+type ScoringProfile struct {
+	Name          string  `json:"name"`
+	Formula       string  `json:"formula"`
+	SuccessWeight float64 `json:"success_weight"`
+	FailureWeight float64 `json:"failure_weight"`
+	TimeWeight    float64 `json:"time_weight"`
+	// TimeMetric selects the latency that is penalized: mean, p50, p90, p95
+	// or p99. Percentiles fall back to the mean for results without them.
+	TimeMetric string `json:"time_metric"`
+}
+
+// scoreFormula computes a participant score for a profile
+type scoreFormula func(p *ParticipantResult, profile ScoringProfile) float64
+
+// scoreFormulas are the formulas selectable with the profile's "formula" field
+var scoreFormulas = map[string]scoreFormula{
+	// linear is the hackathon formula: successes minus failures minus time
+	"linear": func(p *ParticipantResult, profile ScoringProfile) float64 {
+		score := float64(p.TotalSuccess)*profile.SuccessWeight - float64(p.TotalFailed)*profile.FailureWeight
+
+		if t := p.PenalizedTime(profile.TimeMetric); t > 0 {
+			score -= t * profile.TimeWeight
+		}
+
+		return score
+	},
+	// rate scores the success percentage instead of absolute counts, so runs
+	// with different dataset sizes remain comparable
+	"rate": func(p *ParticipantResult, profile ScoringProfile) float64 {
+		total := p.TotalSuccess + p.TotalFailed
+		if total == 0 {
+			return 0
+		}
+
+		successRate := float64(p.TotalSuccess) / float64(total) * 100
+		failureRate := float64(p.TotalFailed) / float64(total) * 100
+
+		score := successRate*profile.SuccessWeight - failureRate*profile.FailureWeight
+
+		if t := p.PenalizedTime(profile.TimeMetric); t > 0 {
+			score -= t * profile.TimeWeight
+		}
+
+		return score
+	},
+}
+
+// builtinProfiles can be selected by name with -profile
+var builtinProfiles = map[string]ScoringProfile{
+	"default": {
+		Name:          "default",
+		Formula:       "linear",
+		SuccessWeight: 10.0,
+		FailureWeight: 50.0,
+		TimeWeight:    0.01,
+		TimeMetric:    timeMetricMean,
+	},
+	"accuracy-first": {
+		Name:          "accuracy-first",
+		Formula:       "linear",
+		SuccessWeight: 10.0,
+		FailureWeight: 100.0,
+		TimeWeight:    0.001,
+		TimeMetric:    timeMetricMean,
+	},
+	"latency-first": {
+		Name:          "latency-first",
+		Formula:       "linear",
+		SuccessWeight: 10.0,
+		FailureWeight: 50.0,
+		TimeWeight:    0.1,
+		TimeMetric:    timeMetricP95,
+	},
+}
+
+// loadScoringProfile returns a built-in profile by name or reads a JSON file,
+// or a YAML one when the extension is .yaml or .yml
+func loadScoringProfile(nameOrPath string) (ScoringProfile, error) {
+	if profile, ok := builtinProfiles[nameOrPath]; ok {
+		return profile, nil
+	}
+
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return ScoringProfile{}, fmt.Errorf("unknown profile %q (built-in: %s): %w",
+			nameOrPath, strings.Join(builtinProfileNames(), ", "), err)
+	}
+
+	profile := builtinProfiles["default"]
+	profile.Name = nameOrPath
+
+	switch strings.ToLower(filepath.Ext(nameOrPath)) {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data); err != nil {
+			return ScoringProfile{}, fmt.Errorf("failed to parse profile: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return ScoringProfile{}, fmt.Errorf("failed to parse profile: %w", err)
+	}
+
+	if err := profile.Validate(); err != nil {
+		return ScoringProfile{}, err
+	}
+
+	return profile, nil
+}
+
+// yamlToJSON converts a flat YAML mapping, the only shape a profile has, to
+// JSON so that both formats share the field names and type checks of
+// ScoringProfile. Nested blocks, lists and anchors are not supported.
+func yamlToJSON(data []byte) ([]byte, error) {
+	fields := make(map[string]any)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text == "---" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok || strings.HasPrefix(scanner.Text(), " ") {
+			return nil, fmt.Errorf("line %d: want a top-level \"key: value\"", line)
+		}
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		value = strings.TrimSpace(value)
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			fields[strings.TrimSpace(key)] = unquoted
+		} else if n, err := strconv.ParseFloat(value, 64); err == nil {
+			fields[strings.TrimSpace(key)] = n
+		} else {
+			fields[strings.TrimSpace(key)] = strings.Trim(value, "'")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+func builtinProfileNames() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Validate checks that the formula and time metric are known
+func (s ScoringProfile) Validate() error {
+	if _, ok := scoreFormulas[s.Formula]; !ok {
+		return fmt.Errorf("unknown formula %q", s.Formula)
+	}
+
+	switch s.TimeMetric {
+	case timeMetricMean, timeMetricP50, timeMetricP90, timeMetricP95, timeMetricP99:
+	default:
+		return fmt.Errorf("unknown time metric %q", s.TimeMetric)
+	}
+
+	return nil
+}
+
+// FormulaText describes the formula for the HTML report
+func (s ScoringProfile) FormulaText() string {
+	timeLabel := "Avg_Time_ms"
+	if s.TimeMetric != timeMetricMean {
+		timeLabel = strings.ToUpper(s.TimeMetric) + "_Time_ms"
+	}
+
+	if s.Formula == "rate" {
+		return fmt.Sprintf("Score = (Success_Rate × %.2f) - (Failure_Rate × %.2f) - (%s × %g)",
+			s.SuccessWeight, s.FailureWeight, timeLabel, s.TimeWeight)
+	}
+
+	return fmt.Sprintf("Score = (Total_Success × %.1f) - (Total_Failed × %.1f) - (%s × %g)",
+		s.SuccessWeight, s.FailureWeight, timeLabel, s.TimeWeight)
+}
+
+// Score computes the participant score with this profile
+func (s ScoringProfile) Score(p *ParticipantResult) float64 {
+	return scoreFormulas[s.Formula](p, s)
+}
+
+// PenalizedTime returns the average of both tests for the given time metric in
+// milliseconds. Tests without percentiles contribute their mean time.
+func (p *ParticipantResult) PenalizedTime(metric string) float64 {
+	t93 := testTime(p.Test93, p.AvgTime93, metric)
+	t80 := testTime(p.Test80, p.AvgTime80, metric)
+
+	return (t93 + t80) / 2.0
+}
+
+func testTime(test *TestResult, mean float64, metric string) float64 {
+	if test == nil || test.Latency == nil {
+		return mean
+	}
+
+	switch metric {
+	case timeMetricP50:
+		return test.Latency.P50Ms
+	case timeMetricP90:
+		return test.Latency.P90Ms
+	case timeMetricP95:
+		return test.Latency.P95Ms
+	case timeMetricP99:
+		return test.Latency.P99Ms
+	}
+
+	return mean
+}
@@ -0,0 +1,119 @@
+package main
+
+// numServices is the number of services a classifier may answer with (IDs 1-16)
+const numServices = 16
+
+type (
+	// ServiceStats holds per-service classification metrics
+	ServiceStats struct {
+		ServiceID      int     `json:"service_id"`
+		ServiceName    string  `json:"service_name,omitempty"`
+		Support        int     `json:"support"`
+		TruePositives  int     `json:"true_positives"`
+		FalsePositives int     `json:"false_positives"`
+		FalseNegatives int     `json:"false_negatives"`
+		Unclassified   int     `json:"unclassified"`
+		Errors         int     `json:"errors"`
+		Precision      float64 `json:"precision"`
+		Recall         float64 `json:"recall"`
+		F1             float64 `json:"f1"`
+	}
+
+	// ConfusionMatrix counts expected (rows) against returned (columns) service IDs.
+	// Answers outside 1-16 are counted as unclassified, and requests without a
+	// well-formed answer as errors.
+	ConfusionMatrix struct {
+		counts       [numServices][numServices]int
+		unclassified [numServices]int
+		errors       [numServices]int
+	}
+)
+
+func newConfusionMatrix() *ConfusionMatrix {
+	return &ConfusionMatrix{}
+}
+
+func validServiceID(id int) bool {
+	return id >= 1 && id <= numServices
+}
+
+// Add records one classification. Only well-formed answers are classifications;
+// anything else goes through AddError.
+func (m *ConfusionMatrix) Add(expected, got int) {
+	if !validServiceID(expected) {
+		return
+	}
+
+	if !validServiceID(got) {
+		m.unclassified[expected-1]++
+		return
+	}
+
+	m.counts[expected-1][got-1]++
+}
+
+// AddError records a request that got no well-formed answer
+func (m *ConfusionMatrix) AddError(expected int) {
+	if validServiceID(expected) {
+		m.errors[expected-1]++
+	}
+}
+
+// Matrix returns the 16x16 counts, indexed by service ID - 1
+func (m *ConfusionMatrix) Matrix() [][]int {
+	matrix := make([][]int, numServices)
+	for i := range matrix {
+		matrix[i] = append([]int(nil), m.counts[i][:]...)
+	}
+
+	return matrix
+}
+
+// ServiceStats computes precision and recall for every service. Service names
+// are taken from the expected records.
+func (m *ConfusionMatrix) ServiceStats(records []CSVRecord) []ServiceStats {
+	names := make(map[int]string, numServices)
+	for _, record := range records {
+		names[record.ServiceID] = record.ServiceName
+	}
+
+	stats := make([]ServiceStats, numServices)
+
+	for i := range numServices {
+		s := ServiceStats{
+			ServiceID:     i + 1,
+			ServiceName:   names[i+1],
+			TruePositives: m.counts[i][i],
+			Unclassified:  m.unclassified[i],
+			Errors:        m.errors[i],
+		}
+
+		for j := range numServices {
+			s.Support += m.counts[i][j]
+
+			if j != i {
+				s.FalseNegatives += m.counts[i][j]
+				s.FalsePositives += m.counts[j][i]
+			}
+		}
+
+		s.Support += s.Unclassified + s.Errors
+		s.FalseNegatives += s.Unclassified + s.Errors
+
+		if predicted := s.TruePositives + s.FalsePositives; predicted > 0 {
+			s.Precision = float64(s.TruePositives) / float64(predicted)
+		}
+
+		if s.Support > 0 {
+			s.Recall = float64(s.TruePositives) / float64(s.Support)
+		}
+
+		if s.Precision+s.Recall > 0 {
+			s.F1 = 2 * s.Precision * s.Recall / (s.Precision + s.Recall)
+		}
+
+		stats[i] = s
+	}
+
+	return stats
+}
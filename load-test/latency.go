@@ -0,0 +1,161 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"slices"
+	"time"
+)
+
+// histogramSubBuckets is the number of linear sub-buckets per power of two,
+// giving roughly two significant digits of precision like an HDR histogram
+const histogramSubBuckets = 64
+
+type (
+	// LatencyStats summarizes the latency distribution of a run in milliseconds
+	LatencyStats struct {
+		Count     int               `json:"count"`
+		MinMs     float64           `json:"min_ms"`
+		MaxMs     float64           `json:"max_ms"`
+		MeanMs    float64           `json:"mean_ms"`
+		StdDevMs  float64           `json:"stddev_ms"`
+		P50Ms     float64           `json:"p50_ms"`
+		P90Ms     float64           `json:"p90_ms"`
+		P95Ms     float64           `json:"p95_ms"`
+		P99Ms     float64           `json:"p99_ms"`
+		P999Ms    float64           `json:"p999_ms"`
+		Histogram []HistogramBucket `json:"histogram,omitempty"`
+	}
+
+	// HistogramBucket counts latencies in the range (previous UpperMs, UpperMs]
+	HistogramBucket struct {
+		UpperMs float64 `json:"upper_ms"`
+		Count   int     `json:"count"`
+	}
+
+	// LatencyRecorder collects latencies and summarizes them
+	LatencyRecorder struct {
+		samples []time.Duration
+		buckets map[int]int
+	}
+)
+
+func newLatencyRecorder(capacity int) *LatencyRecorder {
+	return &LatencyRecorder{
+		samples: make([]time.Duration, 0, capacity),
+		buckets: make(map[int]int),
+	}
+}
+
+// Record adds one latency sample
+func (r *LatencyRecorder) Record(latency time.Duration) {
+	if latency < 0 {
+		latency = 0
+	}
+
+	r.samples = append(r.samples, latency)
+	r.buckets[bucketIndex(latency)]++
+}
+
+// Stats computes the summary of all recorded samples
+func (r *LatencyRecorder) Stats() *LatencyStats {
+	if len(r.samples) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(r.samples)
+	slices.Sort(sorted)
+
+	var sum float64
+	for _, d := range sorted {
+		sum += durationMs(d)
+	}
+
+	mean := sum / float64(len(sorted))
+
+	var variance float64
+	for _, d := range sorted {
+		diff := durationMs(d) - mean
+		variance += diff * diff
+	}
+
+	variance /= float64(len(sorted))
+
+	stats := &LatencyStats{
+		Count:    len(sorted),
+		MinMs:    durationMs(sorted[0]),
+		MaxMs:    durationMs(sorted[len(sorted)-1]),
+		MeanMs:   mean,
+		StdDevMs: math.Sqrt(variance),
+		P50Ms:    percentile(sorted, 50),
+		P90Ms:    percentile(sorted, 90),
+		P95Ms:    percentile(sorted, 95),
+		P99Ms:    percentile(sorted, 99),
+		P999Ms:   percentile(sorted, 99.9),
+	}
+
+	indexes := make([]int, 0, len(r.buckets))
+	for idx := range r.buckets {
+		indexes = append(indexes, idx)
+	}
+
+	slices.Sort(indexes)
+
+	for _, idx := range indexes {
+		stats.Histogram = append(stats.Histogram, HistogramBucket{
+			UpperMs: durationMs(bucketUpperBound(idx)),
+			Count:   r.buckets[idx],
+		})
+	}
+
+	return stats
+}
+
+// percentile uses the nearest-rank method over sorted samples
+func percentile(sorted []time.Duration, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return durationMs(sorted[rank-1])
+}
+
+// bucketIndex maps a latency in microseconds to a log-linear bucket: values
+// below histogramSubBuckets get one bucket each, then every power of two is
+// split into histogramSubBuckets/2 linear steps.
+func bucketIndex(d time.Duration) int {
+	us := uint64(d.Microseconds())
+	if us < histogramSubBuckets {
+		return int(us)
+	}
+
+	half := uint64(histogramSubBuckets / 2)
+	exp := bits.Len64(us) - bits.Len64(histogramSubBuckets-1)
+	sub := us>>uint(exp) - half
+
+	return histogramSubBuckets + (exp-1)*int(half) + int(sub)
+}
+
+// bucketUpperBound returns the largest latency that falls into bucket idx
+func bucketUpperBound(idx int) time.Duration {
+	if idx < histogramSubBuckets {
+		return time.Duration(idx) * time.Microsecond
+	}
+
+	half := histogramSubBuckets / 2
+	exp := (idx-histogramSubBuckets)/half + 1
+	sub := (idx-histogramSubBuckets)%half + half
+
+	upper := (uint64(sub+1) << uint(exp)) - 1
+
+	return time.Duration(upper) * time.Microsecond
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
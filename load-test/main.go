@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,18 +13,24 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 type (
+	// CSVRecord is an intent with its expected service. ServiceID 0 means the
+	// endpoint is expected to reject the intent.
 	CSVRecord struct {
 		ServiceID   int
 		ServiceName string
 		Intent      string
+		// Perturbation is the optional fourth column written by cmd/corpus
+		Perturbation string
 	}
 
 	Response struct {
-		Data  ResponseData `json:"data"`
-		Error string       `json:"error"`
+		Success *bool        `json:"success"`
+		Data    ResponseData `json:"data"`
+		Error   string       `json:"error"`
 	}
 
 	ResponseData struct {
@@ -33,9 +40,17 @@ type (
 
 	Result struct {
 		Success bool
-		Record  CSVRecord
-		Error   string
-		Latency time.Duration
+		// Answered is set when the endpoint returned a well-formed
+		// classification, right or wrong
+		Answered   bool
+		Record     CSVRecord
+		Got        ResponseData
+		StatusCode int
+		Error      string
+		Latency    time.Duration
+		// CorrectedLatency is measured from the scheduled send time in
+		// open-loop mode and equals Latency otherwise
+		CorrectedLatency time.Duration
 	}
 
 	OutputReport struct {
@@ -49,23 +64,64 @@ type (
 		FastestTime   string  `json:"fastest_time,omitempty"`
 		SlowestTime   string  `json:"slowest_time,omitempty"`
 		AverageTime   string  `json:"average_time,omitempty"`
+
+		Mode             string        `json:"mode,omitempty"`
+		TargetRPS        float64       `json:"target_rps,omitempty"`
+		AchievedRPS      float64       `json:"achieved_rps,omitempty"`
+		Latency          *LatencyStats `json:"latency,omitempty"`
+		CorrectedLatency *LatencyStats `json:"corrected_latency,omitempty"`
+
+		ConfusionMatrix [][]int        `json:"confusion_matrix,omitempty"`
+		ServiceStats    []ServiceStats `json:"service_stats,omitempty"`
+
+		Robustness []PerturbationStats `json:"robustness,omitempty"`
+
+		Usage *UsageReport `json:"usage,omitempty"`
 	}
 )
 
 const (
-	clientTimeout = 20 * time.Second
-	numWorkers    = 20
+	defaultClientTimeout = 20 * time.Second
+	defaultNumWorkers    = 20
 )
 
 func main() {
-	if len(os.Args) != 4 {
-		fmt.Println("Usage: go run main.go <csv_file> <endpoint_url> <output_result>")
+	resultsFile := flag.String("results", "", "Optional file to write every record's result to (.jsonl, .json or .csv)")
+	resultsFormat := flag.String("results-format", "", "Format of the results file: jsonl, json or csv (default: from file extension)")
+	numWorkers := flag.Int("workers", defaultNumWorkers, "Number of concurrent workers")
+	clientTimeout := flag.Duration("timeout", defaultClientTimeout, "HTTP client timeout per request")
+	metricsURL := flag.String("metrics-url", "", "Optional service /api/metrics URL; token and cost usage during the run is added to the report")
+	creditsURL := flag.String("credits-url", "", "Optional OpenRouter /api/v1/key URL; the key usage delta is added to the report (uses OPENROUTER_API_KEY)")
+
+	var workload Workload
+	flag.IntVar(&workload.Repeat, "repeat", 1, "Number of passes over the CSV records")
+	flag.Float64Var(&workload.RPS, "rps", 0, "Target requests per second for open-loop mode (0 = closed-loop)")
+	flag.DurationVar(&workload.Ramp, "ramp", 0, "Ramp-up time to reach -rps (open-loop only)")
+	flag.DurationVar(&workload.Duration, "duration", 0, "Soak duration; cycles through the records until it elapses (0 = -repeat passes)")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run . [flags] <csv_file> <endpoint_url> <output_result>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 3 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if *numWorkers < 1 {
+		fmt.Println("Error: workers must be at least 1")
 		os.Exit(1)
 	}
 
-	csvFile := os.Args[1]
-	endpointURL := os.Args[2]
-	outputFile := os.Args[3]
+	if err := workload.Validate(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	csvFile := flag.Arg(0)
+	endpointURL := flag.Arg(1)
+	outputFile := flag.Arg(2)
 
 	records, err := readCSV(csvFile)
 	if err != nil {
@@ -75,33 +131,44 @@ func main() {
 
 	fmt.Printf("Loaded %d records\n", len(records))
 
-	jobs := make(chan CSVRecord, len(records))
-	results := make(chan Result, len(records))
+	var rw resultWriter
+	if *resultsFile != "" {
+		rw, err = newResultWriter(*resultsFile, *resultsFormat)
+		if err != nil {
+			fmt.Printf("Error creating results file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	meter := newUsageMeter(*metricsURL, *creditsURL)
+	if meter != nil {
+		if err := meter.Start(); err != nil {
+			fmt.Printf("Error reading usage: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Running %s workload with %d workers\n", workload.Mode(), *numWorkers)
+
+	jobs := make(chan Job, max(len(records), *numWorkers))
+	results := make(chan Result, *numWorkers)
 
 	var wg sync.WaitGroup
 
 	client := &http.Client{
-		Timeout: clientTimeout,
+		Timeout: *clientTimeout,
 	}
 
 	sw := &Stopwatch{}
 	sw.Start()
 
-	defer sw.Stop()
-
-	for i := range numWorkers {
+	for i := range *numWorkers {
 		wg.Go(func() {
 			worker(i+1, client, endpointURL, jobs, results)
 		})
 	}
 
-	go func() {
-		for i, record := range records {
-			fmt.Printf("Queuing record %d: %s\n", i+1, record.ServiceName)
-			jobs <- record
-		}
-		close(jobs)
-	}()
+	go workload.Feed(records, jobs)
 
 	go func() {
 		wg.Wait()
@@ -113,7 +180,25 @@ func main() {
 	var fastestTime, slowestTime time.Duration
 	var totalLatency time.Duration
 
+	confusion := newConfusionMatrix()
+	robustness := newRobustnessCounter()
+	latencies := newLatencyRecorder(len(records))
+	correctedLatencies := newLatencyRecorder(len(records))
+
 	for result := range results {
+		if result.Answered {
+			confusion.Add(result.Record.ServiceID, result.Got.ServiceID)
+		} else {
+			confusion.AddError(result.Record.ServiceID)
+		}
+		robustness.Add(result)
+
+		if rw != nil {
+			if err := rw.Write(result); err != nil {
+				fmt.Printf("Error writing result: %v\n", err)
+			}
+		}
+
 		if result.Success {
 			successCount++
 		} else {
@@ -121,6 +206,8 @@ func main() {
 		}
 
 		totalLatency += result.Latency
+		latencies.Record(result.Latency)
+		correctedLatencies.Record(result.CorrectedLatency)
 
 		if fastestTime == 0 || result.Latency < fastestTime {
 			fastestTime = result.Latency
@@ -131,7 +218,14 @@ func main() {
 		}
 	}
 
+	sw.Stop()
+
 	total := successCount + failureCount
+	if total == 0 {
+		fmt.Println("Error: no requests were sent")
+		os.Exit(1)
+	}
+
 	successRate := float64(successCount) / float64(total) * 100
 	failureRate := float64(failureCount) / float64(total) * 100
 
@@ -146,6 +240,47 @@ func main() {
 		FastestTime:   fmt.Sprintf("%dms", fastestTime.Milliseconds()),
 		SlowestTime:   fmt.Sprintf("%dms", slowestTime.Milliseconds()),
 		AverageTime:   fmt.Sprintf("%dms", (totalLatency / time.Duration(total)).Milliseconds()),
+
+		Mode:        workload.Mode(),
+		TargetRPS:   workload.RPS,
+		AchievedRPS: float64(total) / sw.Elapsed().Seconds(),
+		Latency:     latencies.Stats(),
+
+		ConfusionMatrix: confusion.Matrix(),
+		ServiceStats:    confusion.ServiceStats(records),
+
+		Robustness: robustness.Stats(),
+	}
+
+	if workload.Mode() == modeOpenLoop {
+		report.CorrectedLatency = correctedLatencies.Stats()
+	}
+
+	if meter != nil {
+		usage, err := meter.Report(total)
+		if err != nil {
+			fmt.Printf("Error reading usage: %v\n", err)
+		} else {
+			report.Usage = usage
+
+			if *metricsURL != "" {
+				fmt.Printf("Usage: %d completions, %d tokens, $%.6f\n",
+					usage.Totals.Requests, usage.Totals.TotalTokens, usage.Totals.Cost)
+			}
+
+			if usage.CreditsUsed != nil {
+				fmt.Printf("OpenRouter credits used: $%.6f\n", *usage.CreditsUsed)
+			}
+		}
+	}
+
+	if rw != nil {
+		if err := rw.Close(); err != nil {
+			fmt.Printf("Error closing results file: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Per-record results saved to %s\n", *resultsFile)
 	}
 
 	err = saveReportToFile(report, outputFile)
@@ -166,6 +301,7 @@ func readCSV(filename string) ([]CSVRecord, error) {
 
 	reader := csv.NewReader(file)
 	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
 
 	var records []CSVRecord
 	for {
@@ -191,11 +327,17 @@ func readCSV(filename string) ([]CSVRecord, error) {
 			panic(err)
 		}
 
-		records = append(records, CSVRecord{
+		csvRecord := CSVRecord{
 			ServiceID:   serviceID,
 			ServiceName: strings.TrimSpace(record[1]),
 			Intent:      strings.TrimSpace(record[2]),
-		})
+		}
+
+		if len(record) > 3 {
+			csvRecord.Perturbation = strings.TrimSpace(record[3])
+		}
+
+		records = append(records, csvRecord)
 	}
 
 	return records, nil
@@ -210,24 +352,29 @@ func saveReportToFile(report OutputReport, filename string) error {
 	return os.WriteFile(filename, jsonData, 0644)
 }
 
-func worker(id int, client *http.Client, endpointURL string, jobs <-chan CSVRecord, results chan<- Result) {
-	for record := range jobs {
-		fmt.Printf("Worker %d processing: %s\n", id, record.ServiceName)
+func worker(id int, client *http.Client, endpointURL string, jobs <-chan Job, results chan<- Result) {
+	for job := range jobs {
+		fmt.Printf("Worker %d processing: %s\n", id, job.Record.ServiceName)
 
 		startTime := time.Now()
 
-		success := processRecord(client, endpointURL, record)
-		elapsedTime := time.Since(startTime)
+		result := processRecord(client, endpointURL, job.Record)
+		result.Latency = time.Since(startTime)
+		result.CorrectedLatency = result.Latency
 
-		results <- Result{
-			Success: success,
-			Record:  record,
-			Latency: elapsedTime,
+		if !job.Scheduled.IsZero() {
+			result.CorrectedLatency = time.Since(job.Scheduled)
 		}
+
+		results <- result
 	}
 }
 
-func processRecord(client *http.Client, endpointURL string, record CSVRecord) bool {
+// processRecord sends a single intent to the endpoint and returns its result.
+// Latency is filled in by the caller.
+func processRecord(client *http.Client, endpointURL string, record CSVRecord) Result {
+	result := Result{Record: record}
+
 	payload := map[string]string{
 		"intent": record.Intent,
 	}
@@ -235,42 +382,87 @@ func processRecord(client *http.Client, endpointURL string, record CSVRecord) bo
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		fmt.Printf("Error marshaling payload: %v\n", err)
-		return false
+		result.Error = fmt.Sprintf("marshal payload: %v", err)
+		return result
 	}
 
 	resp, err := client.Post(endpointURL, "application/json", bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		fmt.Printf("Error making request: %v\n", err)
-		return false
+		result.Error = fmt.Sprintf("request: %v", err)
+		return result
 	}
 	defer resp.Body.Close()
 
+	result.StatusCode = resp.StatusCode
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Printf("Error reading response: %v\n", err)
-		return false
+		result.Error = fmt.Sprintf("read response: %v", err)
+		return result
 	}
 
 	var response Response
 	err = json.Unmarshal(body, &response)
+
+	if record.ServiceID == 0 {
+		return checkRejection(result, resp.StatusCode, response, err)
+	}
+
 	if err != nil {
 		fmt.Printf("Error unmarshaling response: %v\n", err)
-		return false
+		result.Error = fmt.Sprintf("unmarshal response: %v", err)
+		return result
 	}
 
+	result.Got = response.Data
+
 	if resp.StatusCode != http.StatusOK {
 		fmt.Printf("API error: %s\n", response.Error)
-		return false
+		result.Error = fmt.Sprintf("API error: %s", response.Error)
+		return result
 	}
 
+	result.Answered = true
+
 	if response.Data.ServiceID != record.ServiceID || response.Data.ServiceName != record.ServiceName {
 		fmt.Printf("Validation failed for intent %q - Expected: ID=%d, Name=%s | Got: ID=%d, Name=%s\n",
-			record.Intent, record.ServiceID, record.ServiceName, response.Data.ServiceID, response.Data.ServiceName)
-		return false
+			truncateIntent(record.Intent), record.ServiceID, record.ServiceName, response.Data.ServiceID, response.Data.ServiceName)
+		result.Error = "validation failed"
+		return result
 	}
 
 	fmt.Printf("Success - ID=%d, Name=%s\n", response.Data.ServiceID, response.Data.ServiceName)
-	return true
+	result.Success = true
+	return result
+}
+
+// checkRejection validates the answer to an intent the endpoint is expected
+// to refuse: any non-200 status or "success": false counts as a rejection
+func checkRejection(result Result, statusCode int, response Response, unmarshalErr error) Result {
+	result.Got = response.Data
+
+	rejected := statusCode != http.StatusOK || (unmarshalErr == nil && response.Success != nil && !*response.Success)
+	if !rejected {
+		fmt.Printf("Validation failed for intent %q - Expected rejection | Got: ID=%d, Name=%s\n",
+			truncateIntent(result.Record.Intent), response.Data.ServiceID, response.Data.ServiceName)
+		result.Error = "expected rejection"
+		return result
+	}
+
+	fmt.Printf("Success - rejected with status %d\n", statusCode)
+	result.Success = true
+	return result
+}
+
+// truncateIntent shortens huge intents in log lines
+func truncateIntent(intent string) string {
+	const maxLen = 120
+	if utf8.RuneCountInString(intent) > maxLen {
+		return string([]rune(intent)[:maxLen]) + "..."
+	}
+	return intent
 }
 
 // Stopwatch struct
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type (
+	// ResultRecord is the serialized form of a single Result
+	ResultRecord struct {
+		Intent              string  `json:"intent"`
+		ExpectedServiceID   int     `json:"expected_service_id"`
+		ExpectedServiceName string  `json:"expected_service_name"`
+		ServiceID           int     `json:"service_id"`
+		ServiceName         string  `json:"service_name"`
+		StatusCode          int     `json:"status_code"`
+		Success             bool    `json:"success"`
+		Error               string  `json:"error,omitempty"`
+		LatencyMs           float64 `json:"latency_ms"`
+		Perturbation        string  `json:"perturbation,omitempty"`
+	}
+
+	// resultWriter persists every Result of a run
+	resultWriter interface {
+		Write(result Result) error
+		Close() error
+	}
+
+	jsonlResultWriter struct {
+		file *os.File
+		buf  *bufio.Writer
+		enc  *json.Encoder
+	}
+
+	// jsonResultWriter writes the records as a single JSON array
+	jsonResultWriter struct {
+		file    *os.File
+		buf     *bufio.Writer
+		written int
+	}
+
+	csvResultWriter struct {
+		file *os.File
+		w    *csv.Writer
+	}
+)
+
+var resultCSVHeader = []string{
+	"intent",
+	"expected_service_id",
+	"expected_service_name",
+	"service_id",
+	"service_name",
+	"status_code",
+	"success",
+	"error",
+	"latency_ms",
+	"perturbation",
+}
+
+// newResultWriter creates a writer for filename. When format is empty it is
+// inferred from the file extension, defaulting to jsonl.
+func newResultWriter(filename, format string) (resultWriter, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	switch format {
+	case "csv", "jsonl", "ndjson", "json", "":
+	default:
+		return nil, fmt.Errorf("unsupported results format %q", format)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "csv" {
+		w := csv.NewWriter(file)
+		w.Comma = ';'
+
+		if err := w.Write(resultCSVHeader); err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		return &csvResultWriter{file: file, w: w}, nil
+	}
+
+	buf := bufio.NewWriter(file)
+
+	if format == "json" {
+		return &jsonResultWriter{file: file, buf: buf}, nil
+	}
+
+	return &jsonlResultWriter{file: file, buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+// newResultRecord converts a Result into its serialized form
+func newResultRecord(result Result) ResultRecord {
+	return ResultRecord{
+		Intent:              result.Record.Intent,
+		ExpectedServiceID:   result.Record.ServiceID,
+		ExpectedServiceName: result.Record.ServiceName,
+		ServiceID:           result.Got.ServiceID,
+		ServiceName:         result.Got.ServiceName,
+		StatusCode:          result.StatusCode,
+		Success:             result.Success,
+		Error:               result.Error,
+		LatencyMs:           float64(result.Latency.Microseconds()) / 1000,
+		Perturbation:        result.Record.Perturbation,
+	}
+}
+
+func (w *jsonlResultWriter) Write(result Result) error {
+	return w.enc.Encode(newResultRecord(result))
+}
+
+func (w *jsonlResultWriter) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+
+	return w.file.Close()
+}
+
+func (w *jsonResultWriter) Write(result Result) error {
+	data, err := json.MarshalIndent(newResultRecord(result), "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if w.written == 0 {
+		sep = "[\n  "
+	}
+	w.written++
+
+	if _, err := w.buf.WriteString(sep); err != nil {
+		return err
+	}
+
+	_, err = w.buf.Write(data)
+	return err
+}
+
+func (w *jsonResultWriter) Close() error {
+	end := "\n]\n"
+	if w.written == 0 {
+		end = "[]\n"
+	}
+
+	if _, err := w.buf.WriteString(end); err != nil {
+		w.file.Close()
+		return err
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+
+	return w.file.Close()
+}
+
+func (w *csvResultWriter) Write(result Result) error {
+	r := newResultRecord(result)
+
+	return w.w.Write([]string{
+		r.Intent,
+		strconv.Itoa(r.ExpectedServiceID),
+		r.ExpectedServiceName,
+		strconv.Itoa(r.ServiceID),
+		r.ServiceName,
+		strconv.Itoa(r.StatusCode),
+		strconv.FormatBool(r.Success),
+		r.Error,
+		strconv.FormatFloat(r.LatencyMs, 'f', 3, 64),
+		r.Perturbation,
+	})
+}
+
+func (w *csvResultWriter) Close() error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		w.file.Close()
+		return err
+	}
+
+	return w.file.Close()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+type (
+	// PerturbationStats is the accuracy on records sharing a perturbation type
+	PerturbationStats struct {
+		Perturbation string  `json:"perturbation"`
+		Total        int     `json:"total"`
+		Success      int     `json:"success"`
+		Failed       int     `json:"failed"`
+		Accuracy     float64 `json:"accuracy"`
+		// ExpectRejection is true when the records are inputs the endpoint
+		// should refuse rather than classify
+		ExpectRejection bool `json:"expect_rejection,omitempty"`
+	}
+
+	// robustnessCounter aggregates results by the perturbation column of the CSV
+	robustnessCounter struct {
+		stats map[string]*PerturbationStats
+	}
+)
+
+func newRobustnessCounter() *robustnessCounter {
+	return &robustnessCounter{stats: make(map[string]*PerturbationStats)}
+}
+
+// Add records one result. Records without a perturbation are ignored.
+func (c *robustnessCounter) Add(result Result) {
+	name := result.Record.Perturbation
+	if name == "" {
+		return
+	}
+
+	s, ok := c.stats[name]
+	if !ok {
+		s = &PerturbationStats{Perturbation: name}
+		c.stats[name] = s
+	}
+
+	s.Total++
+	if result.Success {
+		s.Success++
+	} else {
+		s.Failed++
+	}
+
+	if result.Record.ServiceID == 0 {
+		s.ExpectRejection = true
+	}
+}
+
+// Stats returns the accuracy per perturbation sorted by name, or nil when the
+// CSV has no perturbation column
+func (c *robustnessCounter) Stats() []PerturbationStats {
+	if len(c.stats) == 0 {
+		return nil
+	}
+
+	stats := make([]PerturbationStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		s.Accuracy = float64(s.Success) / float64(s.Total) * 100
+		stats = append(stats, *s)
+	}
+
+	slices.SortFunc(stats, func(a, b PerturbationStats) int {
+		return strings.Compare(a.Perturbation, b.Perturbation)
+	})
+
+	return stats
+}
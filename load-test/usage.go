@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+type (
+	// UsageTotals is the token and cost accounting exposed by a service's
+	// /api/metrics endpoint
+	UsageTotals struct {
+		Requests         int64   `json:"requests"`
+		PromptTokens     int64   `json:"prompt_tokens"`
+		CompletionTokens int64   `json:"completion_tokens"`
+		TotalTokens      int64   `json:"total_tokens"`
+		Cost             float64 `json:"cost"`
+	}
+
+	// UsageMetrics is the body of /api/metrics
+	UsageMetrics struct {
+		Totals    UsageTotals            `json:"totals"`
+		ByModel   map[string]UsageTotals `json:"by_model"`
+		ByService map[string]UsageTotals `json:"by_service"`
+	}
+
+	// UsageReport is the usage consumed during the run
+	UsageReport struct {
+		UsageMetrics
+		// CostPerRequest is the cost divided by the load test requests, not
+		// by the completions the service made
+		CostPerRequest float64 `json:"cost_per_request"`
+		// CreditsUsed is the OpenRouter key usage delta when -credits-url is set
+		CreditsUsed *float64 `json:"credits_used,omitempty"`
+	}
+)
+
+// fetchJSON decodes the body of a GET request into v
+func fetchJSON(client *http.Client, url string, header http.Header, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	for k, values := range header {
+		req.Header[k] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func fetchUsage(client *http.Client, url string) (*UsageMetrics, error) {
+	var metrics UsageMetrics
+	if err := fetchJSON(client, url, nil, &metrics); err != nil {
+		return nil, err
+	}
+
+	return &metrics, nil
+}
+
+// fetchCredits returns the usage in USD of the OpenRouter key in OPENROUTER_API_KEY
+func fetchCredits(client *http.Client, url string) (float64, error) {
+	header := http.Header{}
+	if key := os.Getenv("OPENROUTER_API_KEY"); key != "" {
+		header.Set("Authorization", "Bearer "+key)
+	}
+
+	var body struct {
+		Data struct {
+			Usage float64 `json:"usage"`
+		} `json:"data"`
+	}
+	if err := fetchJSON(client, url, header, &body); err != nil {
+		return 0, err
+	}
+
+	return body.Data.Usage, nil
+}
+
+func (t UsageTotals) sub(other UsageTotals) UsageTotals {
+	return UsageTotals{
+		Requests:         t.Requests - other.Requests,
+		PromptTokens:     t.PromptTokens - other.PromptTokens,
+		CompletionTokens: t.CompletionTokens - other.CompletionTokens,
+		TotalTokens:      t.TotalTokens - other.TotalTokens,
+		Cost:             t.Cost - other.Cost,
+	}
+}
+
+func subUsageMap(after, before map[string]UsageTotals) map[string]UsageTotals {
+	delta := make(map[string]UsageTotals, len(after))
+	for k, totals := range after {
+		if d := totals.sub(before[k]); d.Requests != 0 {
+			delta[k] = d
+		}
+	}
+	return delta
+}
+
+// usageMeter snapshots service metrics and OpenRouter credits around a run
+type usageMeter struct {
+	client     *http.Client
+	metricsURL string
+	creditsURL string
+
+	before        *UsageMetrics
+	creditsBefore float64
+}
+
+func newUsageMeter(metricsURL, creditsURL string) *usageMeter {
+	if metricsURL == "" && creditsURL == "" {
+		return nil
+	}
+
+	return &usageMeter{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		metricsURL: metricsURL,
+		creditsURL: creditsURL,
+	}
+}
+
+// Start records the usage before the run
+func (m *usageMeter) Start() error {
+	var err error
+
+	if m.metricsURL != "" {
+		if m.before, err = fetchUsage(m.client, m.metricsURL); err != nil {
+			return fmt.Errorf("fetch metrics: %w", err)
+		}
+	}
+
+	if m.creditsURL != "" {
+		if m.creditsBefore, err = fetchCredits(m.client, m.creditsURL); err != nil {
+			return fmt.Errorf("fetch credits: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Report returns the usage consumed since Start
+func (m *usageMeter) Report(totalRequests int) (*UsageReport, error) {
+	report := &UsageReport{}
+
+	if m.metricsURL != "" {
+		after, err := fetchUsage(m.client, m.metricsURL)
+		if err != nil {
+			return nil, fmt.Errorf("fetch metrics: %w", err)
+		}
+
+		report.Totals = after.Totals.sub(m.before.Totals)
+		report.ByModel = subUsageMap(after.ByModel, m.before.ByModel)
+		report.ByService = subUsageMap(after.ByService, m.before.ByService)
+
+		if totalRequests > 0 {
+			report.CostPerRequest = report.Totals.Cost / float64(totalRequests)
+		}
+	}
+
+	if m.creditsURL != "" {
+		credits, err := fetchCredits(m.client, m.creditsURL)
+		if err != nil {
+			return nil, fmt.Errorf("fetch credits: %w", err)
+		}
+
+		used := credits - m.creditsBefore
+		report.CreditsUsed = &used
+
+		if m.metricsURL == "" && totalRequests > 0 {
+			report.CostPerRequest = used / float64(totalRequests)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	modeClosedLoop = "closed-loop"
+	modeOpenLoop   = "open-loop"
+)
+
+type (
+	// Workload describes how records are sent to the endpoint
+	Workload struct {
+		// Repeat is the number of passes over the CSV records
+		Repeat int
+		// RPS enables open-loop mode, sending at a fixed rate regardless of
+		// how fast the endpoint answers. Zero means closed-loop.
+		RPS float64
+		// Ramp linearly increases the rate from zero to RPS (open-loop only)
+		Ramp time.Duration
+		// Duration keeps cycling through the records until it elapses,
+		// after the ramp. Zero means Repeat passes.
+		Duration time.Duration
+	}
+
+	// Job is a record scheduled to be sent. Scheduled is the intended send
+	// time in open-loop mode and zero otherwise.
+	Job struct {
+		Record    CSVRecord
+		Scheduled time.Time
+	}
+)
+
+// Mode returns the workload mode name used in the report
+func (w Workload) Mode() string {
+	if w.RPS > 0 {
+		return modeOpenLoop
+	}
+
+	return modeClosedLoop
+}
+
+// Validate checks the workload flags
+func (w Workload) Validate() error {
+	if w.Repeat < 1 {
+		return fmt.Errorf("repeat must be at least 1")
+	}
+
+	if w.RPS < 0 {
+		return fmt.Errorf("rps cannot be negative")
+	}
+
+	if w.Ramp < 0 || w.Duration < 0 {
+		return fmt.Errorf("ramp and duration cannot be negative")
+	}
+
+	if w.Ramp > 0 && w.RPS == 0 {
+		return fmt.Errorf("ramp requires rps")
+	}
+
+	return nil
+}
+
+// Feed sends jobs according to the workload and closes the channel when done
+func (w Workload) Feed(records []CSVRecord, jobs chan<- Job) {
+	defer close(jobs)
+
+	if len(records) == 0 {
+		return
+	}
+
+	if w.RPS > 0 {
+		w.feedOpenLoop(records, jobs)
+		return
+	}
+
+	var deadline time.Time
+	if w.Duration > 0 {
+		deadline = time.Now().Add(w.Duration)
+	}
+
+	for i := 0; ; i++ {
+		if deadline.IsZero() && i >= len(records)*w.Repeat {
+			return
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return
+		}
+
+		record := records[i%len(records)]
+		fmt.Printf("Queuing record %d: %s\n", i+1, record.ServiceName)
+		jobs <- Job{Record: record}
+	}
+}
+
+// feedOpenLoop schedules every request at its intended time. Latency measured
+// from that time, rather than from when a worker picks it up, is corrected for
+// coordinated omission.
+func (w Workload) feedOpenLoop(records []CSVRecord, jobs chan<- Job) {
+	start := time.Now()
+	end := w.Ramp + w.Duration
+	limit := len(records) * w.Repeat
+
+	var offset time.Duration
+
+	for i := 0; ; i++ {
+		if w.Duration > 0 && offset >= end {
+			return
+		}
+
+		if w.Duration == 0 && i >= limit {
+			return
+		}
+
+		scheduled := start.Add(offset)
+		time.Sleep(time.Until(scheduled))
+
+		record := records[i%len(records)]
+		fmt.Printf("Scheduling record %d at %s: %s\n", i+1, offset.Round(time.Millisecond), record.ServiceName)
+		jobs <- Job{Record: record, Scheduled: scheduled}
+
+		offset += time.Duration(float64(time.Second) / w.rateAt(offset))
+	}
+}
+
+// rateAt returns the target rate at the given offset from the start, linearly
+// ramping from one request per second up to RPS
+func (w Workload) rateAt(offset time.Duration) float64 {
+	if w.Ramp == 0 || offset >= w.Ramp {
+		return w.RPS
+	}
+
+	rate := w.RPS * float64(offset) / float64(w.Ramp)
+
+	return max(rate, min(1, w.RPS))
+}
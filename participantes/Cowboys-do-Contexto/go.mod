@@ -1,3 +1,3 @@
-module main
+module cowboys
 
 go 1.24.5
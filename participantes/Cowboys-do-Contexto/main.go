@@ -9,10 +9,15 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ---------------------- Tipos ----------------------
@@ -68,7 +73,11 @@ var httpClient = &http.Client{
 
 const llmModel = "openai/gpt-4o-mini"
 
-const systemPrompt = `{"t":"Classificar intenção (0–16) e retornar só o número. Se dúvida, 15 ou 0, avalie o contexto.","r":[0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16],"s":{"t":"string","id":"int(0–16)"},"e":[{"t":"Serviço não identificado","i":0},{"t":"Melhor dia de compra","i":1},{"t":"2ª via boleto","i":2},{"t":"2ª via fatura","i":3},{"t":"Entrega do cartão","i":4},{"t":"Cartão ativo?","i":5},{"t":"Aumento limite","i":6},{"t":"Cancelar cartão","i":7},{"t":"Telefone seguradora","i":8},{"t":"Desbloquear cartão","i":9},{"t":"Esqueci senha","i":10},{"t":"Perdi cartão","i":11},{"t":"Consultar saldo","i":12},{"t":"Pagar conta","i":13},{"t":"Reclamação","i":14},{"t":"Atendente","i":15},{"t":"Token proposta","i":16},{"t":"Ajuda","i":15}],"examples":[{"service_id":0,"intent":"oi tudo bem"},{"service_id":0,"intent":"preciso de ajuda mas não sei com o que"},{"service_id":0,"intent":"como funciona o aplicativo"},{"service_id":0,"intent":"quero saber mais sobre vocês"},{"service_id":0,"intent":"não entendi o que fazer"},{"service_id":0,"intent":"ajuda geral"},{"service_id":1,"intent":"Quanto tem disponível para usar"},{"service_id":1,"intent":"quando fecha minha fatura"},{"service_id":1,"intent":"Quando vence meu cartão"},{"service_id":1,"intent":"quando posso comprar"},{"service_id":1,"intent":"vencimento da fatura"},{"service_id":1,"intent":"valor para gastar"},{"service_id":2,"intent":"segunda via boleto de acordo"},{"service_id":2,"intent":"Boleto para pagar minha negociação"},{"service_id":2,"intent":"código de barras acordo"},{"service_id":2,"intent":"preciso pagar negociação"},{"service_id":2,"intent":"enviar boleto acordo"},{"service_id":2,"intent":"boleto da negociação"},{"service_id":3,"intent":"quero meu boleto"},{"service_id":3,"intent":"segunda via de fatura"},{"service_id":3,"intent":"código de barras fatura"},{"service_id":3,"intent":"quero a fatura do cartão"},{"service_id":3,"intent":"enviar boleto da fatura"},{"service_id":3,"intent":"fatura para pagamento"},{"service_id":4,"intent":"onde está meu cartão"},{"service_id":4,"intent":"meu cartão não chegou"},{"service_id":4,"intent":"status da entrega do cartão"},{"service_id":4,"intent":"cartão em transporte"},{"service_id":4,"intent":"previsão de entrega do cartão"},{"service_id":4,"intent":"cartão foi enviado?"},{"service_id":5,"intent":"não consigo passar meu cartão"},{"service_id":5,"intent":"meu cartão não funciona"},{"service_id":5,"intent":"cartão recusado"},{"service_id":5,"intent":"cartão não está passando"},{"service_id":5,"intent":"status do cartão ativo"},{"service_id":5,"intent":"problema com cartão"},{"service_id":6,"intent":"quero mais limite"},{"service_id":6,"intent":"aumentar limite do cartão"},{"service_id":6,"intent":"solicitar aumento de crédito"},{"service_id":6,"intent":"preciso de mais limite"},{"service_id":6,"intent":"pedido de aumento de limite"},{"service_id":6,"intent":"limite maior no cartão"},{"service_id":7,"intent":"cancelar cartão"},{"service_id":7,"intent":"quero encerrar meu cartão"},{"service_id":7,"intent":"bloquear cartão definitivamente"},{"service_id":7,"intent":"cancelamento de crédito"},{"service_id":7,"intent":"desistir do cartão"},{"service_id":8,"intent":"quero cancelar seguro"},{"service_id":8,"intent":"telefone do seguro"},{"service_id":8,"intent":"contato da seguradora"},{"service_id":8,"intent":"preciso falar com o seguro"},{"service_id":8,"intent":"seguro do cartão"},{"service_id":8,"intent":"cancelar assistência"},{"service_id":9,"intent":"desbloquear cartão"},{"service_id":9,"intent":"ativar cartão novo"},{"service_id":9,"intent":"como desbloquear meu cartão"},{"service_id":9,"intent":"quero desbloquear o cartão"},{"service_id":9,"intent":"cartão para uso imediato"},{"service_id":9,"intent":"desbloqueio para compras"},{"service_id":10,"intent":"não tenho mais a senha do cartão"},{"service_id":10,"intent":"esqueci minha senha"},{"service_id":10,"intent":"trocar senha do cartão"},{"service_id":10,"intent":"preciso de nova senha"},{"service_id":10,"intent":"recuperar senha"},{"service_id":10,"intent":"senha bloqueada"},{"service_id":11,"intent":"perdi meu cartão"},{"service_id":11,"intent":"roubaram meu cartão"},{"service_id":11,"intent":"cartão furtado"},{"service_id":11,"intent":"perda do cartão"},{"service_id":11,"intent":"bloquear cartão por roubo"},{"service_id":11,"intent":"extravio de cartão"},{"service_id":12,"intent":"saldo conta corrente"},{"service_id":12,"intent":"consultar saldo"},{"service_id":12,"intent":"quanto tenho na conta"},{"service_id":12,"intent":"extrato da conta"},{"service_id":12,"intent":"saldo disponível"},{"service_id":12,"intent":"meu saldo atual"},{"service_id":13,"intent":"quero pagar minha conta"},{"service_id":13,"intent":"pagar boleto"},{"service_id":13,"intent":"pagamento de conta"},{"service_id":13,"intent":"quero pagar fatura"},{"service_id":13,"intent":"efetuar pagamento"},{"service_id":14,"intent":"quero reclamar"},{"service_id":14,"intent":"abrir reclamação"},{"service_id":14,"intent":"fazer queixa"},{"service_id":14,"intent":"reclamar atendimento"},{"service_id":14,"intent":"registrar problema"},{"service_id":14,"intent":"protocolo de reclamação"},{"service_id":15,"intent":"falar com uma pessoa"},{"service_id":15,"intent":"preciso de humano"},{"service_id":15,"intent":"transferir para atendente"},{"service_id":15,"intent":"quero falar com atendente"},{"service_id":15,"intent":"atendimento pessoal"},{"service_id":16,"intent":"código para fazer meu cartão"},{"service_id":16,"intent":"token de proposta"},{"service_id":16,"intent":"receber código do cartão"},{"service_id":16,"intent":"proposta token"},{"service_id":16,"intent":"número de token"},{"service_id":16,"intent":"código de token da proposta"}]}`
+// systemPromptBase é o catálogo compacto; os exemplos entram por requisição
+const systemPromptBase = `{"t":"Classificar intenção (0–16) e retornar só o número. Se dúvida, 15 ou 0, avalie o contexto.","r":[0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16],"s":{"t":"string","id":"int(0–16)"},"e":[{"t":"Serviço não identificado","i":0},{"t":"Melhor dia de compra","i":1},{"t":"2ª via boleto","i":2},{"t":"2ª via fatura","i":3},{"t":"Entrega do cartão","i":4},{"t":"Cartão ativo?","i":5},{"t":"Aumento limite","i":6},{"t":"Cancelar cartão","i":7},{"t":"Telefone seguradora","i":8},{"t":"Desbloquear cartão","i":9},{"t":"Esqueci senha","i":10},{"t":"Perdi cartão","i":11},{"t":"Consultar saldo","i":12},{"t":"Pagar conta","i":13},{"t":"Reclamação","i":14},{"t":"Atendente","i":15},{"t":"Token proposta","i":16},{"t":"Ajuda","i":15}]}`
+
+// trainingExamples são os exemplos rotulados de onde saem os exemplos do prompt
+const trainingExamples = `[{"service_id":0,"intent":"oi tudo bem"},{"service_id":0,"intent":"preciso de ajuda mas não sei com o que"},{"service_id":0,"intent":"como funciona o aplicativo"},{"service_id":0,"intent":"quero saber mais sobre vocês"},{"service_id":0,"intent":"não entendi o que fazer"},{"service_id":0,"intent":"ajuda geral"},{"service_id":1,"intent":"Quanto tem disponível para usar"},{"service_id":1,"intent":"quando fecha minha fatura"},{"service_id":1,"intent":"Quando vence meu cartão"},{"service_id":1,"intent":"quando posso comprar"},{"service_id":1,"intent":"vencimento da fatura"},{"service_id":1,"intent":"valor para gastar"},{"service_id":2,"intent":"segunda via boleto de acordo"},{"service_id":2,"intent":"Boleto para pagar minha negociação"},{"service_id":2,"intent":"código de barras acordo"},{"service_id":2,"intent":"preciso pagar negociação"},{"service_id":2,"intent":"enviar boleto acordo"},{"service_id":2,"intent":"boleto da negociação"},{"service_id":3,"intent":"quero meu boleto"},{"service_id":3,"intent":"segunda via de fatura"},{"service_id":3,"intent":"código de barras fatura"},{"service_id":3,"intent":"quero a fatura do cartão"},{"service_id":3,"intent":"enviar boleto da fatura"},{"service_id":3,"intent":"fatura para pagamento"},{"service_id":4,"intent":"onde está meu cartão"},{"service_id":4,"intent":"meu cartão não chegou"},{"service_id":4,"intent":"status da entrega do cartão"},{"service_id":4,"intent":"cartão em transporte"},{"service_id":4,"intent":"previsão de entrega do cartão"},{"service_id":4,"intent":"cartão foi enviado?"},{"service_id":5,"intent":"não consigo passar meu cartão"},{"service_id":5,"intent":"meu cartão não funciona"},{"service_id":5,"intent":"cartão recusado"},{"service_id":5,"intent":"cartão não está passando"},{"service_id":5,"intent":"status do cartão ativo"},{"service_id":5,"intent":"problema com cartão"},{"service_id":6,"intent":"quero mais limite"},{"service_id":6,"intent":"aumentar limite do cartão"},{"service_id":6,"intent":"solicitar aumento de crédito"},{"service_id":6,"intent":"preciso de mais limite"},{"service_id":6,"intent":"pedido de aumento de limite"},{"service_id":6,"intent":"limite maior no cartão"},{"service_id":7,"intent":"cancelar cartão"},{"service_id":7,"intent":"quero encerrar meu cartão"},{"service_id":7,"intent":"bloquear cartão definitivamente"},{"service_id":7,"intent":"cancelamento de crédito"},{"service_id":7,"intent":"desistir do cartão"},{"service_id":8,"intent":"quero cancelar seguro"},{"service_id":8,"intent":"telefone do seguro"},{"service_id":8,"intent":"contato da seguradora"},{"service_id":8,"intent":"preciso falar com o seguro"},{"service_id":8,"intent":"seguro do cartão"},{"service_id":8,"intent":"cancelar assistência"},{"service_id":9,"intent":"desbloquear cartão"},{"service_id":9,"intent":"ativar cartão novo"},{"service_id":9,"intent":"como desbloquear meu cartão"},{"service_id":9,"intent":"quero desbloquear o cartão"},{"service_id":9,"intent":"cartão para uso imediato"},{"service_id":9,"intent":"desbloqueio para compras"},{"service_id":10,"intent":"não tenho mais a senha do cartão"},{"service_id":10,"intent":"esqueci minha senha"},{"service_id":10,"intent":"trocar senha do cartão"},{"service_id":10,"intent":"preciso de nova senha"},{"service_id":10,"intent":"recuperar senha"},{"service_id":10,"intent":"senha bloqueada"},{"service_id":11,"intent":"perdi meu cartão"},{"service_id":11,"intent":"roubaram meu cartão"},{"service_id":11,"intent":"cartão furtado"},{"service_id":11,"intent":"perda do cartão"},{"service_id":11,"intent":"bloquear cartão por roubo"},{"service_id":11,"intent":"extravio de cartão"},{"service_id":12,"intent":"saldo conta corrente"},{"service_id":12,"intent":"consultar saldo"},{"service_id":12,"intent":"quanto tenho na conta"},{"service_id":12,"intent":"extrato da conta"},{"service_id":12,"intent":"saldo disponível"},{"service_id":12,"intent":"meu saldo atual"},{"service_id":13,"intent":"quero pagar minha conta"},{"service_id":13,"intent":"pagar boleto"},{"service_id":13,"intent":"pagamento de conta"},{"service_id":13,"intent":"quero pagar fatura"},{"service_id":13,"intent":"efetuar pagamento"},{"service_id":14,"intent":"quero reclamar"},{"service_id":14,"intent":"abrir reclamação"},{"service_id":14,"intent":"fazer queixa"},{"service_id":14,"intent":"reclamar atendimento"},{"service_id":14,"intent":"registrar problema"},{"service_id":14,"intent":"protocolo de reclamação"},{"service_id":15,"intent":"falar com uma pessoa"},{"service_id":15,"intent":"preciso de humano"},{"service_id":15,"intent":"transferir para atendente"},{"service_id":15,"intent":"quero falar com atendente"},{"service_id":15,"intent":"atendimento pessoal"},{"service_id":16,"intent":"código para fazer meu cartão"},{"service_id":16,"intent":"token de proposta"},{"service_id":16,"intent":"receber código do cartão"},{"service_id":16,"intent":"proposta token"},{"service_id":16,"intent":"número de token"},{"service_id":16,"intent":"código de token da proposta"}]`
 
 func classifyWithLLM(ctx context.Context, intent string) (int, error) {
 	apiKey := os.Getenv("OPENROUTER_API_KEY")
@@ -76,8 +85,9 @@ func classifyWithLLM(ctx context.Context, intent string) (int, error) {
 		return 0, errors.New("OPENROUTER_API_KEY não definido")
 	}
 
+	prompt, nExamples, promptTokens := buildSystemPrompt(intent)
 	messages := []map[string]string{
-		{"role": "system", "content": systemPrompt},
+		{"role": "system", "content": prompt},
 		{"role": "user", "content": intent},
 	}
 
@@ -85,6 +95,7 @@ func classifyWithLLM(ctx context.Context, intent string) (int, error) {
 		"model":       llmModel,
 		"temperature": 0,
 		"max_tokens":  4,
+		"stream":      true,
 		"messages":    messages,
 	}
 
@@ -94,39 +105,330 @@ func classifyWithLLM(ctx context.Context, intent string) (int, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Title", "ura-classifier-proxy")
 
+	start := time.Now()
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return 0, err
 	}
-	defer resp.Body.Close()
+	defer resp.Body.Close() // fecha o stream mesmo se o modelo ainda estiver gerando
 
-	body, _ := io.ReadAll(bufio.NewReader(resp.Body))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(bufio.NewReader(resp.Body))
 		return 0, fmt.Errorf("openrouter: http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
-	var or struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+	id, ttft, err := readStreamID(resp.Body, start)
+	if err != nil {
+		return 0, err
 	}
-	if err := json.Unmarshal(body, &or); err != nil {
-		return 0, fmt.Errorf("falha ao decodificar resposta do LLM: %w", err)
+	log.Printf("llm: id=%d ttft=%dms total=%dms exemplos=%d prompt_tokens~%d", id, ttft.Milliseconds(), time.Since(start).Milliseconds(), nExamples, promptTokens)
+
+	if id < 1 || id > 16 {
+		return 0, nil // serviço não identificado
 	}
-	if len(or.Choices) == 0 {
-		return 0, errors.New("openrouter: nenhuma choice retornada")
+	return id, nil
+}
+
+// ---------------------- Exemplos (TF-IDF de trigramas) ----------------------
+
+// Em vez de mandar os 99 exemplos em todo prompt, manda só os mais parecidos
+// com a intent, divididos entre os serviços candidatos. Os termos são
+// trigramas de letras, então "cancelar" e "cancelamento", ou "cartao" digitado
+// sem acento, ainda casam sem stemmer nem lista de stopwords.
+const (
+	promptExamples   = 8 // exemplos por prompt
+	promptCandidates = 4 // serviços entre os quais os exemplos são divididos
+)
+
+type example struct {
+	ServiceID int    `json:"service_id"`
+	Intent    string `json:"intent"`
+}
+
+type scored struct {
+	example
+	score float64
+}
+
+// exampleIndex é um índice invertido: para cada trigrama, os exemplos que o
+// contêm com o peso TF-IDF dele no vetor (normalizado) do exemplo
+type exampleIndex struct {
+	examples []example
+	idf      map[string]float64
+	postings map[string][]posting
+	fixed    []scored // o primeiro exemplo de cada serviço, por id
+}
+
+type posting struct {
+	doc    int
+	weight float64
+}
+
+var examples = newExampleIndex(trainingExamples)
+
+func newExampleIndex(raw string) *exampleIndex {
+	idx := &exampleIndex{idf: map[string]float64{}, postings: map[string][]posting{}}
+	if err := json.Unmarshal([]byte(raw), &idx.examples); err != nil {
+		panic(fmt.Sprintf("trainingExamples inválido: %v", err))
 	}
 
-	content := strings.TrimSpace(or.Choices[0].Message.Content)
-	content = strings.Trim(content, " \t\r\n\"'`.,;")
+	counts := make([]map[string]int, len(idx.examples))
+	for i, ex := range idx.examples {
+		counts[i] = trigrams(ex.Intent)
+		for g := range counts[i] {
+			idx.idf[g]++ // por enquanto, a frequência nos documentos
+		}
+	}
 
-	id, err := strconv.Atoi(content)
-	if err != nil || id < 1 || id > 16 {
-		return 0, nil // serviço não identificado
+	// "car" e "tao" estão em quase todo exemplo e pesam perto de zero
+	n := float64(len(idx.examples))
+	for g, df := range idx.idf {
+		idx.idf[g] = math.Log(n / df)
 	}
-	return id, nil
+
+	seen := map[int]bool{}
+	for i, ex := range idx.examples {
+		for g, w := range idx.weigh(counts[i]) {
+			idx.postings[g] = append(idx.postings[g], posting{doc: i, weight: w})
+		}
+		if !seen[ex.ServiceID] {
+			seen[ex.ServiceID] = true
+			idx.fixed = append(idx.fixed, scored{example: ex})
+		}
+	}
+	sort.SliceStable(idx.fixed, func(a, b int) bool { return idx.fixed[a].ServiceID < idx.fixed[b].ServiceID })
+	return idx
+}
+
+// weigh: contagem × IDF, normalizado; trigramas fora do índice pesam zero
+func (idx *exampleIndex) weigh(counts map[string]int) map[string]float64 {
+	w := make(map[string]float64, len(counts))
+	var norm float64
+	for g, c := range counts {
+		if x := float64(c) * idx.idf[g]; x > 0 {
+			w[g] = x
+			norm += x * x
+		}
+	}
+	for g := range w {
+		w[g] /= math.Sqrt(norm)
+	}
+	return w
+}
+
+// retrieve devolve até k exemplos por similaridade de cosseno, só dos
+// serviços candidatos (os donos dos exemplos mais parecidos): cada candidato
+// tem direito a uma cota de k/candidatos e as vagas que sobram vão para os
+// melhores exemplos restantes. Intent sem trigrama conhecido recebe um
+// exemplo fixo por serviço (score 0).
+func (idx *exampleIndex) retrieve(intent string, k, candidates int) []scored {
+	scores := map[int]float64{}
+	for g, qw := range idx.weigh(trigrams(intent)) {
+		for _, p := range idx.postings[g] {
+			scores[p.doc] += qw * p.weight
+		}
+	}
+	if len(scores) == 0 {
+		return idx.fixed
+	}
+
+	docs := make([]int, 0, len(scores))
+	for doc := range scores {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(a, b int) bool {
+		if scores[docs[a]] != scores[docs[b]] {
+			return scores[docs[a]] > scores[docs[b]]
+		}
+		return docs[a] < docs[b]
+	})
+
+	taken := map[int]int{} // exemplos por candidato
+	for _, doc := range docs {
+		if id := idx.examples[doc].ServiceID; len(taken) < candidates {
+			if _, ok := taken[id]; !ok {
+				taken[id] = 0
+			}
+		}
+	}
+	quota := (k + len(taken) - 1) / len(taken)
+
+	picked := make([]bool, len(docs))
+	n := 0
+	for pass := 0; pass < 2; pass++ { // cota primeiro, sobra depois
+		for i, doc := range docs {
+			id := idx.examples[doc].ServiceID
+			count, ok := taken[id]
+			if !ok || picked[i] || n == k || (pass == 0 && count == quota) {
+				continue
+			}
+			picked[i] = true
+			taken[id]++
+			n++
+		}
+	}
+
+	out := make([]scored, 0, n)
+	for i, doc := range docs {
+		if picked[i] {
+			out = append(out, scored{idx.examples[doc], scores[doc]})
+		}
+	}
+	return out
+}
+
+// buildSystemPrompt junta o catálogo aos exemplos recuperados para a intent.
+// Devolve também quantos exemplos entraram e a estimativa de tokens do prompt
+// e da intent, a ~4 caracteres por token (sem tokenizer do modelo).
+func buildSystemPrompt(intent string) (prompt string, nExamples, tokens int) {
+	selected := examples.retrieve(intent, promptExamples, promptCandidates)
+	list := make([]example, len(selected))
+	for i, s := range selected {
+		list[i] = s.example
+	}
+
+	b, _ := json.Marshal(list)
+	prompt = strings.TrimSuffix(systemPromptBase, "}") + `,"examples":` + string(b) + "}"
+	chars := utf8.RuneCountInString(prompt) + utf8.RuneCountInString(intent)
+	return prompt, len(list), (chars + 3) / 4
+}
+
+// trigrams conta os trigramas das palavras da frase, em minúsculas, sem acento
+// e com as bordas marcadas: "cartão" → " ca", "car", "art", "rta", "tao", "ao ".
+// Palavras de até 2 letras ("o", "de", "me") ficam de fora.
+func trigrams(s string) map[string]int {
+	words := strings.FieldsFunc(strings.Map(foldAccent, strings.ToLower(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	out := map[string]int{}
+	for _, w := range words {
+		r := []rune(" " + w + " ")
+		if len(r) < 5 {
+			continue
+		}
+		for i := 0; i+3 <= len(r); i++ {
+			out[string(r[i:i+3])]++
+		}
+	}
+	return out
+}
+
+func foldAccent(r rune) rune {
+	switch r {
+	case 'á', 'à', 'â', 'ã':
+		return 'a'
+	case 'é', 'ê':
+		return 'e'
+	case 'í':
+		return 'i'
+	case 'ó', 'ô', 'õ':
+		return 'o'
+	case 'ú', 'ü':
+		return 'u'
+	case 'ç':
+		return 'c'
+	}
+	return r
+}
+
+// ---------------------- Streaming (SSE) ----------------------
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// readStreamID lê os eventos "data:" do stream e para assim que o conteúdo
+// tiver um ID completo: "3" só é completo seguido de espaço, aspas ou "}" (ou
+// no fim do stream), já que pode virar "16" ou "3a via"; "3." e "3," só no fim
+// do stream, já que podem virar "3. O cliente". Retorna 0 quando a resposta
+// não é um número. ttft é medido a partir de start, o envio da requisição
+func readStreamID(body io.Reader, start time.Time) (id int, ttft time.Duration, err error) {
+	scanner := bufio.NewScanner(body)
+
+	var content strings.Builder
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // linhas em branco e comentários ": OPENROUTER PROCESSING"
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return 0, ttft, fmt.Errorf("falha ao decodificar chunk do LLM: %w", err)
+		}
+		if chunk.Error != nil {
+			return 0, ttft, fmt.Errorf("openrouter: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		if ttft == 0 {
+			ttft = time.Since(start)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+
+		if id, done := parseStreamID(content.String(), false); done {
+			return id, ttft, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, ttft, err
+	}
+
+	id, _ = parseStreamID(content.String(), true)
+	return id, ttft, nil
+}
+
+// parseStreamID decide o ID a partir do conteúdo parcial. final indica o fim do stream
+func parseStreamID(content string, final bool) (int, bool) {
+	content = strings.TrimLeft(content, " \t\r\n\"'`")
+	digits := content
+	for i, r := range content {
+		if r < '0' || r > '9' {
+			digits = content[:i]
+			break
+		}
+	}
+
+	switch {
+	case content == "":
+		return 0, final
+	case digits == "":
+		return 0, true // não começa com número: serviço não identificado
+	case len(digits) == len(content):
+		if !final {
+			return 0, false // o próximo token pode continuar o número
+		}
+	case strings.ContainsRune(".,;", rune(content[len(digits)])):
+		// "3." é um ID; "1. O cliente" é texto
+		if strings.TrimSpace(content[len(digits)+1:]) != "" {
+			return 0, true
+		}
+		if !final {
+			return 0, false
+		}
+	case !strings.ContainsRune("\"'`} \t\r\n", rune(content[len(digits)])):
+		return 0, true // "2a via": texto, não um ID
+	}
+
+	id, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, true
+	}
+	return id, true
 }
 
 // ---------------------- HTTP ----------------------
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRetrieve(t *testing.T) {
+	tests := []struct {
+		intent    string
+		wantTop   int // serviço do exemplo mais parecido
+		wantFixed bool
+	}{
+		{intent: "quero cancelar meu cartão", wantTop: 7},
+		{intent: "cancelamento do cartao", wantTop: 7}, // outra forma da palavra, sem acento
+		{intent: "esqueci a senha", wantTop: 10},
+		{intent: "boleto do acordo", wantTop: 2},
+		{intent: "segunda via da fatura", wantTop: 3},
+		{intent: "limite", wantTop: 6},
+		{intent: "kkkk", wantFixed: true},
+		{intent: "me dá", wantFixed: true}, // só palavras curtas
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.intent, func(t *testing.T) {
+			out := examples.retrieve(tt.intent, promptExamples, promptCandidates)
+
+			if tt.wantFixed {
+				if len(out) != 17 {
+					t.Fatalf("retrieve(%q) = %d exemplos, want 17 (um por serviço)", tt.intent, len(out))
+				}
+				for i, s := range out {
+					if s.ServiceID != i || s.score != 0 {
+						t.Errorf("retrieve()[%d] = serviço %d, score %.3f, want serviço %d, score 0", i, s.ServiceID, s.score, i)
+					}
+				}
+				return
+			}
+
+			if len(out) != promptExamples {
+				t.Errorf("retrieve(%q) = %d exemplos, want %d", tt.intent, len(out), promptExamples)
+			}
+			if len(out) == 0 || out[0].ServiceID != tt.wantTop {
+				t.Fatalf("retrieve(%q) = %+v, want o serviço %d no topo", tt.intent, out, tt.wantTop)
+			}
+
+			services := map[int]bool{}
+			for i, s := range out {
+				services[s.ServiceID] = true
+				if i > 0 && s.score > out[i-1].score {
+					t.Errorf("retrieve()[%d].score = %.3f > %.3f, want decrescente", i, s.score, out[i-1].score)
+				}
+			}
+			if len(services) > promptCandidates {
+				t.Errorf("retrieve(%q) com %d serviços, want no máximo %d", tt.intent, len(services), promptCandidates)
+			}
+		})
+	}
+}
+
+func TestBuildSystemPrompt(t *testing.T) {
+	tests := []struct {
+		intent       string
+		wantExamples int
+	}{
+		{intent: "quero cancelar meu cartão", wantExamples: promptExamples},
+		{intent: "kkkk", wantExamples: 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.intent, func(t *testing.T) {
+			prompt, nExamples, tokens := buildSystemPrompt(tt.intent)
+
+			var parsed struct {
+				Examples []example `json:"examples"`
+			}
+			if err := json.Unmarshal([]byte(prompt), &parsed); err != nil {
+				t.Fatalf("prompt não é JSON: %v", err)
+			}
+			if nExamples != tt.wantExamples || len(parsed.Examples) != nExamples {
+				t.Errorf("nExamples = %d, no prompt %d, want %d", nExamples, len(parsed.Examples), tt.wantExamples)
+			}
+
+			chars := utf8.RuneCountInString(prompt) + utf8.RuneCountInString(tt.intent)
+			if want := (chars + 3) / 4; tokens != want {
+				t.Errorf("tokens = %d, want %d", tokens, want)
+			}
+		})
+	}
+}
+
+func TestParseStreamID(t *testing.T) {
+	tests := []struct {
+		content  string
+		final    bool
+		wantID   int
+		wantDone bool
+	}{
+		{content: "", wantDone: false},
+		{content: "", final: true, wantDone: true},
+		{content: "1", wantDone: false}, // pode virar 10..16
+		{content: "1", final: true, wantID: 1, wantDone: true},
+		{content: "16 ", wantID: 16, wantDone: true},
+		{content: "3\n", wantID: 3, wantDone: true},
+		{content: `"7"`, wantID: 7, wantDone: true},
+		{content: "2a via", wantDone: true},
+		{content: "1. O cliente", wantDone: true},
+		{content: "3.", wantDone: false}, // pode virar "3. O cliente"
+		{content: "3.", final: true, wantID: 3, wantDone: true},
+		{content: "12,", final: true, wantID: 12, wantDone: true},
+		{content: "7; \n", final: true, wantID: 7, wantDone: true},
+		{content: "não sei", wantDone: true},
+	}
+
+	for _, tt := range tests {
+		id, done := parseStreamID(tt.content, tt.final)
+		if id != tt.wantID || done != tt.wantDone {
+			t.Errorf("parseStreamID(%q, %v) = %d, %v, want %d, %v", tt.content, tt.final, id, done, tt.wantID, tt.wantDone)
+		}
+	}
+}
@@ -5,20 +5,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+
+	"github.com/TaysonMartinss/cacadores-de-corrida/participantes/cacadores-de-corrida/validator"
 )
 
 type ServiceClassifier struct {
 	apiKey     string
 	httpClient *http.Client
+	// noStructuredOutputs fica true quando o modelo recusa response_format
+	noStructuredOutputs atomic.Bool
+	// examples seleciona os exemplos de treinamento enviados com cada intent
+	examples *exampleIndex
 }
 
 type OpenRouterRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 type Message struct {
@@ -28,9 +37,15 @@ type Message struct {
 
 type OpenRouterResponse struct {
 	Choices []Choice `json:"choices"`
+	Usage   *Usage    `json:"usage,omitempty"`
 	Error   *APIError `json:"error,omitempty"`
 }
 
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
 type Choice struct {
 	Message Message `json:"message"`
 }
@@ -45,15 +60,22 @@ func NewServiceClassifier(apiKey string) (*ServiceClassifier, error) {
 		return nil, fmt.Errorf("API key não pode ser vazia")
 	}
 
+	examples, err := loadExamples(intentsCSV)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ServiceClassifier{
 		apiKey:     apiKey,
 		httpClient: &http.Client{},
+		examples:   newExampleIndex(examples),
 	}, nil
 }
 
 func (sc *ServiceClassifier) Classify(intent string) (int, string, error) {
 	systemPrompt := GetSystemPrompt()
-	userPrompt := fmt.Sprintf("Intent do cliente: %s", intent)
+	// Só os exemplos mais parecidos com a intent vão no prompt, não a base toda
+	userPrompt, stats := sc.examples.buildUserPrompt(intent)
 
 	reqBody := OpenRouterRequest{
 		Model: "openai/gpt-4o-mini", // Modelo eficiente e econômico
@@ -63,34 +85,26 @@ func (sc *ServiceClassifier) Classify(intent string) (int, string, error) {
 		},
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return 0, "", fmt.Errorf("erro ao serializar requisição: %w", err)
+	if !sc.noStructuredOutputs.Load() {
+		reqBody.ResponseFormat = serviceResponseFormat()
 	}
 
-	req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return 0, "", fmt.Errorf("erro ao criar requisição: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+sc.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("HTTP-Referer", "https://github.com/TaysonMartinss/cacadores-de-corrida")
-	req.Header.Set("X-Title", "Cacadores de Corrida - Hackathon")
-
-	resp, err := sc.httpClient.Do(req)
+	body, status, err := sc.send(&reqBody)
 	if err != nil {
-		return 0, "", fmt.Errorf("erro ao fazer requisição: %w", err)
+		return 0, "", err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, "", fmt.Errorf("erro ao ler resposta: %w", err)
+	// Modelo sem suporte a json_schema: repete sem response_format e conta com o reparo do parseResponse
+	if reqBody.ResponseFormat != nil && unsupportedResponseFormat(status, body) {
+		sc.noStructuredOutputs.Store(true)
+		reqBody.ResponseFormat = nil
+		if body, status, err = sc.send(&reqBody); err != nil {
+			return 0, "", err
+		}
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, "", fmt.Errorf("erro na API: status %d, body: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		return 0, "", fmt.Errorf("erro na API: status %d, body: %s", status, string(body))
 	}
 
 	var apiResp OpenRouterResponse
@@ -106,6 +120,12 @@ func (sc *ServiceClassifier) Classify(intent string) (int, string, error) {
 		return 0, "", fmt.Errorf("nenhuma resposta retornada pela API")
 	}
 
+	promptTokens := stats.Tokens
+	if apiResp.Usage != nil && apiResp.Usage.PromptTokens > 0 {
+		promptTokens = apiResp.Usage.PromptTokens
+	}
+	log.Printf("prompt: %d exemplos dos serviços %v, %d tokens (estimados %d)", stats.Examples, stats.Services, promptTokens, stats.Tokens)
+
 	// Extrair service_id e service_name da resposta
 	content := apiResp.Choices[0].Message.Content
 	serviceID, serviceName, err := parseResponse(content)
@@ -116,76 +136,86 @@ func (sc *ServiceClassifier) Classify(intent string) (int, string, error) {
 	return serviceID, serviceName, nil
 }
 
+// send envia a requisição e retorna o corpo e o status da resposta
+func (sc *ServiceClassifier) send(reqBody *OpenRouterRequest) ([]byte, int, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao serializar requisição: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+sc.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("HTTP-Referer", "https://github.com/TaysonMartinss/cacadores-de-corrida")
+	req.Header.Set("X-Title", "Cacadores de Corrida - Hackathon")
+
+	resp, err := sc.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao fazer requisição: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao ler resposta: %w", err)
+	}
+
+	return body, resp.StatusCode, nil
+}
+
 type ServiceResponse struct {
 	ServiceID   int    `json:"service_id"`
 	ServiceName string `json:"service_name"`
 }
 
+var (
+	// Padrão 1: ID: 1, Nome: Consulta Limite / Vencimento do cartão / Melhor dia de compra
+	idNameRe = regexp.MustCompile(`ID:\s*(\d+),?\s*Nome:\s*(.+?)(?:\n|$)`)
+	// Padrão 2: service_id: 1
+	serviceIDRe = regexp.MustCompile(`service_id[\"']?\s*:\s*(\d+)`)
+	// Padrão 3: ID em prosa, "ID: 3" ou "serviço 3"
+	proseIDRe = regexp.MustCompile(`(?i)\b(?:id|serviço|servico)\s*[:#\-]?\s*(\d{1,2})\b`)
+)
+
+// parseResponse valida a resposta contra o schema do catálogo. Se ela não
+// validar (modelo sem structured outputs), tenta repará-la; o nome retornado
+// no reparo sempre vem do catálogo
 func parseResponse(content string) (int, string, error) {
-	// Limpar conteúdo (remover possíveis markdown ou espaços extras)
 	content = strings.TrimSpace(content)
+	if serviceID, serviceName, err := validateResponse(content); err == nil {
+		return serviceID, serviceName, nil
+	}
+
+	// Reparo: limpar conteúdo (remover possíveis markdown ou espaços extras)
 	content = strings.Trim(content, "`")
 	if strings.HasPrefix(content, "json") {
 		content = strings.TrimPrefix(content, "json")
 		content = strings.TrimSpace(content)
 	}
 
-	// Tentar parsear JSON direto (formato principal esperado)
+	// JSON com nome divergente ou chaves extras
 	var svcResp ServiceResponse
 	if err := json.Unmarshal([]byte(content), &svcResp); err == nil {
-		if svcResp.ServiceID >= 1 && svcResp.ServiceID <= 16 {
-			return svcResp.ServiceID, svcResp.ServiceName, nil
+		if validator.IsValidService(svcResp.ServiceID) {
+			return svcResp.ServiceID, validator.GetServiceName(svcResp.ServiceID), nil
 		}
 	}
 
-	// Fallback: Tentar extrair com regex (formato antigo)
-	// Padrão 1: ID: 1, Nome: Consulta Limite / Vencimento do cartão / Melhor dia de compra
-	re := regexp.MustCompile(`ID:\s*(\d+),?\s*Nome:\s*(.+?)(?:\n|$)`)
-	matches := re.FindStringSubmatch(content)
-
-	if len(matches) >= 3 {
-		serviceID, err := strconv.Atoi(strings.TrimSpace(matches[1]))
-		if err == nil {
-			serviceName := strings.TrimSpace(matches[2])
-			return serviceID, serviceName, nil
+	for _, re := range []*regexp.Regexp{idNameRe, serviceIDRe, proseIDRe} {
+		matches := re.FindStringSubmatch(content)
+		if len(matches) < 2 {
+			continue
 		}
-	}
 
-	// Fallback 2: service_id: 1
-	re2 := regexp.MustCompile(`service_id[\"']?\s*:\s*(\d+)`)
-	matches2 := re2.FindStringSubmatch(content)
-	if len(matches2) >= 2 {
-		serviceID, _ := strconv.Atoi(matches2[1])
-		if serviceID >= 1 && serviceID <= 16 {
-			return serviceID, getServiceNameByID(serviceID), nil
+		serviceID, err := strconv.Atoi(strings.TrimSpace(matches[1]))
+		if err == nil && validator.IsValidService(serviceID) {
+			return serviceID, validator.GetServiceName(serviceID), nil
 		}
 	}
 
 	return 0, "", fmt.Errorf("formato de resposta inválido: %s", content)
 }
-
-func getServiceNameByID(id int) string {
-	services := map[int]string{
-		1:  "Consulta Limite / Vencimento do cartão / Melhor dia de compra",
-		2:  "Segunda via de boleto de acordo",
-		3:  "Segunda via de Fatura",
-		4:  "Status de Entrega do Cartão",
-		5:  "Status de cartão",
-		6:  "Solicitação de aumento de limite",
-		7:  "Cancelamento de cartão",
-		8:  "Telefones de seguradoras",
-		9:  "Desbloqueio de Cartão",
-		10: "Esqueceu senha / Troca de senha",
-		11: "Perda e roubo",
-		12: "Consulta do Saldo",
-		13: "Pagamento de contas",
-		14: "Reclamações",
-		15: "Atendimento humano",
-		16: "Token de proposta",
-	}
-	
-	if name, ok := services[id]; ok {
-		return name
-	}
-	return ""
-}
@@ -34,439 +34,10 @@ O ` + "`service_name`" + ` na sua resposta DEVE ser IDÊNTICO a um dos nomes na
 ## Regras de Classificação
 1. **Intenção Clara**: Se a intenção do usuário corresponde claramente a um dos 16 serviços, classifique-a com o ` + "`service_id`" + ` e ` + "`service_name`" + ` exato.
 2. **Intenção Ambigua**: Se a intenção é relacionada a serviços bancários, mas é ambígua, vaga ou não se encaixa perfeitamente em nenhum dos outros 15 serviços, direcione para {"service_id": 15, "service_name": "Atendimento humano"}.
-3. **Intenção Inválida**: Se a intenção do usuário **NÃO** tem relação alguma com serviços bancários/financeiros (ex: "receita de bolo", "clima hoje", "presidente dos EUA"), classifique como {"service_id": 0, "service_name": "INVALID"}.
-
-## EXEMPLOS DE TREINAMENTO (TODOS OS 141 CASOS)
-
-Intent: "Quanto tem disponível para usar"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "quando fecha minha fatura"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "Quando vence meu cartão"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "quando posso comprar"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "vencimento da fatura"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "valor para gastar"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "qual o meu limite de crédito atual?"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "meu cartão vira que dia?"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "data de fechamento da fatura"
-{"service_id": 1, "service_name": "Consulta Limite / Vencimento do cartão / Melhor dia de compra"}
-
-Intent: "segunda via boleto de acordo"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "Boleto para pagar minha negociação"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "código de barras acordo"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "preciso pagar negociação"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "enviar boleto acordo"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "boleto da negociação"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "perdi o boleto do meu acordo"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "me manda o pdf da negociação"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "quero pagar meu parcelamento"
-{"service_id": 2, "service_name": "Segunda via de boleto de acordo"}
-
-Intent: "quero meu boleto"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "segunda via de fatura"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "código de barras fatura"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "quero a fatura do cartão"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "enviar boleto da fatura"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "fatura para pagamento"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "preciso da fatura desse mês"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "emite meu boleto do cartão"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "não recebi minha fatura"
-{"service_id": 3, "service_name": "Segunda via de Fatura"}
-
-Intent: "onde está meu cartão"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "meu cartão não chegou"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "status da entrega do cartão"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "cartão em transporte"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "previsão de entrega do cartão"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "cartão foi enviado?"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "qual o rastreio do meu cartão novo?"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "a transportadora já passou?"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "quando meu cartão vai ser entregue?"
-{"service_id": 4, "service_name": "Status de Entrega do Cartão"}
-
-Intent: "não consigo passar meu cartão"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "meu cartão não funciona"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "cartão recusado"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "cartão não está passando"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "status do cartão ativo"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "problema com cartão"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "deu compra negada"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "meu cartão tá bloqueado?"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "por que não consigo usar meu cartão?"
-{"service_id": 5, "service_name": "Status de cartão"}
-
-Intent: "quero mais limite"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "aumentar limite do cartão"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "solicitar aumento de crédito"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "preciso de mais limite"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "pedido de aumento de limite"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "limite maior no cartão"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "meu limite tá baixo, pode aumentar?"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "como faço pra ter mais crédito?"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "quero mais saldo pra comprar"
-{"service_id": 6, "service_name": "Solicitação de aumento de limite"}
-
-Intent: "cancelar cartão"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "quero encerrar meu cartão"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "bloquear cartão definitivamente"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "cancelamento de crédito"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "desistir do cartão"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "não quero mais ter esse cartão"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "quero destruir meu cartão"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "como faço o cancelamento?"
-{"service_id": 7, "service_name": "Cancelamento de cartão"}
-
-Intent: "quero cancelar seguro"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "telefone do seguro"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "contato da seguradora"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "preciso falar com o seguro"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "seguro do cartão"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "cancelar assistência"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "qual o número da seguradora?"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "como aciono o seguro do cartão?"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "preciso do contato da assistência"
-{"service_id": 8, "service_name": "Telefones de seguradoras"}
-
-Intent: "desbloquear cartão"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "ativar cartão novo"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "como desbloquear meu cartão"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "quero desbloquear o cartão"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "cartão para uso imediato"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "desbloqueio para compras"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "meu cartão chegou, como ativo?"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "quero usar meu cartão pela primeira vez"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "ativar meu cartão"
-{"service_id": 9, "service_name": "Desbloqueio de Cartão"}
-
-Intent: "não tenho mais a senha do cartão"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "esqueci minha senha"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "trocar senha do cartão"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "preciso de nova senha"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "recuperar senha"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "senha bloqueada"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "não lembro a senha de 4 dígitos"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "como mudo minha senha?"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "quero cadastrar uma nova senha"
-{"service_id": 10, "service_name": "Esqueceu senha / Troca de senha"}
-
-Intent: "perdi meu cartão"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "roubaram meu cartão"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "cartão furtado"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "perda do cartão"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "bloquear cartão por roubo"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "extravio de cartão"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "fui assaltado e levaram meu cartão"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "sumiu meu cartão, preciso bloquear"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "bloqueio por furto"
-{"service_id": 11, "service_name": "Perda e roubo"}
-
-Intent: "saldo conta corrente"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "consultar saldo"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "quanto tenho na conta"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "extrato da conta"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "saldo disponível"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "meu saldo atual"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "ver meu saldo"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "quanto de dinheiro eu tenho?"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "me fala o saldo da conta"
-{"service_id": 12, "service_name": "Consulta do Saldo"}
-
-Intent: "quero pagar minha conta"
-{"service_id": 13, "service_name": "Pagamento de contas"}
-
-Intent: "pagar boleto"
-{"service_id": 13, "service_name": "Pagamento de contas"}
-
-Intent: "pagar uma conta de luz"
-{"service_id": 13, "service_name": "Pagamento de contas"}
-
-Intent: "posso usar pra pagar boleto?"
-{"service_id": 13, "service_name": "Pagamento de contas"}
-
-Intent: "quero pagar fatura"
-{"service_id": 13, "service_name": "Pagamento de contas"}
-
-Intent: "efetuar pagamento"
-{"service_id": 13, "service_name": "Pagamento de contas"}
-
-Intent: "quero quitar um boleto"
-{"service_id": 13, "service_name": "Pagamento de contas"}
-
-Intent: "quero reclamar"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "abrir reclamação"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "fazer queixa"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "reclamar atendimento"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "registrar problema"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "protocolo de reclamação"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "fui mal atendido"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "tive um problema com a cobrança"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "quero registrar uma insatisfação"
-{"service_id": 14, "service_name": "Reclamações"}
-
-Intent: "falar com uma pessoa"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "preciso de humano"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "transferir para atendente"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "quero falar com atendente"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "atendimento pessoal"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "não é nenhuma dessas opções"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "me tira daqui, quero um humano"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "opções"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "ajuda"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "oi"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "asdfghjkl"
-{"service_id": 15, "service_name": "Atendimento humano"}
-
-Intent: "código para fazer meu cartão"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "token de proposta"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "receber código do cartão"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "proposta token"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "número de token"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "código de token da proposta"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "não recebi o código da proposta"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "qual o token para finalizar?"
-{"service_id": 16, "service_name": "Token de proposta"}
-
-Intent: "preciso do código de aceite"
-{"service_id": 16, "service_name": "Token de proposta"}
+3. **Intenção Inválida**: Se a intenção do usuário **NÃO** tem relação alguma com serviços bancários/financeiros (ex: "receita de bolo", "clima hoje", "presidente dos EUA"), também direcione para {"service_id": 15, "service_name": "Atendimento humano"}: a resposta é validada contra um JSON Schema que só aceita os 16 serviços.
 
+## Exemplos
+A mensagem do usuário traz, antes da intent, exemplos rotulados semelhantes a ela, selecionados da base de treinamento. Use-os como referência para desempatar serviços parecidos, mas classifique a intent do cliente, não os exemplos.
 
 # O que NÃO fazer
 - **NUNCA** responda com nada além de um objeto JSON puro.
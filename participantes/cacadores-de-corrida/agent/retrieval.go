@@ -0,0 +1,290 @@
+package agent
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/TaysonMartinss/cacadores-de-corrida/participantes/cacadores-de-corrida/validator"
+)
+
+// intentsCSV são os exemplos rotulados (service_id;service_name;intent) que
+// antes ficavam todos no system prompt
+//
+//go:embed intents.csv
+var intentsCSV []byte
+
+const (
+	// maxExamples é quantos exemplos entram no prompt
+	maxExamples = 8
+	// maxCandidates é entre quantos serviços candidatos os exemplos são divididos
+	maxCandidates = 4
+)
+
+// Example é uma intent rotulada da base de treinamento
+type Example struct {
+	ServiceID int
+	Intent    string
+}
+
+// exampleIndex é um índice TF-IDF dos exemplos: cada intent vira um vetor
+// normalizado de pesos por termo, e a similaridade é o cosseno entre vetores
+type exampleIndex struct {
+	examples []Example
+	vectors  []map[string]float64
+	idf      map[string]float64
+	// sample tem o primeiro exemplo de cada serviço, por service_id, usado
+	// quando a intent não tem nenhum termo em comum com a base
+	sample []scoredExample
+}
+
+// scoredExample é um exemplo com a similaridade com a intent do cliente
+type scoredExample struct {
+	Example
+	score float64
+}
+
+func loadExamples(data []byte) ([]Example, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = ';'
+	reader.FieldsPerRecord = 3
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler exemplos: %w", err)
+	}
+
+	examples := make([]Example, 0, len(records))
+	for i, record := range records {
+		if i == 0 {
+			continue // cabeçalho
+		}
+
+		serviceID, err := strconv.Atoi(record[0])
+		if err != nil || !validator.ValidateResponse(serviceID, record[1]) {
+			return nil, fmt.Errorf("exemplo inválido na linha %d: %v", i+1, record)
+		}
+		examples = append(examples, Example{ServiceID: serviceID, Intent: record[2]})
+	}
+
+	return examples, nil
+}
+
+func newExampleIndex(examples []Example) *exampleIndex {
+	idx := &exampleIndex{
+		examples: examples,
+		vectors:  make([]map[string]float64, len(examples)),
+		idf:      make(map[string]float64),
+	}
+
+	terms := make([][]string, len(examples))
+	df := make(map[string]int)
+	for i, ex := range examples {
+		terms[i] = tokenize(ex.Intent)
+		seen := make(map[string]bool)
+		for _, t := range terms[i] {
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+
+	// Um termo presente em quase todos os exemplos, como "cartao", quase não
+	// distingue serviços; o +1 evita dividir por zero e zerar o peso
+	n := float64(len(examples))
+	for t, count := range df {
+		idx.idf[t] = math.Log((1+n)/(1+float64(count))) + 1
+	}
+
+	seen := make(map[int]bool)
+	for i, ex := range examples {
+		idx.vectors[i] = idx.vectorize(terms[i])
+		if !seen[ex.ServiceID] {
+			seen[ex.ServiceID] = true
+			idx.sample = append(idx.sample, scoredExample{Example: ex})
+		}
+	}
+	sort.SliceStable(idx.sample, func(a, b int) bool { return idx.sample[a].ServiceID < idx.sample[b].ServiceID })
+
+	return idx
+}
+
+// vectorize calcula o vetor TF-IDF normalizado; termos fora do índice são ignorados
+func (idx *exampleIndex) vectorize(terms []string) map[string]float64 {
+	tf := make(map[string]float64, len(terms))
+	for _, t := range terms {
+		if _, ok := idx.idf[t]; ok {
+			tf[t]++
+		}
+	}
+
+	var norm float64
+	for t, count := range tf {
+		w := (1 + math.Log(count)) * idx.idf[t]
+		tf[t] = w
+		norm += w * w
+	}
+
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for t := range tf {
+			tf[t] /= norm
+		}
+	}
+
+	return tf
+}
+
+// retrieve retorna até k exemplos mais similares à intent, divididos entre os
+// serviços candidatos (os de maior similaridade) para que o modelo veja os
+// serviços que competem pela intent, não só o mais provável. Sem nenhum termo
+// em comum com a base (gíria, erro de digitação, outra língua), não há
+// candidatos e retorna um exemplo de cada serviço, com similaridade zero.
+func (idx *exampleIndex) retrieve(intent string, k, candidates int) []scoredExample {
+	query := idx.vectorize(tokenize(intent))
+	if len(query) == 0 {
+		return idx.sample
+	}
+
+	byService := make(map[int][]scoredExample)
+	for i, vec := range idx.vectors {
+		var score float64
+		for t, w := range query {
+			score += w * vec[t]
+		}
+		if score > 0 {
+			ex := idx.examples[i]
+			byService[ex.ServiceID] = append(byService[ex.ServiceID], scoredExample{ex, score})
+		}
+	}
+
+	// Serviço candidato = melhor similaridade entre seus exemplos
+	services := make([]int, 0, len(byService))
+	for id, list := range byService {
+		sort.SliceStable(list, func(a, b int) bool { return list[a].score > list[b].score })
+		services = append(services, id)
+	}
+	sort.Slice(services, func(a, b int) bool {
+		sa, sb := byService[services[a]][0].score, byService[services[b]][0].score
+		if sa != sb {
+			return sa > sb
+		}
+		return services[a] < services[b]
+	})
+	if len(services) > candidates {
+		services = services[:candidates]
+	}
+
+	// Rodízio entre os candidatos: o melhor de cada um, depois o segundo...
+	selected := make([]scoredExample, 0, k)
+	for rank := 0; len(selected) < k; rank++ {
+		added := false
+		for _, id := range services {
+			if list := byService[id]; rank < len(list) && len(selected) < k {
+				selected = append(selected, list[rank])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	sort.SliceStable(selected, func(a, b int) bool { return selected[a].score > selected[b].score })
+	return selected
+}
+
+// PromptStats descreve o prompt montado para uma intent
+type PromptStats struct {
+	Examples int
+	Services []int
+	// Tokens é uma estimativa dos tokens do system prompt mais a mensagem do usuário
+	Tokens int
+}
+
+// buildUserPrompt monta a mensagem do usuário com os exemplos recuperados e a intent
+func (idx *exampleIndex) buildUserPrompt(intent string) (string, PromptStats) {
+	selected := idx.retrieve(intent, maxExamples, maxCandidates)
+
+	var sb strings.Builder
+	var stats PromptStats
+	if len(selected) > 0 {
+		if selected[0].score > 0 {
+			sb.WriteString("Exemplos semelhantes:\n")
+		} else {
+			sb.WriteString("Um exemplo de cada serviço:\n")
+		}
+		seen := make(map[int]bool)
+		for _, ex := range selected {
+			fmt.Fprintf(&sb, "Intent: %q\n{\"service_id\": %d, \"service_name\": %q}\n", ex.Intent, ex.ServiceID, validator.GetServiceName(ex.ServiceID))
+			if !seen[ex.ServiceID] {
+				seen[ex.ServiceID] = true
+				stats.Services = append(stats.Services, ex.ServiceID)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "Intent do cliente: %s", intent)
+
+	userPrompt := sb.String()
+	stats.Examples = len(selected)
+	stats.Tokens = estimateTokens(GetSystemPrompt()) + estimateTokens(userPrompt)
+
+	return userPrompt, stats
+}
+
+// estimateTokens aproxima a contagem de tokens em ~4 caracteres por token, o
+// suficiente para comparar prompts sem carregar o tokenizer do modelo
+func estimateTokens(s string) int {
+	return (len([]rune(s)) + 3) / 4
+}
+
+// stopwords não ajudam a distinguir serviços
+var stopwords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "um": true, "uma": true,
+	"de": true, "do": true, "da": true, "dos": true, "das": true, "no": true, "na": true,
+	"em": true, "para": true, "pra": true, "por": true, "com": true, "e": true, "que": true,
+	"meu": true, "minha": true, "meus": true, "minhas": true, "eu": true, "me": true, "se": true,
+	"esse": true, "essa": true, "desse": true, "dessa": true, "ja": true, "ta": true,
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+)
+
+// tokenize normaliza a intent em termos: minúsculas sem acento, sem
+// stopwords, no singular e truncados em 5 letras, para que "cancelar" e
+// "cancelamento" ou "cartão" e "cartões" virem o mesmo termo
+func tokenize(s string) []string {
+	s = accents.Replace(strings.ToLower(s))
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if len(w) < 2 || stopwords[w] {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(w, "oes"):
+			w = strings.TrimSuffix(w, "oes") + "ao"
+		case len(w) > 3 && strings.HasSuffix(w, "s"):
+			w = strings.TrimSuffix(w, "s")
+		}
+		if r := []rune(w); len(r) > 5 {
+			w = string(r[:5])
+		}
+		terms = append(terms, w)
+	}
+
+	return terms
+}
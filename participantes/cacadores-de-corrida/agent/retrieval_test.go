@@ -0,0 +1,121 @@
+package agent
+
+import "testing"
+
+func TestRetrieve(t *testing.T) {
+	examples, err := loadExamples(intentsCSV)
+	if err != nil {
+		t.Fatalf("loadExamples() error = %v", err)
+	}
+	idx := newExampleIndex(examples)
+
+	tests := []struct {
+		intent string
+		// want são os serviços que precisam aparecer, o primeiro no topo
+		want []int
+		// wantSample indica o fallback de um exemplo por serviço
+		wantSample bool
+	}{
+		// Serviços que disputam a mesma intent aparecem juntos
+		{intent: "quero cancelar meu cartao", want: []int{7, 8}},
+		{intent: "segunda via da fatura", want: []int{3, 2}},
+		{intent: "meu cartão foi roubado", want: []int{11, 4}},
+		{intent: "cancelamento do cartão", want: []int{7}},
+		{intent: "aumentar limites", want: []int{6}},
+		{intent: "esqueci a senha", want: []int{10}},
+		{intent: "wxyk zzz", wantSample: true},
+		{intent: "de para com a", wantSample: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.intent, func(t *testing.T) {
+			selected := idx.retrieve(tt.intent, maxExamples, maxCandidates)
+
+			services := make(map[int]bool)
+			for i, ex := range selected {
+				services[ex.ServiceID] = true
+				if i > 0 && ex.score > selected[i-1].score {
+					t.Errorf("exemplo %d com score %.3f depois de %.3f, want ordem decrescente", i, ex.score, selected[i-1].score)
+				}
+				if (ex.score == 0) != tt.wantSample {
+					t.Errorf("exemplo %q com score %.3f, wantSample %v", ex.Intent, ex.score, tt.wantSample)
+				}
+			}
+
+			if tt.wantSample {
+				if len(selected) != 16 || len(services) != 16 {
+					t.Errorf("retrieve(%q) = %d exemplos de %d serviços, want um de cada um dos 16", tt.intent, len(selected), len(services))
+				}
+				return
+			}
+
+			if len(selected) != maxExamples {
+				t.Errorf("retrieve(%q) = %d exemplos, want %d", tt.intent, len(selected), maxExamples)
+			}
+			if len(services) > maxCandidates {
+				t.Errorf("retrieve(%q) com %d serviços, want no máximo %d", tt.intent, len(services), maxCandidates)
+			}
+			if selected[0].ServiceID != tt.want[0] {
+				t.Errorf("retrieve(%q)[0] = %q (serviço %d), want serviço %d", tt.intent, selected[0].Intent, selected[0].ServiceID, tt.want[0])
+			}
+			for _, id := range tt.want[1:] {
+				if !services[id] {
+					t.Errorf("retrieve(%q) sem o serviço %d: %v", tt.intent, id, services)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildUserPrompt(t *testing.T) {
+	examples, err := loadExamples(intentsCSV)
+	if err != nil {
+		t.Fatalf("loadExamples() error = %v", err)
+	}
+	idx := newExampleIndex(examples)
+
+	tests := []struct {
+		intent       string
+		wantExamples int
+		wantServices int
+	}{
+		{intent: "quero a segunda via da fatura", wantExamples: maxExamples},
+		// Sem termo em comum, o prompt leva um exemplo de cada um dos 16 serviços
+		{intent: "wxyk zzz", wantExamples: 16, wantServices: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.intent, func(t *testing.T) {
+			userPrompt, stats := idx.buildUserPrompt(tt.intent)
+
+			if stats.Examples != tt.wantExamples {
+				t.Errorf("Examples = %d, want %d", stats.Examples, tt.wantExamples)
+			}
+			if tt.wantServices > 0 && len(stats.Services) != tt.wantServices {
+				t.Errorf("Services = %v, want %d serviços", stats.Services, tt.wantServices)
+			}
+			if len(stats.Services) > maxCandidates && tt.wantServices == 0 {
+				t.Errorf("Services = %v, want no máximo %d candidatos", stats.Services, maxCandidates)
+			}
+
+			if want := estimateTokens(GetSystemPrompt()) + estimateTokens(userPrompt); stats.Tokens != want {
+				t.Errorf("Tokens = %d, want %d", stats.Tokens, want)
+			}
+		})
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := map[string]int{
+		"":        0,
+		"abcd":    1,
+		"abcde":   2,
+		"cartões": 2, // conta runas, não bytes
+	}
+
+	for s, want := range tests {
+		if got := estimateTokens(s); got != want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
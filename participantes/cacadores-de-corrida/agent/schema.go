@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TaysonMartinss/cacadores-de-corrida/participantes/cacadores-de-corrida/validator"
+)
+
+// ResponseFormat é o campo response_format de structured outputs da OpenRouter
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+// serviceResponseFormat gera o JSON Schema a partir do catálogo do validator:
+// service_id é um dos IDs de 1 a 16 e service_name um dos nomes. A
+// correspondência entre os dois é conferida em validateResponse
+func serviceResponseFormat() *ResponseFormat {
+	ids := make([]int, len(validator.Services))
+	names := make([]string, len(validator.Services))
+	for i, s := range validator.Services {
+		ids[i], names[i] = s.ID, s.Name
+	}
+
+	return &ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &JSONSchema{
+			Name:   "service_classification",
+			Strict: true,
+			Schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"service_id":   map[string]any{"type": "integer", "enum": ids},
+					"service_name": map[string]any{"type": "string", "enum": names},
+				},
+				"required":             []string{"service_id", "service_name"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// validateResponse valida a resposta contra o schema: exatamente service_id e
+// service_name, com o nome correspondente ao ID
+func validateResponse(content string) (int, string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		return 0, "", err
+	}
+
+	if len(fields) != 2 {
+		return 0, "", fmt.Errorf("esperado apenas service_id e service_name: %s", content)
+	}
+
+	var svcResp ServiceResponse
+	if err := json.Unmarshal([]byte(content), &svcResp); err != nil {
+		return 0, "", err
+	}
+
+	if !validator.ValidateResponse(svcResp.ServiceID, svcResp.ServiceName) {
+		return 0, "", fmt.Errorf("serviço fora do catálogo: %d - %s", svcResp.ServiceID, svcResp.ServiceName)
+	}
+
+	return svcResp.ServiceID, svcResp.ServiceName, nil
+}
+
+// unsupportedResponseFormat indica se a API recusou a requisição por falta de
+// suporte a structured outputs no modelo
+func unsupportedResponseFormat(status int, body []byte) bool {
+	if status != http.StatusBadRequest && status != http.StatusNotFound {
+		return false
+	}
+
+	lower := strings.ToLower(string(body))
+	for _, hint := range []string{"response_format", "json_schema", "structured output", "requested parameters"} {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
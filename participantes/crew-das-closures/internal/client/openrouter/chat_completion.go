@@ -17,10 +17,28 @@ type (
 	OpenRouterRequest struct {
 		Model    string          `json:"model"`
 		Messages []PromptMessage `json:"messages"`
+		Usage    *UsageOptions   `json:"usage,omitempty"`
+		// Temperature is left to the provider default when nil
+		Temperature *float64 `json:"temperature,omitempty"`
+	}
+
+	// UsageOptions asks OpenRouter to include the generation cost in the usage block
+	UsageOptions struct {
+		Include bool `json:"include"`
 	}
 
 	OpenRouterResponse struct {
+		Model   string   `json:"model"`
 		Choices []Choice `json:"choices"`
+		Usage   Usage    `json:"usage"`
+	}
+
+	// Usage is the token and cost accounting of a single completion
+	Usage struct {
+		PromptTokens     int     `json:"prompt_tokens"`
+		CompletionTokens int     `json:"completion_tokens"`
+		TotalTokens      int     `json:"total_tokens"`
+		Cost             float64 `json:"cost"`
 	}
 
 	Choice struct {
@@ -35,6 +53,10 @@ type (
 		ServiceID   uint8  `json:"service_id"`
 		ServiceName string `json:"service_name"`
 		Result      string `json:"result"`
+
+		// Model and Usage come from the OpenRouter response, not from the model output
+		Model string `json:"-"`
+		Usage Usage  `json:"-"`
 	}
 
 	ContextPrompt struct {
@@ -49,6 +71,9 @@ type (
 	}
 )
 
+// ChatCompletion sends the request and decodes the service pair from the model
+// output. When the output cannot be decoded the returned response still
+// carries Model and Usage, since the generation has already been billed.
 func (c *Client) ChatCompletion(ctx context.Context, request *OpenRouterRequest) (*DataResponse, error) {
 	if request == nil {
 		return nil, fmt.Errorf("request cannot be nil")
@@ -66,7 +91,7 @@ func (c *Client) ChatCompletion(ctx context.Context, request *OpenRouterRequest)
 		return nil, fmt.Errorf("error encoding request: %v", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(buf.Bytes()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf.Bytes()))
 	if err != nil {
 		buf.Reset()
 		bufPool.Put(buf)
@@ -102,17 +127,25 @@ func (c *Client) ChatCompletion(ctx context.Context, request *OpenRouterRequest)
 	}
 
 	reasoning, response, err := filterReasoning(openRouterResp.Choices)
+	model, usage := openRouterResp.Model, openRouterResp.Usage
 	// Return the pooled object after extracting needed data
 	openRouterRespPool.Put(openRouterResp)
 	if err != nil {
 		return nil, fmt.Errorf("error filtering reasoning: %v", err)
 	}
 
+	if model == "" {
+		model = request.Model
+	}
+
 	var dataRes DataResponse
 	if err := json.NewDecoder(strings.NewReader(response)).Decode(&dataRes); err != nil {
-		return nil, fmt.Errorf("error unmarshaling data response: %v", err)
+		return &DataResponse{Model: model, Usage: usage}, fmt.Errorf("error unmarshaling data response: %v", err)
 	}
 
+	dataRes.Model = model
+	dataRes.Usage = usage
+
 	// Optionally log reasoning for debugging
 	if reasoning != "" {
 		fmt.Printf("Reasoning: %s\n", reasoning)
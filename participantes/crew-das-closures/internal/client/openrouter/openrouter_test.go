@@ -2,6 +2,7 @@ package openrouter
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -92,3 +93,76 @@ func TestNewClient_WithAuthOption(t *testing.T) {
 		t.Fatalf("Do() error: %v", err)
 	}
 }
+
+func TestChatCompletion_ParsesUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenRouterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Usage == nil || !req.Usage.Include {
+			t.Errorf("request usage.include not set")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"model": "openai/gpt-4o-mini",
+			"choices": [{"message": {"content": "{\"service_id\": 3, \"service_name\": \"Segunda via de Fatura\"}"}}],
+			"usage": {"prompt_tokens": 120, "completion_tokens": 15, "total_tokens": 135, "cost": 0.00042}
+		}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+
+	res, err := c.ChatCompletion(context.Background(), &OpenRouterRequest{
+		Model:    "gpt-4o-mini",
+		Messages: []PromptMessage{{Role: "user", Content: "segunda via"}},
+		Usage:    &UsageOptions{Include: true},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion() error: %v", err)
+	}
+
+	if res.ServiceID != 3 {
+		t.Errorf("ServiceID = %d, want 3", res.ServiceID)
+	}
+
+	if res.Model != "openai/gpt-4o-mini" {
+		t.Errorf("Model = %q, want %q", res.Model, "openai/gpt-4o-mini")
+	}
+
+	want := Usage{PromptTokens: 120, CompletionTokens: 15, TotalTokens: 135, Cost: 0.00042}
+	if res.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", res.Usage, want)
+	}
+}
+
+func TestChatCompletion_UsageOnInvalidOutput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"choices": [{"message": {"content": "not json"}}],
+			"usage": {"prompt_tokens": 100, "completion_tokens": 2, "total_tokens": 102, "cost": 0.0001}
+		}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+
+	res, err := c.ChatCompletion(context.Background(), &OpenRouterRequest{Model: "gpt-4o"})
+	if err == nil {
+		t.Fatal("ChatCompletion() error = nil, want decode error")
+	}
+
+	if res == nil {
+		t.Fatal("ChatCompletion() response = nil, want usage of the billed completion")
+	}
+
+	if res.Model != "gpt-4o" {
+		t.Errorf("Model = %q, want request model %q", res.Model, "gpt-4o")
+	}
+
+	if res.Usage.TotalTokens != 102 {
+		t.Errorf("Usage.TotalTokens = %d, want 102", res.Usage.TotalTokens)
+	}
+}
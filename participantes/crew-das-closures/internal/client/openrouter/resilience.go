@@ -0,0 +1,342 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The options below wrap the doFunc chain, so the last option passed to
+// NewClient runs first. The usual order is innermost first:
+//
+//	NewClient(baseURL,
+//		WithAuth(token),
+//		WithAttemptTimeout(10*time.Second),
+//		WithRateLimit(5, 10),
+//		WithCircuitBreaker(5, 30*time.Second),
+//		WithRetry(DefaultRetryPolicy()),
+//	)
+//
+// Every attempt then gets its own timeout, waits for a rate limit token and
+// counts towards the circuit breaker, while the retry loop sits on top.
+
+// ErrCircuitOpen is returned without calling the upstream while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("openrouter: circuit breaker is open")
+
+// RetryPolicy configures WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, the first one included.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles on every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After longer than MaxDelay is not
+	// waited for and the response is returned as is.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns 3 attempts with a backoff from 200ms up to 5s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// backoff returns a full-jitter delay for the given retry, starting at 0.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.MaxDelay
+	if shift := uint(retry); shift < 32 {
+		if d := p.BaseDelay << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// WithRetry retries transport errors, 429 and 5xx responses with jittered
+// exponential backoff, honouring the Retry-After header when present.
+// Requests whose body cannot be rewound are not retried.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		next := c.doFunc
+		c.doFunc = func(c *Client, req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+
+			for attempt := 1; ; attempt++ {
+				resp, err := next(c, req)
+				if attempt >= policy.MaxAttempts || !retryable(resp, err) || ctx.Err() != nil {
+					return resp, err
+				}
+
+				if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+					return resp, err
+				}
+
+				delay := policy.backoff(attempt - 1)
+				if resp != nil {
+					if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+						if after > policy.MaxDelay {
+							return resp, err
+						}
+						delay = max(delay, after)
+					}
+
+					// Drain the body so the connection can be reused
+					_, _ = io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+
+				if err := sleep(ctx, delay); err != nil {
+					return nil, err
+				}
+
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					req.Body = body
+				}
+			}
+		}
+	}
+}
+
+// retryable reports whether the outcome of an attempt is worth retrying.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithAttemptTimeout bounds every attempt, reading the response body included,
+// to timeout. Unlike http.Client.Timeout it applies per attempt when combined with WithRetry.
+func WithAttemptTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		next := c.doFunc
+		c.doFunc = func(c *Client, req *http.Request) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+
+			resp, err := next(c, req.WithContext(ctx))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+	}
+}
+
+// cancelOnClose releases the attempt context once the body has been consumed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// WithRateLimit limits the requests to rps per second with bursts of up to
+// burst requests, waiting for a token or for the request context to end.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		bucket := newTokenBucket(rps, burst)
+		next := c.doFunc
+		c.doFunc = func(c *Client, req *http.Request) (*http.Response, error) {
+			if err := bucket.wait(req.Context()); err != nil {
+				return nil, err
+			}
+			return next(c, req)
+		}
+	}
+}
+
+// tokenBucket is a token bucket rate limiter. It is safe for concurrent use.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	b := float64(max(burst, 1))
+	return &tokenBucket{rate: rps, burst: b, tokens: b, last: time.Now()}
+}
+
+// wait takes a token, blocking until one is available.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// WithCircuitBreaker opens the circuit after threshold consecutive failures
+// (transport errors and 5xx responses). While open, requests fail with
+// ErrCircuitOpen; after cooldown a single probe request is let through and
+// its outcome closes or reopens the circuit.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		breaker := &circuitBreaker{threshold: max(threshold, 1), cooldown: cooldown}
+		next := c.doFunc
+		c.doFunc = func(c *Client, req *http.Request) (*http.Response, error) {
+			if !breaker.allow() {
+				return nil, ErrCircuitOpen
+			}
+
+			resp, err := next(c, req)
+			switch {
+			case err != nil && req.Context().Err() != nil:
+				// Cancelled by the caller; says nothing about the upstream
+				breaker.release()
+			case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+				breaker.failure()
+			default:
+				breaker.success()
+			}
+
+			return resp, err
+		}
+	}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker holds the breaker state. It is safe for concurrent use.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a request may go through, moving an open circuit to
+// half-open once the cooldown has passed.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = circuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.state = circuitOpen
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+}
+
+// release frees the half-open probe slot without recording an outcome.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
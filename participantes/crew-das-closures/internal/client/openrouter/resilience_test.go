@@ -0,0 +1,321 @@
+package openrouter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers each call with the status returned by status(n), n
+// starting at 1, and echoes the request body on 200
+func flakyServer(t *testing.T, status func(n int32, w http.ResponseWriter) int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+
+		code := status(n, w)
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			_, _ = w.Write(body)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func post(t *testing.T, c *Client, ctx context.Context, url, body string) (*http.Response, error) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+
+	return c.Do(ctx, req)
+}
+
+func fastRetry(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+}
+
+func TestDo_PropagatesContext(t *testing.T) {
+	srv, calls := flakyServer(t, func(int32, http.ResponseWriter) int { return http.StatusOK })
+	c := NewClient(srv.URL, WithRetry(fastRetry(3)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := post(t, c, ctx, srv.URL, "{}"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do() error = %v, want context.Canceled", err)
+	}
+
+	if calls.Load() != 0 {
+		t.Errorf("server calls = %d, want 0", calls.Load())
+	}
+}
+
+func TestWithRetry_RetriesServerErrors(t *testing.T) {
+	srv, calls := flakyServer(t, func(n int32, _ http.ResponseWriter) int {
+		if n < 3 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	c := NewClient(srv.URL, WithRetry(fastRetry(3)))
+
+	resp, err := post(t, c, context.Background(), srv.URL, `{"model":"x"}`)
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	// The body must be rewound for every attempt
+	if body, _ := io.ReadAll(resp.Body); string(body) != `{"model":"x"}` {
+		t.Errorf("echoed body = %q, want the original request body", body)
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("server calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestWithRetry_GivesUp(t *testing.T) {
+	srv, calls := flakyServer(t, func(int32, http.ResponseWriter) int { return http.StatusBadGateway })
+	c := NewClient(srv.URL, WithRetry(fastRetry(3)))
+
+	resp, err := post(t, c, context.Background(), srv.URL, "{}")
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 3 {
+		t.Errorf("status = %d after %d calls, want 502 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestWithRetry_SkipsClientErrors(t *testing.T) {
+	srv, calls := flakyServer(t, func(int32, http.ResponseWriter) int { return http.StatusBadRequest })
+	c := NewClient(srv.URL, WithRetry(fastRetry(3)))
+
+	resp, err := post(t, c, context.Background(), srv.URL, "{}")
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 1 {
+		t.Errorf("server calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestWithRetry_HonoursRetryAfter(t *testing.T) {
+	srv, calls := flakyServer(t, func(n int32, w http.ResponseWriter) int {
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			return http.StatusTooManyRequests
+		}
+		return http.StatusOK
+	})
+	c := NewClient(srv.URL, WithRetry(fastRetry(2)))
+
+	start := time.Now()
+	resp, err := post(t, c, context.Background(), srv.URL, "{}")
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s Retry-After", elapsed)
+	}
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("status = %d after %d calls, want 200 after 2", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestWithRetry_RetryAfterBeyondMaxDelay(t *testing.T) {
+	srv, calls := flakyServer(t, func(_ int32, w http.ResponseWriter) int {
+		w.Header().Set("Retry-After", "60")
+		return http.StatusTooManyRequests
+	})
+	c := NewClient(srv.URL, WithRetry(fastRetry(3)))
+
+	resp, err := post(t, c, context.Background(), srv.URL, "{}")
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("status = %d after %d calls, want 429 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestWithAttemptTimeout(t *testing.T) {
+	srv, calls := flakyServer(t, func(n int32, _ http.ResponseWriter) int {
+		if n == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return http.StatusOK
+	})
+	c := NewClient(srv.URL, WithAttemptTimeout(50*time.Millisecond), WithRetry(fastRetry(2)))
+
+	resp, err := post(t, c, context.Background(), srv.URL, "ok")
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The body is still readable after Do returns; the attempt context ends on Close
+	if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+		t.Errorf("body = %q, want ok", body)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("server calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	srv, _ := flakyServer(t, func(int32, http.ResponseWriter) int { return http.StatusOK })
+	c := NewClient(srv.URL, WithRateLimit(20, 1))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := post(t, c, context.Background(), srv.URL, "{}")
+		if err != nil {
+			t.Fatalf("Do() error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// One token up front, then one every 50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 100ms at 20 rps", elapsed)
+	}
+}
+
+func TestWithRateLimit_ContextCancelled(t *testing.T) {
+	srv, calls := flakyServer(t, func(int32, http.ResponseWriter) int { return http.StatusOK })
+	c := NewClient(srv.URL, WithRateLimit(0.1, 1))
+
+	resp, err := post(t, c, context.Background(), srv.URL, "{}")
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := post(t, c, ctx, srv.URL, "{}"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("server calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	srv, calls := flakyServer(t, func(int32, http.ResponseWriter) int {
+		if healthy.Load() {
+			return http.StatusOK
+		}
+		return http.StatusInternalServerError
+	})
+	c := NewClient(srv.URL, WithCircuitBreaker(2, 50*time.Millisecond))
+
+	do := func() (*http.Response, error) {
+		resp, err := post(t, c, context.Background(), srv.URL, "{}")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := do(); err != nil {
+			t.Fatalf("Do() #%d error: %v", i+1, err)
+		}
+	}
+
+	// Open: fails fast without calling the upstream
+	if _, err := do(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do() error = %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("server calls = %d, want 2", calls.Load())
+	}
+
+	// Half-open: a failed probe reopens the circuit
+	time.Sleep(60 * time.Millisecond)
+	if _, err := do(); err != nil {
+		t.Fatalf("probe error: %v", err)
+	}
+	if _, err := do(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do() after failed probe error = %v, want ErrCircuitOpen", err)
+	}
+
+	// Half-open: a successful probe closes it
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if resp, err := do(); err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Do() after recovery = %v, %v, want 200", resp, err)
+		}
+	}
+
+	if calls.Load() != 6 {
+		t.Errorf("server calls = %d, want 6", calls.Load())
+	}
+}
+
+func TestCircuitBreaker_SingleProbe(t *testing.T) {
+	b := &circuitBreaker{threshold: 1, cooldown: 0}
+	b.failure()
+
+	if !b.allow() {
+		t.Fatal("allow() = false, want the probe through")
+	}
+	if b.allow() {
+		t.Fatal("allow() = true while probing, want false")
+	}
+
+	b.success()
+	if !b.allow() || !b.allow() {
+		t.Error("allow() = false after a successful probe, want closed")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("retryAfter(3) = %v, %v", d, ok)
+	}
+
+	date := time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(date); !ok || d <= 0 || d > 2*time.Second {
+		t.Errorf("retryAfter(%s) = %v, %v", date, d, ok)
+	}
+
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("retryAfter(soon) ok = true, want false")
+	}
+}
@@ -12,26 +12,53 @@ import (
 	"github.com/dyammarcano/crew-das-closures/internal/client/openrouter"
 	"github.com/dyammarcano/crew-das-closures/internal/model"
 	"github.com/dyammarcano/crew-das-closures/internal/prompt"
+	"github.com/dyammarcano/crew-das-closures/internal/prompt/models"
 )
 
 type Core struct {
 	*openrouter.Client
 	*prompt.PromptManager
+	// Usage aggregates the tokens and cost reported by OpenRouter
+	Usage *models.UsageTracker
+	// Selector picks the model of each request from the intent complexity and
+	// the latency, success and cost recorded in its PerformanceMonitor
+	Selector *models.ModelSelector
+	// Ensemble fans each intent out to several models when its strategy is
+	// race or vote; Agreement records how the models compare with the decisions
+	Ensemble  EnsembleConfig
+	Agreement *models.AgreementTracker
 }
 
+// attemptTimeout bounds each completion, so a slow primary model still leaves
+// time to retry with the fallback model
+const attemptTimeout = 10 * time.Second
+
 var findReqPool = sync.Pool{New: func() any { return new(model.FindServiceRequest) }}
 
-func NewCore(urlStr string, opts openrouter.Option) (*Core, error) {
-	return &Core{
-		Client:        openrouter.NewClient(urlStr, opts),
+func NewCore(urlStr string, opts ...openrouter.Option) (*Core, error) {
+	c := &Core{
+		Client:        openrouter.NewClient(urlStr, opts...),
 		PromptManager: prompt.NewPromptManager(),
-	}, nil
+		Usage:         models.NewUsageTracker(),
+		Agreement:     models.NewAgreementTracker(),
+	}
+	c.SetModelSelector(models.NewModelSelector())
+
+	return c, nil
+}
+
+// SetModelSelector replaces the model selector. Its cost estimates use the
+// usage reported by OpenRouter.
+func (c *Core) SetModelSelector(selector *models.ModelSelector) {
+	selector.SetUsageTracker(c.Usage)
+	c.Selector = selector
 }
 
 // AskQuestion decodes the request, prepares a (mock) service response, and analyzes
 // coherence issues between input and output. Diagnostics are returned to help
-// detect problems early when integrating with external APIs.
-func (c *Core) AskQuestion(question []byte) (*model.FindServiceResponse, error) {
+// detect problems early when integrating with external APIs. Cancelling ctx,
+// as a client disconnect does, aborts the model calls still running.
+func (c *Core) AskQuestion(ctx context.Context, question []byte) (*model.FindServiceResponse, error) {
 	obj := findReqPool.Get().(*model.FindServiceRequest)
 	// reset fields (only one field now, but future-proof)
 	*obj = model.FindServiceRequest{}
@@ -41,36 +68,25 @@ func (c *Core) AskQuestion(question []byte) (*model.FindServiceResponse, error)
 	}
 	defer findReqPool.Put(obj)
 
-	// montar o prompt para o OpenRouter com base no obj.Intent
-	result, err := c.PromptManager.GenerateModelSpecificPrompt(obj.Intent)
-	if err != nil {
-		return nil, err
-	}
-
-	var msgs []openrouter.PromptMessage
-
-	msg := openrouter.PromptMessage{
-		Role:    "user",
-		Content: result,
-	}
-
-	msgs = append(msgs, msg)
-
-	oRequest := &openrouter.OpenRouterRequest{
-		Model:    "gpt-4o",
-		Messages: msgs,
+	var (
+		response *openrouter.DataResponse
+		err      error
+	)
+	switch c.Ensemble.Strategy {
+	case StrategyRace:
+		response, err = c.race(ctx, obj.Intent)
+	case StrategyVote:
+		response, err = c.vote(ctx, obj.Intent)
+	default:
+		response, err = c.single(ctx, obj.Intent)
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-
-	response, err := c.Client.ChatCompletion(ctx, oRequest)
 	if err != nil {
 		return nil, err
 	}
 
 	// Normalize/validate the model output against the canonical service registry
 	normalizedID, normalizedName, normDiag := c.normalizeServicePair(response.ServiceID, response.ServiceName)
+	c.recordUsage(response, normalizedID)
 
 	sData := &model.ServiceData{
 		ServiceID:   normalizedID,
@@ -101,6 +117,87 @@ func (c *Core) AskQuestion(question []byte) (*model.FindServiceResponse, error)
 	}, nil
 }
 
+// single asks the model picked by the selector and retries once with the
+// fallback model when it fails, unless ctx is done
+func (c *Core) single(ctx context.Context, intent string) (*openrouter.DataResponse, error) {
+	// escolher o modelo pela complexidade do intent e pelas métricas observadas
+	recommendation := c.Selector.SelectModel(intent)
+
+	response, err := c.complete(ctx, intent, recommendation.ModelName, nil)
+	if fallback := c.Selector.FallbackModel(); err != nil && ctx.Err() == nil && recommendation.ModelName != fallback {
+		log.Printf("Model %s failed (%v), retrying with %s", recommendation.ModelName, err, fallback)
+		response, err = c.complete(ctx, intent, fallback, nil)
+	}
+
+	return response, err
+}
+
+// complete asks modelName to classify the intent and records the latency,
+// success and cost of the attempt in the selector's PerformanceMonitor. A
+// completion that does not name a known service counts as a failure; one
+// aborted because ctx is done is not recorded, since the model is not to blame.
+func (c *Core) complete(ctx context.Context, intent, modelName string, temperature *float64) (*openrouter.DataResponse, error) {
+	content, err := c.PromptManager.GenerateModelSpecificPrompt(intent, modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	oRequest := &openrouter.OpenRouterRequest{
+		Model:       modelName,
+		Messages:    []openrouter.PromptMessage{{Role: "user", Content: content}},
+		Usage:       &openrouter.UsageOptions{Include: true},
+		Temperature: temperature,
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	defer cancel()
+
+	start := time.Now()
+	response, err := c.Client.ChatCompletion(attemptCtx, oRequest)
+	latency := time.Since(start)
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if err == nil && !c.isKnownService(response.ServiceID, response.ServiceName) {
+		err = fmt.Errorf("model answered an unknown service (id=%d,name=%q)", response.ServiceID, response.ServiceName)
+	}
+
+	var cost float64
+	if response != nil {
+		cost = response.Usage.Cost
+	}
+	c.Selector.GetPerformanceMonitor().RecordRequest(modelName, latency, err == nil, cost)
+
+	if err != nil {
+		// a completion that could not be used was still billed
+		if response != nil {
+			c.recordUsage(response, 0)
+		}
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// isKnownService reports whether the id or the name returned by the model
+// matches the service registry
+func (c *Core) isKnownService(id uint8, name string) bool {
+	name = strings.TrimSpace(name)
+	for _, s := range c.PromptManager.GetServiceDefinitions() {
+		if uint8(s.ID) == id || strings.EqualFold(s.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Core) recordUsage(response *openrouter.DataResponse, serviceID uint8) {
+	u := response.Usage
+	c.Usage.Record(response.Model, int(serviceID), u.PromptTokens, u.CompletionTokens, u.Cost)
+}
+
 // analyzeCoherence performs lightweight checks to surface coherence issues
 // between the incoming request and the produced service data. This is useful
 // when consuming web/API outputs where format/content can drift.
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dyammarcano/crew-das-closures/internal/client/openrouter"
+	"github.com/dyammarcano/crew-das-closures/internal/prompt/models"
+)
+
+// fakeOpenRouter answers service 3 for every model, except those for which
+// failing (if any) reports true, which get a 500
+func fakeOpenRouter(t *testing.T, failing func(model string) bool) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openrouter.OpenRouterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		mu.Lock()
+		calls = append(calls, req.Model)
+		mu.Unlock()
+
+		if failing != nil && failing(req.Model) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		content := `{\"service_id\": 3, \"service_name\": \"Segunda via de Fatura\"}`
+		_, _ = fmt.Fprintf(w, `{"model": %q, "choices": [{"message": {"content": "%s"}}], "usage": {"prompt_tokens": 100, "completion_tokens": 10, "cost": 0.0002}}`, req.Model, content)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func TestAskQuestion_UsesSelectedModel(t *testing.T) {
+	srv, calls := fakeOpenRouter(t, nil)
+
+	c, err := NewCore(srv.URL, openrouter.WithAuth("test"))
+	if err != nil {
+		t.Fatalf("NewCore() error: %v", err)
+	}
+
+	res, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via da fatura"}`))
+	if err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+
+	if !res.Success || res.Data.ServiceID != 3 {
+		t.Errorf("response = %+v, want service 3", res.Data)
+	}
+
+	// A short intent is low complexity, so the cost-efficient primary model answers
+	if len(*calls) != 1 || (*calls)[0] != models.ModelMistral7B {
+		t.Errorf("models called = %v, want [%s]", *calls, models.ModelMistral7B)
+	}
+
+	metrics := c.Selector.GetPerformanceMonitor().GetMetrics(models.ModelMistral7B)
+	if metrics == nil || metrics.TotalRequests != 1 || metrics.FailedRequests != 0 {
+		t.Fatalf("primary metrics = %+v, want 1 successful request", metrics)
+	}
+
+	if metrics.CostPerRequest != 0.0002 {
+		t.Errorf("CostPerRequest = %v, want 0.0002", metrics.CostPerRequest)
+	}
+}
+
+func TestAskQuestion_RetriesWithFallbackModel(t *testing.T) {
+	srv, calls := fakeOpenRouter(t, func(model string) bool { return model == models.ModelMistral7B })
+
+	c, err := NewCore(srv.URL, openrouter.WithAuth("test"))
+	if err != nil {
+		t.Fatalf("NewCore() error: %v", err)
+	}
+
+	res, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via da fatura"}`))
+	if err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+
+	if !res.Success || res.Data.ServiceID != 3 {
+		t.Errorf("response = %+v, want service 3", res.Data)
+	}
+
+	want := []string{models.ModelMistral7B, models.ModelGPT4OMini}
+	if fmt.Sprint(*calls) != fmt.Sprint(want) {
+		t.Errorf("models called = %v, want %v", *calls, want)
+	}
+
+	monitor := c.Selector.GetPerformanceMonitor()
+	if m := monitor.GetMetrics(models.ModelMistral7B); m == nil || m.FailedRequests != 1 {
+		t.Errorf("primary metrics = %+v, want 1 failed request", m)
+	}
+	if m := monitor.GetMetrics(models.ModelGPT4OMini); m == nil || m.TotalRequests != 1 || m.FailedRequests != 0 {
+		t.Errorf("fallback metrics = %+v, want 1 successful request", m)
+	}
+}
+
+func TestAskQuestion_PrimaryModelRecovers(t *testing.T) {
+	const window = 100 * time.Millisecond
+
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	srv, calls := fakeOpenRouter(t, func(model string) bool {
+		return model == models.ModelMistral7B && primaryDown.Load()
+	})
+
+	c, err := NewCore(srv.URL, openrouter.WithAuth("test"))
+	if err != nil {
+		t.Fatalf("NewCore() error: %v", err)
+	}
+	c.SetModelSelector(models.NewModelSelectorWithConfig(models.ModelConfig{EnableMonitoring: true, HealthWindow: window}))
+
+	if _, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via da fatura"}`)); err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+
+	// While the failure is recent, requests go to the fallback model directly
+	if got := c.Selector.SelectModel("segunda via da fatura").ModelName; got != models.ModelGPT4OMini {
+		t.Errorf("SelectModel() right after the failure = %s, want %s", got, models.ModelGPT4OMini)
+	}
+
+	primaryDown.Store(false)
+	time.Sleep(window + 20*time.Millisecond)
+
+	// Once the failure leaves the health window, the primary model is tried again
+	*calls = nil
+	if _, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via da fatura"}`)); err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+	if len(*calls) != 1 || (*calls)[0] != models.ModelMistral7B {
+		t.Errorf("models called after recovery = %v, want [%s]", *calls, models.ModelMistral7B)
+	}
+
+	if m := c.Selector.GetPerformanceMonitor().GetMetrics(models.ModelMistral7B); m == nil || m.SuccessRate != 1 || m.FailedRequests != 1 {
+		t.Errorf("primary metrics = %+v, want a recent success rate of 1 and 1 failure in total", m)
+	}
+}
+
+func TestAskQuestion_Concurrent(t *testing.T) {
+	srv, _ := fakeOpenRouter(t, nil)
+
+	c, err := NewCore(srv.URL, openrouter.WithAuth("test"))
+	if err != nil {
+		t.Fatalf("NewCore() error: %v", err)
+	}
+
+	// concurrent handlers record into the same PerformanceMonitor
+	const requests = 20
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via da fatura"}`)); err != nil {
+				t.Errorf("AskQuestion() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	metrics := c.Selector.GetPerformanceMonitor().GetMetrics(models.ModelMistral7B)
+	if metrics == nil || metrics.TotalRequests != requests {
+		t.Errorf("primary metrics = %+v, want %d requests", metrics, requests)
+	}
+}
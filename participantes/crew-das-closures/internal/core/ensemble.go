@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dyammarcano/crew-das-closures/internal/client/openrouter"
+	"github.com/dyammarcano/crew-das-closures/internal/prompt/models"
+)
+
+// Strategy chooses how many models classify each intent
+type Strategy string
+
+const (
+	// StrategySingle asks the model picked by the selector, then its fallback
+	StrategySingle Strategy = "single"
+	// StrategyRace asks every candidate and keeps the first valid answer
+	StrategyRace Strategy = "race"
+	// StrategyVote asks every candidate and keeps the service answered by a
+	// quorum of them, or by most of them when no service reaches the quorum
+	StrategyVote Strategy = "vote"
+)
+
+// EnsembleConfig configures the race and vote strategies
+type EnsembleConfig struct {
+	Strategy Strategy
+	// Models are asked in parallel; empty uses the selector's primary and
+	// fallback models
+	Models []string
+	// Samples is how many times each model is asked, 1 when zero
+	Samples int
+	// Quorum is how many answers must agree to stop a vote early, a majority
+	// of the candidates when zero
+	Quorum int
+	// Temperature is sent with every candidate so repeated samples of a model
+	// can differ; nil keeps the provider default
+	Temperature *float64
+}
+
+// SetEnsemble validates and replaces the ensemble configuration
+func (c *Core) SetEnsemble(cfg EnsembleConfig) error {
+	switch cfg.Strategy {
+	case "", StrategySingle, StrategyRace, StrategyVote:
+	default:
+		return fmt.Errorf("unknown ensemble strategy %q", cfg.Strategy)
+	}
+
+	if cfg.Samples < 0 || cfg.Quorum < 0 {
+		return fmt.Errorf("ensemble samples and quorum must not be negative")
+	}
+
+	if n := len(cfg.Models) * max(cfg.Samples, 1); len(cfg.Models) > 0 && cfg.Quorum > n {
+		return fmt.Errorf("ensemble quorum %d exceeds the %d candidates", cfg.Quorum, n)
+	}
+
+	c.Ensemble = cfg
+	return nil
+}
+
+// candidates lists the model of each parallel call
+func (c *Core) candidates() []string {
+	names := c.Ensemble.Models
+	if len(names) == 0 {
+		names = []string{c.Selector.PrimaryModel(), c.Selector.FallbackModel()}
+	}
+
+	samples := max(c.Ensemble.Samples, 1)
+	out := make([]string, 0, len(names)*samples)
+	for _, name := range names {
+		for i := 0; i < samples; i++ {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// quorum is the configured quorum, or a majority of n
+func (c *Core) quorum(n int) int {
+	if c.Ensemble.Quorum > 0 && c.Ensemble.Quorum <= n {
+		return c.Ensemble.Quorum
+	}
+	return n/2 + 1
+}
+
+// answer is the result of one candidate call
+type answer struct {
+	index    int
+	response *openrouter.DataResponse
+	// serviceID is the normalized service of the response
+	serviceID uint8
+	err       error
+}
+
+// fanOut asks every candidate in parallel. The channel receives exactly one
+// answer per candidate; cancelling ctx aborts the calls still running.
+func (c *Core) fanOut(ctx context.Context, intent string, candidates []string) <-chan answer {
+	answers := make(chan answer, len(candidates))
+
+	for i, name := range candidates {
+		go func(i int, name string) {
+			response, err := c.complete(ctx, intent, name, c.Ensemble.Temperature)
+			a := answer{index: i, response: response, err: err}
+			if err == nil {
+				a.serviceID, _, _ = c.normalizeServicePair(response.ServiceID, response.ServiceName)
+			}
+			answers <- a
+		}(i, name)
+	}
+
+	return answers
+}
+
+// race returns the first valid answer and cancels the other candidates
+func (c *Core) race(ctx context.Context, intent string) (*openrouter.DataResponse, error) {
+	candidates := c.candidates()
+	outcomes := newOutcomes(candidates)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var winner *answer
+	var errs []error
+	answers := c.fanOut(ctx, intent, candidates)
+	for range candidates {
+		a := <-answers
+
+		switch {
+		case a.err == nil:
+			outcomes[a.index].ServiceID = int(a.serviceID)
+			if winner == nil {
+				winner = &a
+				outcomes[a.index].Won = true
+				cancel()
+				continue
+			}
+			// a loser that answered before being cancelled was still billed
+			c.recordUsage(a.response, a.serviceID)
+		case winner != nil && errors.Is(a.err, context.Canceled):
+			outcomes[a.index].Cancelled = true
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", candidates[a.index], a.err))
+		}
+	}
+
+	if winner == nil {
+		c.Agreement.Record(outcomes, 0, false)
+		return nil, fmt.Errorf("no model answered: %w", errors.Join(errs...))
+	}
+
+	c.Agreement.Record(outcomes, int(winner.serviceID), true)
+	return winner.response, nil
+}
+
+// vote returns the service answered by a quorum of the candidates, cancelling
+// the calls still running once it is reached. Without a quorum the service
+// with most answers wins, ties going to the one answered first.
+func (c *Core) vote(ctx context.Context, intent string) (*openrouter.DataResponse, error) {
+	candidates := c.candidates()
+	outcomes := newOutcomes(candidates)
+	quorum := c.quorum(len(candidates))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var valid []answer
+	var errs []error
+	votes := make(map[uint8]int)
+	var decided uint8
+
+	answers := c.fanOut(ctx, intent, candidates)
+	for range candidates {
+		a := <-answers
+
+		switch {
+		case a.err == nil:
+			outcomes[a.index].ServiceID = int(a.serviceID)
+			valid = append(valid, a)
+			votes[a.serviceID]++
+			if decided == 0 && votes[a.serviceID] >= quorum {
+				decided = a.serviceID
+				cancel()
+			}
+		case decided != 0 && errors.Is(a.err, context.Canceled):
+			outcomes[a.index].Cancelled = true
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", candidates[a.index], a.err))
+		}
+	}
+
+	if len(valid) == 0 {
+		c.Agreement.Record(outcomes, 0, false)
+		return nil, fmt.Errorf("no model answered: %w", errors.Join(errs...))
+	}
+
+	reached := decided != 0
+	if !reached {
+		// valid is in arrival order, so the first service to reach the highest
+		// count is the earliest answered among the tied ones
+		for _, a := range valid {
+			if votes[a.serviceID] > votes[decided] {
+				decided = a.serviceID
+			}
+		}
+	}
+
+	var winner *openrouter.DataResponse
+	for _, a := range valid {
+		if winner == nil && a.serviceID == decided {
+			winner = a.response
+			continue
+		}
+		// AskQuestion records the usage of the returned response only
+		c.recordUsage(a.response, a.serviceID)
+	}
+
+	c.Agreement.Record(outcomes, int(decided), reached)
+	return winner, nil
+}
+
+func newOutcomes(candidates []string) []models.ModelOutcome {
+	outcomes := make([]models.ModelOutcome, len(candidates))
+	for i, name := range candidates {
+		outcomes[i].Model = name
+	}
+	return outcomes
+}
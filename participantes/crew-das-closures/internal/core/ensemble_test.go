@@ -0,0 +1,305 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dyammarcano/crew-das-closures/internal/client/openrouter"
+	"github.com/dyammarcano/crew-das-closures/internal/prompt/models"
+)
+
+const (
+	llama4Scout = "meta-llama/llama-4-scout"
+	gpt4O       = "openai/gpt-4o"
+)
+
+// scriptedReply is how the ensemble fake answers one model
+type scriptedReply struct {
+	serviceID uint8
+	delay     time.Duration
+}
+
+var serviceNames = map[uint8]string{
+	2: "Segunda via de boleto de acordo",
+	3: "Segunda via de Fatura",
+	7: "Cancelamento de cartão",
+}
+
+// ensembleOpenRouter answers each model as scripted and reports the models
+// whose request was cancelled by the client
+func ensembleOpenRouter(t *testing.T, replies map[string]scriptedReply) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var cancelled []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openrouter.OpenRouterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		reply, ok := replies[req.Model]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			mu.Lock()
+			cancelled = append(cancelled, req.Model)
+			mu.Unlock()
+			return
+		case <-time.After(reply.delay):
+		}
+
+		content := fmt.Sprintf(`{\"service_id\": %d, \"service_name\": \"%s\"}`, reply.serviceID, serviceNames[reply.serviceID])
+		_, _ = fmt.Fprintf(w, `{"model": %q, "choices": [{"message": {"content": "%s"}}], "usage": {"prompt_tokens": 100, "completion_tokens": 10, "cost": 0.0002}}`, req.Model, content)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		// the server notices a cancelled request asynchronously
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), cancelled...)
+	}
+}
+
+func newEnsembleCore(t *testing.T, url string, cfg EnsembleConfig) *Core {
+	t.Helper()
+
+	c, err := NewCore(url, openrouter.WithAuth("test"))
+	if err != nil {
+		t.Fatalf("NewCore() error: %v", err)
+	}
+	if err := c.SetEnsemble(cfg); err != nil {
+		t.Fatalf("SetEnsemble() error: %v", err)
+	}
+	return c
+}
+
+func TestAskQuestion_RaceKeepsFirstValidAnswer(t *testing.T) {
+	srv, cancelled := ensembleOpenRouter(t, map[string]scriptedReply{
+		models.ModelMistral7B: {serviceID: 3, delay: 10 * time.Millisecond},
+		models.ModelGPT4OMini: {serviceID: 2, delay: 2 * time.Second},
+		llama4Scout:           {serviceID: 2, delay: 2 * time.Second},
+	})
+
+	c := newEnsembleCore(t, srv.URL, EnsembleConfig{
+		Strategy: StrategyRace,
+		Models:   []string{models.ModelMistral7B, models.ModelGPT4OMini, llama4Scout},
+	})
+
+	start := time.Now()
+	res, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via da fatura"}`))
+	if err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+
+	if res.Data.ServiceID != 3 {
+		t.Errorf("response = %+v, want the fastest answer, service 3", res.Data)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("race took %v, want the losers cancelled", elapsed)
+	}
+	if got := cancelled(); len(got) != 2 {
+		t.Errorf("cancelled = %v, want the 2 slower models", got)
+	}
+
+	report := c.Agreement.Report()
+	winner := report.ByModel[models.ModelMistral7B]
+	if report.Decisions != 1 || winner.Wins != 1 || winner.Agreed != 1 {
+		t.Errorf("agreement = %+v, want 1 decision won by %s", report, models.ModelMistral7B)
+	}
+	if loser := report.ByModel[models.ModelGPT4OMini]; loser.Cancelled != 1 || loser.Answers != 0 {
+		t.Errorf("%s stats = %+v, want 1 cancelled call", models.ModelGPT4OMini, loser)
+	}
+
+	// cancelled calls say nothing about the model, so they are not recorded
+	if metrics := c.Selector.GetPerformanceMonitor().GetMetrics(models.ModelGPT4OMini); metrics != nil && metrics.TotalRequests != 0 {
+		t.Errorf("%s metrics = %+v, want no request recorded", models.ModelGPT4OMini, metrics)
+	}
+}
+
+func TestAskQuestion_RaceSkipsFailedModels(t *testing.T) {
+	srv, _ := ensembleOpenRouter(t, map[string]scriptedReply{
+		models.ModelGPT4OMini: {serviceID: 7, delay: 50 * time.Millisecond},
+	})
+
+	c := newEnsembleCore(t, srv.URL, EnsembleConfig{
+		Strategy: StrategyRace,
+		Models:   []string{models.ModelMistral7B, models.ModelGPT4OMini},
+	})
+
+	res, err := c.AskQuestion(context.Background(), []byte(`{"intent": "cancelar cartão"}`))
+	if err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+
+	if res.Data.ServiceID != 7 {
+		t.Errorf("response = %+v, want service 7", res.Data)
+	}
+	if failures := c.Agreement.Report().ByModel[models.ModelMistral7B].Failures; failures != 1 {
+		t.Errorf("%s failures = %d, want 1", models.ModelMistral7B, failures)
+	}
+}
+
+func TestAskQuestion_VoteStopsAtQuorum(t *testing.T) {
+	srv, cancelled := ensembleOpenRouter(t, map[string]scriptedReply{
+		models.ModelMistral7B: {serviceID: 2, delay: 10 * time.Millisecond},
+		models.ModelGPT4OMini: {serviceID: 3, delay: 20 * time.Millisecond},
+		llama4Scout:           {serviceID: 3, delay: 30 * time.Millisecond},
+		gpt4O:                 {serviceID: 3, delay: 2 * time.Second},
+	})
+
+	c := newEnsembleCore(t, srv.URL, EnsembleConfig{
+		Strategy: StrategyVote,
+		Models:   []string{models.ModelMistral7B, models.ModelGPT4OMini, llama4Scout, gpt4O},
+		Quorum:   2,
+	})
+
+	res, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via"}`))
+	if err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+
+	if res.Data.ServiceID != 3 {
+		t.Errorf("response = %+v, want the quorum service 3", res.Data)
+	}
+	if got := cancelled(); len(got) != 1 || got[0] != gpt4O {
+		t.Errorf("cancelled = %v, want [%s]", got, gpt4O)
+	}
+
+	report := c.Agreement.Report()
+	if report.Decisions != 1 || report.NoQuorum != 0 {
+		t.Errorf("agreement = %+v, want 1 decision with quorum", report)
+	}
+	if dissent := report.ByModel[models.ModelMistral7B]; dissent.Answers != 1 || dissent.Agreed != 0 {
+		t.Errorf("%s stats = %+v, want 1 answer that disagreed", models.ModelMistral7B, dissent)
+	}
+
+	// every answered completion was billed, not only the returned one
+	if usage := c.Usage.Report(); usage.Totals.Requests != 3 {
+		t.Errorf("usage requests = %d, want 3", usage.Totals.Requests)
+	}
+}
+
+func TestAskQuestion_VoteFallsBackToPlurality(t *testing.T) {
+	srv, _ := ensembleOpenRouter(t, map[string]scriptedReply{
+		models.ModelMistral7B: {serviceID: 2, delay: 10 * time.Millisecond},
+		models.ModelGPT4OMini: {serviceID: 3, delay: 20 * time.Millisecond},
+	})
+
+	// each model is sampled twice, so 4 candidates need a quorum of 3
+	c := newEnsembleCore(t, srv.URL, EnsembleConfig{
+		Strategy: StrategyVote,
+		Models:   []string{models.ModelMistral7B, models.ModelGPT4OMini},
+		Samples:  2,
+	})
+
+	res, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via"}`))
+	if err != nil {
+		t.Fatalf("AskQuestion() error: %v", err)
+	}
+
+	// a 2-2 tie goes to the service answered first
+	if res.Data.ServiceID != 2 {
+		t.Errorf("response = %+v, want service 2", res.Data)
+	}
+
+	report := c.Agreement.Report()
+	if report.Decisions != 1 || report.NoQuorum != 1 {
+		t.Errorf("agreement = %+v, want 1 decision without quorum", report)
+	}
+	if stats := report.ByModel[models.ModelGPT4OMini]; stats.Answers != 2 || stats.Agreed != 0 {
+		t.Errorf("%s stats = %+v, want 2 answers that disagreed", models.ModelGPT4OMini, stats)
+	}
+}
+
+func TestAskQuestion_VoteWithoutAnswers(t *testing.T) {
+	srv, _ := ensembleOpenRouter(t, nil)
+
+	c := newEnsembleCore(t, srv.URL, EnsembleConfig{Strategy: StrategyVote})
+
+	if _, err := c.AskQuestion(context.Background(), []byte(`{"intent": "segunda via"}`)); err == nil {
+		t.Fatal("AskQuestion() error = nil, want every model failed")
+	}
+
+	report := c.Agreement.Report()
+	if report.Decisions != 0 || report.ByModel[models.ModelMistral7B].Failures != 1 || report.ByModel[models.ModelGPT4OMini].Failures != 1 {
+		t.Errorf("agreement = %+v, want a failure for the primary and fallback models", report)
+	}
+}
+
+func TestSetEnsemble(t *testing.T) {
+	c, err := NewCore("http://127.0.0.1:0", openrouter.WithAuth("test"))
+	if err != nil {
+		t.Fatalf("NewCore() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     EnsembleConfig
+		wantErr bool
+	}{
+		{name: "default", cfg: EnsembleConfig{}},
+		{name: "vote", cfg: EnsembleConfig{Strategy: StrategyVote, Models: []string{"a", "b"}, Samples: 2, Quorum: 3}},
+		{name: "unknown strategy", cfg: EnsembleConfig{Strategy: "best"}, wantErr: true},
+		{name: "quorum above candidates", cfg: EnsembleConfig{Strategy: StrategyVote, Models: []string{"a", "b"}, Quorum: 3}, wantErr: true},
+		{name: "negative samples", cfg: EnsembleConfig{Strategy: StrategyRace, Samples: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.SetEnsemble(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("SetEnsemble() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAskQuestion_ContextCancelsModelCalls(t *testing.T) {
+	for _, strategy := range []Strategy{StrategySingle, StrategyRace, StrategyVote} {
+		t.Run(string(strategy), func(t *testing.T) {
+			srv, cancelled := ensembleOpenRouter(t, map[string]scriptedReply{
+				models.ModelMistral7B: {serviceID: 3, delay: 2 * time.Second},
+				models.ModelGPT4OMini: {serviceID: 3, delay: 2 * time.Second},
+			})
+			c := newEnsembleCore(t, srv.URL, EnsembleConfig{Strategy: strategy})
+
+			// the client disconnects while the models are still thinking
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			if _, err := c.AskQuestion(ctx, []byte(`{"intent": "segunda via da fatura"}`)); err == nil {
+				t.Fatal("AskQuestion() error = nil, want the cancellation")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("AskQuestion() took %v, want the model calls aborted", elapsed)
+			}
+
+			// single does not retry with the fallback model after the cancellation
+			want := len(c.candidates())
+			if strategy == StrategySingle {
+				want = 1
+			}
+			if got := cancelled(); len(got) != want {
+				t.Errorf("cancelled = %v, want %d calls", got, want)
+			}
+
+			if metrics := c.Selector.GetPerformanceMonitor().GetAllMetrics(); len(metrics) != 0 {
+				t.Errorf("metrics = %v, want no request recorded", metrics)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// ModelOutcome is the result of one model call in a multi-model decision
+type ModelOutcome struct {
+	Model string
+	// ServiceID is the normalized service the model answered, 0 when it failed
+	ServiceID int
+	// Cancelled is set when the call was cancelled once the decision was made
+	Cancelled bool
+	// Won is set for the answer that decided a race
+	Won bool
+}
+
+// AgreementStats counts how the answers of a model compare with the decisions
+type AgreementStats struct {
+	Answers   int64 `json:"answers"`
+	Agreed    int64 `json:"agreed"`
+	Wins      int64 `json:"wins"`
+	Failures  int64 `json:"failures"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+// AgreementRate is the share of the answers that matched the decision
+func (s AgreementStats) AgreementRate() float64 {
+	if s.Answers == 0 {
+		return 0
+	}
+	return float64(s.Agreed) / float64(s.Answers)
+}
+
+// AgreementReport is a snapshot of the multi-model decisions
+type AgreementReport struct {
+	Since     time.Time                 `json:"since"`
+	Decisions int64                     `json:"decisions"`
+	NoQuorum  int64                     `json:"no_quorum"`
+	ByModel   map[string]AgreementStats `json:"by_model"`
+}
+
+// AgreementTracker records how often each model agrees with the racing and
+// voting decisions. It is safe for concurrent use.
+type AgreementTracker struct {
+	mu        sync.Mutex
+	since     time.Time
+	decisions int64
+	noQuorum  int64
+	byModel   map[string]*AgreementStats
+}
+
+// NewAgreementTracker creates an empty agreement tracker
+func NewAgreementTracker() *AgreementTracker {
+	return &AgreementTracker{
+		since:   time.Now(),
+		byModel: make(map[string]*AgreementStats),
+	}
+}
+
+// Record adds one decision. decided is the chosen service ID, or 0 when no
+// model answered; quorum is false when a vote fell back to the plurality.
+func (at *AgreementTracker) Record(outcomes []ModelOutcome, decided int, quorum bool) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	if decided > 0 {
+		at.decisions++
+		if !quorum {
+			at.noQuorum++
+		}
+	}
+
+	for _, o := range outcomes {
+		stats := at.byModel[o.Model]
+		if stats == nil {
+			stats = &AgreementStats{}
+			at.byModel[o.Model] = stats
+		}
+
+		switch {
+		case o.Cancelled:
+			stats.Cancelled++
+		case o.ServiceID == 0:
+			stats.Failures++
+		default:
+			stats.Answers++
+			if o.ServiceID == decided {
+				stats.Agreed++
+			}
+		}
+
+		if o.Won {
+			stats.Wins++
+		}
+	}
+}
+
+// Report returns a copy of the recorded decisions
+func (at *AgreementTracker) Report() AgreementReport {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	report := AgreementReport{
+		Since:     at.since,
+		Decisions: at.decisions,
+		NoQuorum:  at.noQuorum,
+		ByModel:   make(map[string]AgreementStats, len(at.byModel)),
+	}
+
+	for name, stats := range at.byModel {
+		report.ByModel[name] = *stats
+	}
+
+	return report
+}
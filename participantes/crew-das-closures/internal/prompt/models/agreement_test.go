@@ -0,0 +1,66 @@
+package models
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAgreementTracker_Record(t *testing.T) {
+	tracker := NewAgreementTracker()
+
+	tracker.Record([]ModelOutcome{
+		{Model: ModelGPT4OMini, ServiceID: 3, Won: true},
+		{Model: ModelMistral7B, Cancelled: true},
+	}, 3, true)
+
+	tracker.Record([]ModelOutcome{
+		{Model: ModelGPT4OMini, ServiceID: 2},
+		{Model: ModelMistral7B, ServiceID: 3},
+		{Model: ModelMistral7B, ServiceID: 0},
+	}, 2, false)
+
+	report := tracker.Report()
+
+	if report.Decisions != 2 || report.NoQuorum != 1 {
+		t.Errorf("Expected 2 decisions, 1 without quorum, got %+v", report)
+	}
+
+	gpt := report.ByModel[ModelGPT4OMini]
+	if gpt.Answers != 2 || gpt.Agreed != 2 || gpt.Wins != 1 || gpt.AgreementRate() != 1 {
+		t.Errorf("Unexpected %s stats: %+v", ModelGPT4OMini, gpt)
+	}
+
+	mistral := report.ByModel[ModelMistral7B]
+	if mistral.Answers != 1 || mistral.Agreed != 0 || mistral.Failures != 1 || mistral.Cancelled != 1 {
+		t.Errorf("Unexpected %s stats: %+v", ModelMistral7B, mistral)
+	}
+}
+
+func TestAgreementTracker_NoAnswer(t *testing.T) {
+	tracker := NewAgreementTracker()
+	tracker.Record([]ModelOutcome{{Model: ModelGPT4OMini}}, 0, false)
+
+	report := tracker.Report()
+	if report.Decisions != 0 || report.ByModel[ModelGPT4OMini].Failures != 1 {
+		t.Errorf("Expected only a failure, got %+v", report)
+	}
+}
+
+func TestAgreementTracker_Concurrent(t *testing.T) {
+	tracker := NewAgreementTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.Record([]ModelOutcome{{Model: ModelGPT4OMini, ServiceID: 1}}, 1, true)
+			_ = tracker.Report()
+		}()
+	}
+	wg.Wait()
+
+	if got := tracker.Report().ByModel[ModelGPT4OMini].Agreed; got != 50 {
+		t.Errorf("Expected 50 agreed answers, got %d", got)
+	}
+}
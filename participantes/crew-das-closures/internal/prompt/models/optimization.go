@@ -2,6 +2,7 @@ package models
 
 import (
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 )
@@ -12,6 +13,7 @@ type ModelSelector struct {
 	fallbackModel  string
 	costThreshold  float64
 	performanceLog *PerformanceMonitor
+	usage          *UsageTracker
 }
 
 // ModelConfig holds configuration for model selection
@@ -20,6 +22,9 @@ type ModelConfig struct {
 	FallbackModel    string  `json:"fallback_model"`
 	CostThreshold    float64 `json:"cost_threshold"`
 	EnableMonitoring bool    `json:"enable_monitoring"`
+	// HealthWindow is how far back the success rate and latency of a model
+	// look; DefaultHealthWindow when zero
+	HealthWindow time.Duration `json:"health_window"`
 }
 
 // RequestComplexity represents the complexity analysis of a user request
@@ -41,7 +46,10 @@ type ModelRecommendation struct {
 	Priority      string            `json:"priority"` // "cost", "accuracy", "speed"
 }
 
-// PerformanceMetrics holds performance data for a model
+// PerformanceMetrics holds performance data for a model. AverageLatency and
+// SuccessRate cover the requests of the monitor's health window only, so a
+// model that stopped being selected after a bad spell is trusted again once
+// those failures age out; with no recent request they are 0 and 1.
 type PerformanceMetrics struct {
 	ModelName      string        `json:"model_name"`
 	AverageLatency time.Duration `json:"average_latency"`
@@ -52,10 +60,49 @@ type PerformanceMetrics struct {
 	CostPerRequest float64       `json:"cost_per_request"`
 }
 
-// PerformanceMonitor tracks performance metrics for different models
+// PerformanceMonitor tracks performance metrics for different models.
+// It is safe for concurrent use.
 type PerformanceMonitor struct {
-	metrics map[string]*PerformanceMetrics
-	enabled bool
+	mu         sync.RWMutex
+	metrics    map[string]*PerformanceMetrics
+	histograms map[string]*LatencyHistogram
+	recent     map[string][]requestOutcome
+	window     time.Duration
+	enabled    bool
+	now        func() time.Time
+}
+
+// requestOutcome is a request kept in the health window
+type requestOutcome struct {
+	at      time.Time
+	latency time.Duration
+	success bool
+}
+
+// maxRecentOutcomes bounds the requests kept per model in the health window
+const maxRecentOutcomes = 200
+
+// LatencyBuckets are the upper bounds, in seconds, of the latency histograms
+var LatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// LatencyHistogram counts request latencies in the cumulative layout used by
+// Prometheus: Buckets[i] is the number of requests that took at most
+// LatencyBuckets[i] seconds.
+type LatencyHistogram struct {
+	Buckets []uint64 `json:"buckets"`
+	Count   uint64   `json:"count"`
+	Sum     float64  `json:"sum"` // seconds
+}
+
+func (h *LatencyHistogram) observe(latency time.Duration) {
+	seconds := latency.Seconds()
+	for i, bound := range LatencyBuckets {
+		if seconds <= bound {
+			h.Buckets[i]++
+		}
+	}
+	h.Count++
+	h.Sum += seconds
 }
 
 // Model constants
@@ -75,6 +122,10 @@ const (
 	// Performance thresholds
 	MaxAcceptableLatency     = 5 * time.Second
 	MinAcceptableSuccessRate = 0.85
+
+	// DefaultHealthWindow is how long a failure or a slow request keeps
+	// counting against a model
+	DefaultHealthWindow = 2 * time.Minute
 )
 
 // NewModelSelector creates a new model selector with default configuration
@@ -107,6 +158,9 @@ func NewModelSelectorWithConfig(config ModelConfig) *ModelSelector {
 	var monitor *PerformanceMonitor
 	if config.EnableMonitoring {
 		monitor = NewPerformanceMonitor()
+		if config.HealthWindow > 0 {
+			monitor.window = config.HealthWindow
+		}
 	}
 
 	return &ModelSelector{
@@ -216,8 +270,8 @@ func (ms *ModelSelector) makeModelDecision(complexity RequestComplexity, primary
 	estimatedTokens := float64(complexity.WordCount) * 1.3
 
 	// Calculate estimated costs
-	primaryCost := estimatedTokens * MistralCostPerToken
-	fallbackCost := estimatedTokens * GPTCostPerToken
+	primaryCost := ms.estimateCost(ms.primaryModel, estimatedTokens, MistralCostPerToken)
+	fallbackCost := ms.estimateCost(ms.fallbackModel, estimatedTokens, GPTCostPerToken)
 
 	// Decision matrix based on complexity
 	switch {
@@ -342,31 +396,58 @@ func (ms *ModelSelector) OptimizeForAccuracy(userIntent string) ModelRecommendat
 	}
 }
 
-// GetModelCostEstimate returns the estimated cost for a request with a specific model
+// GetModelCostEstimate returns the estimated cost for a request with a specific model.
+// The observed average cost is used once the usage tracker has recorded the model.
 func (ms *ModelSelector) GetModelCostEstimate(userIntent, modelName string) float64 {
 	complexity := ms.AnalyzeComplexity(userIntent)
 	estimatedTokens := float64(complexity.WordCount) * 1.3
 
 	switch modelName {
-	case ModelMistral7B:
-		return estimatedTokens * MistralCostPerToken
 	case ModelGPT4OMini:
-		return estimatedTokens * GPTCostPerToken
+		return ms.estimateCost(modelName, estimatedTokens, GPTCostPerToken)
 	default:
-		return estimatedTokens * MistralCostPerToken // Default to primary model cost
+		return ms.estimateCost(modelName, estimatedTokens, MistralCostPerToken) // Default to primary model cost
 	}
 }
 
+// estimateCost returns the observed average cost of the model, falling back
+// to the token estimate when no usage was recorded
+func (ms *ModelSelector) estimateCost(modelName string, estimatedTokens, costPerToken float64) float64 {
+	if ms.usage != nil {
+		if cost, ok := ms.usage.AverageCost(modelName); ok {
+			return cost
+		}
+	}
+
+	return estimatedTokens * costPerToken
+}
+
+// SetUsageTracker makes cost estimates use the usage reported by OpenRouter
+func (ms *ModelSelector) SetUsageTracker(tracker *UsageTracker) {
+	ms.usage = tracker
+}
+
 // NewPerformanceMonitor creates a new performance monitor
 func NewPerformanceMonitor() *PerformanceMonitor {
 	return &PerformanceMonitor{
-		metrics: make(map[string]*PerformanceMetrics),
-		enabled: true,
+		metrics:    make(map[string]*PerformanceMetrics),
+		histograms: make(map[string]*LatencyHistogram),
+		recent:     make(map[string][]requestOutcome),
+		window:     DefaultHealthWindow,
+		enabled:    true,
+		now:        time.Now,
 	}
 }
 
 // RecordRequest records a request and its performance metrics
 func (pm *PerformanceMonitor) RecordRequest(modelName string, latency time.Duration, success bool, cost float64) {
+	if pm == nil {
+		return
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
 	if !pm.enabled {
 		return
 	}
@@ -379,27 +460,25 @@ func (pm *PerformanceMonitor) RecordRequest(modelName string, latency time.Durat
 			SuccessRate:    1.0,
 			CostPerRequest: cost,
 		}
+		pm.histograms[modelName] = &LatencyHistogram{Buckets: make([]uint64, len(LatencyBuckets))}
 	}
 
+	pm.histograms[modelName].observe(latency)
+
+	now := pm.now()
 	metrics := pm.metrics[modelName]
 	metrics.TotalRequests++
-	metrics.LastUsed = time.Now()
+	metrics.LastUsed = now
 
 	if !success {
 		metrics.FailedRequests++
 	}
 
-	// Update success rate
-	metrics.SuccessRate = float64(metrics.TotalRequests-metrics.FailedRequests) / float64(metrics.TotalRequests)
-
-	// Update average latency (exponential moving average)
-	if metrics.AverageLatency == 0 {
-		metrics.AverageLatency = latency
-	} else {
-		// Use exponential moving average with alpha = 0.1
-		alpha := 0.1
-		metrics.AverageLatency = time.Duration(float64(metrics.AverageLatency)*(1-alpha) + float64(latency)*alpha)
+	recent := append(pm.recentSince(modelName, now), requestOutcome{at: now, latency: latency, success: success})
+	if len(recent) > maxRecentOutcomes {
+		recent = recent[len(recent)-maxRecentOutcomes:]
 	}
+	pm.recent[modelName] = recent
 
 	// Update average cost per request
 	if metrics.CostPerRequest == 0 {
@@ -410,54 +489,160 @@ func (pm *PerformanceMonitor) RecordRequest(modelName string, latency time.Durat
 	}
 }
 
-// GetMetrics returns performance metrics for a specific model
+// recentSince returns the requests of the model still inside the health
+// window at now. The caller must hold pm.mu.
+func (pm *PerformanceMonitor) recentSince(modelName string, now time.Time) []requestOutcome {
+	recent := pm.recent[modelName]
+	for len(recent) > 0 && now.Sub(recent[0].at) > pm.window {
+		recent = recent[1:]
+	}
+	return recent
+}
+
+// snapshot copies the metrics of the model with the success rate and latency
+// of its health window. The caller must hold pm.mu.
+func (pm *PerformanceMonitor) snapshot(modelName string, now time.Time) *PerformanceMetrics {
+	copied := *pm.metrics[modelName]
+	copied.SuccessRate = 1.0
+	copied.AverageLatency = 0
+
+	recent := pm.recentSince(modelName, now)
+	if len(recent) == 0 {
+		return &copied
+	}
+
+	var succeeded int
+	var latency time.Duration
+	for _, outcome := range recent {
+		if outcome.success {
+			succeeded++
+		}
+		latency += outcome.latency
+	}
+	copied.SuccessRate = float64(succeeded) / float64(len(recent))
+	copied.AverageLatency = latency / time.Duration(len(recent))
+
+	return &copied
+}
+
+// GetMetrics returns a copy of the performance metrics for a specific model.
+// A nil monitor, as created by NewModelSelectorWithConfig without monitoring,
+// has no metrics.
 func (pm *PerformanceMonitor) GetMetrics(modelName string) *PerformanceMetrics {
-	if !pm.enabled {
+	if pm == nil {
 		return nil
 	}
 
-	return pm.metrics[modelName]
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if !pm.enabled || pm.metrics[modelName] == nil {
+		return nil
+	}
+
+	return pm.snapshot(modelName, pm.now())
 }
 
-// GetAllMetrics returns performance metrics for all models
+// GetAllMetrics returns a copy of the performance metrics for all models
 func (pm *PerformanceMonitor) GetAllMetrics() map[string]*PerformanceMetrics {
+	if pm == nil {
+		return nil
+	}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	if !pm.enabled {
 		return nil
 	}
 
 	// Return a copy to prevent external modification
-	result := make(map[string]*PerformanceMetrics)
-	for k, v := range pm.metrics {
-		result[k] = v
+	now := pm.now()
+	result := make(map[string]*PerformanceMetrics, len(pm.metrics))
+	for k := range pm.metrics {
+		result[k] = pm.snapshot(k, now)
+	}
+	return result
+}
+
+// GetLatencyHistograms returns a copy of the latency histogram of each model
+func (pm *PerformanceMonitor) GetLatencyHistograms() map[string]LatencyHistogram {
+	if pm == nil {
+		return nil
+	}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if !pm.enabled {
+		return nil
+	}
+
+	result := make(map[string]LatencyHistogram, len(pm.histograms))
+	for k, v := range pm.histograms {
+		copied := *v
+		copied.Buckets = append([]uint64(nil), v.Buckets...)
+		result[k] = copied
 	}
 	return result
 }
 
 // ResetMetrics clears all performance metrics
 func (pm *PerformanceMonitor) ResetMetrics() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
 	if pm.enabled {
 		pm.metrics = make(map[string]*PerformanceMetrics)
+		pm.histograms = make(map[string]*LatencyHistogram)
+		pm.recent = make(map[string][]requestOutcome)
 	}
 }
 
 // Enable enables performance monitoring
 func (pm *PerformanceMonitor) Enable() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
 	pm.enabled = true
 	if pm.metrics == nil {
 		pm.metrics = make(map[string]*PerformanceMetrics)
+		pm.histograms = make(map[string]*LatencyHistogram)
+		pm.recent = make(map[string][]requestOutcome)
 	}
 }
 
 // Disable disables performance monitoring
 func (pm *PerformanceMonitor) Disable() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
 	pm.enabled = false
 }
 
 // IsEnabled returns whether performance monitoring is enabled
 func (pm *PerformanceMonitor) IsEnabled() bool {
+	if pm == nil {
+		return false
+	}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	return pm.enabled
 }
 
+// PrimaryModel returns the cost-efficient model preferred for simple requests
+func (ms *ModelSelector) PrimaryModel() string {
+	return ms.primaryModel
+}
+
+// FallbackModel returns the high-accuracy model used for complex requests and
+// to retry failed requests
+func (ms *ModelSelector) FallbackModel() string {
+	return ms.fallbackModel
+}
+
 // GetPerformanceMonitor returns the performance monitor for the model selector
 func (ms *ModelSelector) GetPerformanceMonitor() *PerformanceMonitor {
 	return ms.performanceLog
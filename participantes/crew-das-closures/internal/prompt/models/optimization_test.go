@@ -1,6 +1,7 @@
 package models
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
@@ -215,6 +216,67 @@ func TestPerformanceMonitor(t *testing.T) {
 	}
 }
 
+func TestPerformanceMonitor_ConcurrentRecord(t *testing.T) {
+	monitor := NewPerformanceMonitor()
+	selector := NewModelSelector()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			monitor.RecordRequest(ModelMistral7B, time.Duration(i)*time.Millisecond, i%5 != 0, 0.0001)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = monitor.GetMetrics(ModelMistral7B)
+			_ = monitor.GetLatencyHistograms()
+			_ = selector.SelectModel("Qual meu limite?")
+		}()
+	}
+	wg.Wait()
+
+	metrics := monitor.GetMetrics(ModelMistral7B)
+	if metrics.TotalRequests != 50 || metrics.FailedRequests != 10 {
+		t.Errorf("Expected 50 requests with 10 failures, got %d with %d", metrics.TotalRequests, metrics.FailedRequests)
+	}
+}
+
+func TestPerformanceMonitor_LatencyHistogram(t *testing.T) {
+	monitor := NewPerformanceMonitor()
+
+	monitor.RecordRequest(ModelGPT4OMini, 50*time.Millisecond, true, 0)
+	monitor.RecordRequest(ModelGPT4OMini, 700*time.Millisecond, true, 0)
+	monitor.RecordRequest(ModelGPT4OMini, 20*time.Second, false, 0)
+
+	histogram, ok := monitor.GetLatencyHistograms()[ModelGPT4OMini]
+	if !ok {
+		t.Fatal("Expected a latency histogram for the model")
+	}
+
+	// Buckets are cumulative: 0.1, 0.25, 0.5, 1, 2.5, 5, 10 seconds
+	want := []uint64{1, 1, 1, 2, 2, 2, 2}
+	for i := range want {
+		if histogram.Buckets[i] != want[i] {
+			t.Errorf("Bucket le=%g = %d, want %d", LatencyBuckets[i], histogram.Buckets[i], want[i])
+		}
+	}
+
+	if histogram.Count != 3 {
+		t.Errorf("Expected count 3, got %d", histogram.Count)
+	}
+
+	if abs(histogram.Sum-20.75) > 1e-9 {
+		t.Errorf("Expected sum 20.75s, got %f", histogram.Sum)
+	}
+
+	// Histograms returned are copies
+	histogram.Buckets[0] = 99
+	if monitor.GetLatencyHistograms()[ModelGPT4OMini].Buckets[0] != 1 {
+		t.Error("Expected GetLatencyHistograms to return a copy")
+	}
+}
+
 func TestPerformanceMonitorDisable(t *testing.T) {
 	monitor := NewPerformanceMonitor()
 
@@ -291,6 +353,46 @@ func TestModelDecisionWithPerformanceData(t *testing.T) {
 	}
 }
 
+func TestPerformanceMonitor_HealthWindow(t *testing.T) {
+	selector := NewModelSelector()
+	monitor := selector.performanceLog
+
+	now := time.Now()
+	monitor.now = func() time.Time { return now }
+
+	monitor.RecordRequest(ModelMistral7B, 6*time.Second, false, 0.001)
+	monitor.RecordRequest(ModelMistral7B, 7*time.Second, false, 0.001)
+
+	if got := selector.SelectModel("Qual meu limite?").ModelName; got != ModelGPT4OMini {
+		t.Errorf("SelectModel() with recent failures = %s, want %s", got, ModelGPT4OMini)
+	}
+
+	// The primary model is not called meanwhile, yet its failures age out
+	now = now.Add(DefaultHealthWindow + time.Second)
+
+	metrics := monitor.GetMetrics(ModelMistral7B)
+	if metrics.SuccessRate != 1 || metrics.AverageLatency != 0 {
+		t.Errorf("metrics after the window = %+v, want success rate 1 and no latency", metrics)
+	}
+	if metrics.TotalRequests != 2 || metrics.FailedRequests != 2 {
+		t.Errorf("Expected the lifetime counters to keep 2 failed requests, got %d of %d", metrics.FailedRequests, metrics.TotalRequests)
+	}
+
+	if got := selector.SelectModel("Qual meu limite?").ModelName; got != ModelMistral7B {
+		t.Errorf("SelectModel() after the window = %s, want %s", got, ModelMistral7B)
+	}
+
+	// Only the requests inside the window count
+	monitor.RecordRequest(ModelMistral7B, 100*time.Millisecond, true, 0.001)
+	now = now.Add(time.Second)
+	monitor.RecordRequest(ModelMistral7B, 300*time.Millisecond, false, 0.001)
+
+	metrics = monitor.GetMetrics(ModelMistral7B)
+	if metrics.SuccessRate != 0.5 || metrics.AverageLatency != 200*time.Millisecond {
+		t.Errorf("Expected success rate 0.5 and latency 200ms, got %f and %v", metrics.SuccessRate, metrics.AverageLatency)
+	}
+}
+
 // Helper function for floating point comparison
 func abs(x float64) float64 {
 	if x < 0 {
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UsageTotals holds aggregated token and cost accounting
+type UsageTotals struct {
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageReport is a snapshot of the usage aggregated by model and by service ID
+type UsageReport struct {
+	Since     time.Time              `json:"since"`
+	Totals    UsageTotals            `json:"totals"`
+	ByModel   map[string]UsageTotals `json:"by_model"`
+	ByService map[string]UsageTotals `json:"by_service"`
+}
+
+// UsageTracker aggregates the usage block reported by OpenRouter responses.
+// It is safe for concurrent use.
+type UsageTracker struct {
+	mu        sync.Mutex
+	since     time.Time
+	totals    UsageTotals
+	byModel   map[string]*UsageTotals
+	byService map[int]*UsageTotals
+}
+
+// NewUsageTracker creates an empty usage tracker
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		since:     time.Now(),
+		byModel:   make(map[string]*UsageTotals),
+		byService: make(map[int]*UsageTotals),
+	}
+}
+
+func (t *UsageTotals) add(promptTokens, completionTokens int, cost float64) {
+	t.Requests++
+	t.PromptTokens += int64(promptTokens)
+	t.CompletionTokens += int64(completionTokens)
+	t.TotalTokens += int64(promptTokens + completionTokens)
+	t.Cost += cost
+}
+
+// Record adds the usage of one completion. serviceID is the classified
+// service, or 0 when the completion could not be classified.
+func (ut *UsageTracker) Record(modelName string, serviceID int, promptTokens, completionTokens int, cost float64) {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	ut.totals.add(promptTokens, completionTokens, cost)
+
+	if ut.byModel[modelName] == nil {
+		ut.byModel[modelName] = &UsageTotals{}
+	}
+	ut.byModel[modelName].add(promptTokens, completionTokens, cost)
+
+	if ut.byService[serviceID] == nil {
+		ut.byService[serviceID] = &UsageTotals{}
+	}
+	ut.byService[serviceID].add(promptTokens, completionTokens, cost)
+}
+
+// Report returns a copy of the aggregated usage
+func (ut *UsageTracker) Report() UsageReport {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	report := UsageReport{
+		Since:     ut.since,
+		Totals:    ut.totals,
+		ByModel:   make(map[string]UsageTotals, len(ut.byModel)),
+		ByService: make(map[string]UsageTotals, len(ut.byService)),
+	}
+
+	for name, totals := range ut.byModel {
+		report.ByModel[name] = *totals
+	}
+
+	for id, totals := range ut.byService {
+		report.ByService[strconv.Itoa(id)] = *totals
+	}
+
+	return report
+}
+
+// AverageCost returns the observed cost per request of a model and whether
+// any request was recorded for it
+func (ut *UsageTracker) AverageCost(modelName string) (float64, bool) {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	totals := ut.byModel[modelName]
+	if totals == nil || totals.Requests == 0 {
+		return 0, false
+	}
+
+	return totals.Cost / float64(totals.Requests), true
+}
+
+// Reset clears all aggregated usage
+func (ut *UsageTracker) Reset() {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	ut.since = time.Now()
+	ut.totals = UsageTotals{}
+	ut.byModel = make(map[string]*UsageTotals)
+	ut.byService = make(map[int]*UsageTotals)
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestUsageTracker_Record(t *testing.T) {
+	tracker := NewUsageTracker()
+
+	tracker.Record(ModelGPT4OMini, 3, 100, 10, 0.002)
+	tracker.Record(ModelGPT4OMini, 7, 120, 12, 0.004)
+	tracker.Record(ModelMistral7B, 3, 90, 8, 0.001)
+	tracker.Record(ModelMistral7B, 0, 80, 0, 0.0005)
+
+	report := tracker.Report()
+
+	if report.Totals.Requests != 4 {
+		t.Errorf("Expected 4 requests, got %d", report.Totals.Requests)
+	}
+
+	if report.Totals.PromptTokens != 390 || report.Totals.CompletionTokens != 30 || report.Totals.TotalTokens != 420 {
+		t.Errorf("Unexpected token totals: %+v", report.Totals)
+	}
+
+	if abs(report.Totals.Cost-0.0075) > 1e-9 {
+		t.Errorf("Expected total cost 0.0075, got %f", report.Totals.Cost)
+	}
+
+	gpt := report.ByModel[ModelGPT4OMini]
+	if gpt.Requests != 2 || abs(gpt.Cost-0.006) > 1e-9 {
+		t.Errorf("Unexpected %s totals: %+v", ModelGPT4OMini, gpt)
+	}
+
+	service3 := report.ByService["3"]
+	if service3.Requests != 2 || service3.TotalTokens != 208 {
+		t.Errorf("Unexpected service 3 totals: %+v", service3)
+	}
+
+	if report.ByService["0"].Requests != 1 {
+		t.Errorf("Expected 1 unclassified request, got %d", report.ByService["0"].Requests)
+	}
+}
+
+func TestUsageTracker_ConcurrentRecord(t *testing.T) {
+	tracker := NewUsageTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tracker.Record(ModelMistral7B, i%16+1, 10, 1, 0.0001)
+		}(i)
+	}
+	wg.Wait()
+
+	if got := tracker.Report().Totals.Requests; got != 50 {
+		t.Errorf("Expected 50 requests, got %d", got)
+	}
+}
+
+func TestUsageTracker_Reset(t *testing.T) {
+	tracker := NewUsageTracker()
+	tracker.Record(ModelMistral7B, 1, 10, 1, 0.0001)
+	tracker.Reset()
+
+	report := tracker.Report()
+	if report.Totals.Requests != 0 || len(report.ByModel) != 0 || len(report.ByService) != 0 {
+		t.Errorf("Expected empty report after reset, got %+v", report)
+	}
+}
+
+func TestGetModelCostEstimate_UsesObservedCost(t *testing.T) {
+	selector := NewModelSelector()
+	intent := "Qual meu limite do cartão?"
+
+	guess := selector.GetModelCostEstimate(intent, ModelGPT4OMini)
+
+	tracker := NewUsageTracker()
+	selector.SetUsageTracker(tracker)
+
+	if got := selector.GetModelCostEstimate(intent, ModelGPT4OMini); got != guess {
+		t.Errorf("Expected token estimate %f without recorded usage, got %f", guess, got)
+	}
+
+	tracker.Record(ModelGPT4OMini, 1, 200, 20, 0.003)
+	tracker.Record(ModelGPT4OMini, 1, 200, 20, 0.005)
+
+	if got := selector.GetModelCostEstimate(intent, ModelGPT4OMini); abs(got-0.004) > 1e-9 {
+		t.Errorf("Expected observed average cost 0.004, got %f", got)
+	}
+}
@@ -85,18 +85,30 @@ func (pm *PromptManager) GenerateClassificationPrompt(userIntent string) (string
 	return prompt, nil
 }
 
-// GenerateModelSpecificPrompt creates a prompt optimized for a specific model
-func (pm *PromptManager) GenerateModelSpecificPrompt(userIntent string) (string, error) {
+// GenerateModelSpecificPrompt creates a prompt optimized for a specific model.
+// Mistral instruct models get the [INST] wrapper they were trained on.
+func (pm *PromptManager) GenerateModelSpecificPrompt(userIntent, modelName string) (string, error) {
 	if userIntent == "" {
 		return "", fmt.Errorf("user intent cannot be empty")
 	}
 
+	if strings.Contains(strings.ToLower(modelName), "mistral") {
+		return fmt.Sprintf("<s>[INST] %s\n\nUser Intent: %s\n\nClassify this intent and provide your reasoning and the final JSON. [/INST]",
+			pm.systemPrompt,
+			strings.TrimSpace(userIntent)), nil
+	}
+
 	// For GPT and other models, use the standard format with a clear instruction
 	return fmt.Sprintf("%s\n\nUser Intent: %s\n\nClassify this intent and provide your reasoning and the final JSON.",
 		pm.systemPrompt,
 		strings.TrimSpace(userIntent)), nil
 }
 
+// GetSystemPrompt returns the system prompt for classification
+func (pm *PromptManager) GetSystemPrompt() string {
+	return pm.systemPrompt
+}
+
 // GetServiceDefinitions returns all available service definitions
 func (pm *PromptManager) GetServiceDefinitions() []models.ServiceDefinition {
 	services := pm.serviceRegistry.GetAllServices()
@@ -16,10 +16,17 @@ func healthHandler(w http.ResponseWriter, _ *http.Request) {
 	responseJSON(w, http.StatusOK, &model.HealthResponse{Status: "ok"})
 }
 
-func findServiceHandler(aks *core.Core) http.HandlerFunc {
+func metricsHandler(aks *core.Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		responseJSON(w, http.StatusOK, aks.Usage.Report())
+	}
+}
+
+func findServiceHandler(aks *core.Core, requests *requestMetrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		intentData, err := extractIntentFromRequest(r)
 		if err != nil {
+			requests.observe(nil)
 			responseJSON(w, http.StatusOK, &model.FindServiceResponse{
 				Success: false,
 				Error:   fmt.Errorf("invalid request: %w", err).Error(),
@@ -27,7 +34,8 @@ func findServiceHandler(aks *core.Core) http.HandlerFunc {
 			return
 		}
 
-		serviceResponse, err := aks.AskQuestion(intentData)
+		serviceResponse, err := aks.AskQuestion(r.Context(), intentData)
+		requests.observe(serviceResponse)
 		if err != nil {
 			responseJSON(w, http.StatusOK, &model.FindServiceResponse{
 				Success: false,
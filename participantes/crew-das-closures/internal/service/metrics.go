@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dyammarcano/crew-das-closures/internal/core"
+	"github.com/dyammarcano/crew-das-closures/internal/model"
+	"github.com/dyammarcano/crew-das-closures/internal/prompt/models"
+)
+
+// requestMetrics counts the find-service responses. It is safe for concurrent use.
+type requestMetrics struct {
+	mu        sync.Mutex
+	succeeded uint64
+	failed    uint64
+	byService map[uint8]uint64
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{byService: make(map[uint8]uint64)}
+}
+
+// observe records one response; nil stands for a request that failed before classification
+func (m *requestMetrics) observe(res *model.FindServiceResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if res == nil || !res.Success || res.Data == nil {
+		m.failed++
+		return
+	}
+
+	m.succeeded++
+	m.byService[res.Data.ServiceID]++
+}
+
+// prometheusHandler serves the metrics in the Prometheus text exposition format
+func prometheusHandler(aks *core.Core, requests *requestMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+
+		pw := &promWriter{w: bufio.NewWriter(w)}
+		writeRequestMetrics(pw, requests)
+		writeModelMetrics(pw, aks.Selector.GetPerformanceMonitor(), aks.Usage.Report())
+		writeAgreementMetrics(pw, aks.Agreement.Report())
+		_ = pw.w.Flush()
+	}
+}
+
+func writeRequestMetrics(pw *promWriter, requests *requestMetrics) {
+	requests.mu.Lock()
+	succeeded, failed := requests.succeeded, requests.failed
+	byService := make(map[uint8]uint64, len(requests.byService))
+	for id, count := range requests.byService {
+		byService[id] = count
+	}
+	requests.mu.Unlock()
+
+	pw.family("crew_find_service_requests_total", "counter", "Find-service requests by result.")
+	pw.sample("crew_find_service_requests_total", labels("result", "success"), float64(succeeded))
+	pw.sample("crew_find_service_requests_total", labels("result", "failure"), float64(failed))
+
+	pw.family("crew_classifications_total", "counter", "Successful classifications by service_id.")
+	ids := make([]int, 0, len(byService))
+	for id := range byService {
+		ids = append(ids, int(id))
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		pw.sample("crew_classifications_total", labels("service_id", strconv.Itoa(id)), float64(byService[uint8(id)]))
+	}
+}
+
+func writeModelMetrics(pw *promWriter, monitor *models.PerformanceMonitor, usage models.UsageReport) {
+	metrics := monitor.GetAllMetrics()
+	histograms := monitor.GetLatencyHistograms()
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	pw.family("crew_model_requests_total", "counter", "OpenRouter completions by model and result, retries included.")
+	for _, name := range names {
+		m := metrics[name]
+		pw.sample("crew_model_requests_total", labels("model", name, "result", "success"), float64(m.TotalRequests-m.FailedRequests))
+		pw.sample("crew_model_requests_total", labels("model", name, "result", "failure"), float64(m.FailedRequests))
+	}
+
+	pw.family("crew_model_request_duration_seconds", "histogram", "OpenRouter completion latency by model.")
+	for _, name := range names {
+		h, ok := histograms[name]
+		if !ok {
+			continue
+		}
+		for i, bound := range models.LatencyBuckets {
+			pw.sample("crew_model_request_duration_seconds_bucket", labels("model", name, "le", formatFloat(bound)), float64(h.Buckets[i]))
+		}
+		pw.sample("crew_model_request_duration_seconds_bucket", labels("model", name, "le", "+Inf"), float64(h.Count))
+		pw.sample("crew_model_request_duration_seconds_sum", labels("model", name), h.Sum)
+		pw.sample("crew_model_request_duration_seconds_count", labels("model", name), float64(h.Count))
+	}
+
+	pw.family("crew_model_cost_per_request_dollars", "gauge", "Moving average of the cost per completion by model.")
+	for _, name := range names {
+		pw.sample("crew_model_cost_per_request_dollars", labels("model", name), metrics[name].CostPerRequest)
+	}
+
+	// Cost and tokens as reported by OpenRouter, by the model name it answered with
+	usageModels := make([]string, 0, len(usage.ByModel))
+	for name := range usage.ByModel {
+		usageModels = append(usageModels, name)
+	}
+	slices.Sort(usageModels)
+
+	pw.family("crew_model_cost_dollars_total", "counter", "Cost reported by OpenRouter by model.")
+	for _, name := range usageModels {
+		pw.sample("crew_model_cost_dollars_total", labels("model", name), usage.ByModel[name].Cost)
+	}
+
+	pw.family("crew_model_tokens_total", "counter", "Tokens reported by OpenRouter by model and type.")
+	for _, name := range usageModels {
+		totals := usage.ByModel[name]
+		pw.sample("crew_model_tokens_total", labels("model", name, "type", "prompt"), float64(totals.PromptTokens))
+		pw.sample("crew_model_tokens_total", labels("model", name, "type", "completion"), float64(totals.CompletionTokens))
+	}
+}
+
+// writeAgreementMetrics writes how the race and vote candidates compare with
+// the decisions; the families are empty with the single strategy
+func writeAgreementMetrics(pw *promWriter, report models.AgreementReport) {
+	pw.family("crew_ensemble_decisions_total", "counter", "Race and vote decisions by whether a quorum was reached.")
+	pw.sample("crew_ensemble_decisions_total", labels("quorum", "reached"), float64(report.Decisions-report.NoQuorum))
+	pw.sample("crew_ensemble_decisions_total", labels("quorum", "missed"), float64(report.NoQuorum))
+
+	names := make([]string, 0, len(report.ByModel))
+	for name := range report.ByModel {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	pw.family("crew_model_agreement_total", "counter", "Ensemble calls by model and how they compare with the decision.")
+	for _, name := range names {
+		s := report.ByModel[name]
+		pw.sample("crew_model_agreement_total", labels("model", name, "result", "agreed"), float64(s.Agreed))
+		pw.sample("crew_model_agreement_total", labels("model", name, "result", "disagreed"), float64(s.Answers-s.Agreed))
+		pw.sample("crew_model_agreement_total", labels("model", name, "result", "failed"), float64(s.Failures))
+		pw.sample("crew_model_agreement_total", labels("model", name, "result", "cancelled"), float64(s.Cancelled))
+	}
+
+	pw.family("crew_model_race_wins_total", "counter", "Races won by model.")
+	for _, name := range names {
+		pw.sample("crew_model_race_wins_total", labels("model", name), float64(report.ByModel[name].Wins))
+	}
+}
+
+// promWriter writes metric families in the Prometheus text exposition format
+type promWriter struct {
+	w *bufio.Writer
+}
+
+func (pw *promWriter) family(name, typ, help string) {
+	fmt.Fprintf(pw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (pw *promWriter) sample(name, labels string, value float64) {
+	fmt.Fprintf(pw.w, "%s%s %s\n", name, labels, formatFloat(value))
+}
+
+// labels formats key/value pairs as a Prometheus label set
+func labels(pairs ...string) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(pairs[i])
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(pairs[i+1]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dyammarcano/crew-das-closures/internal/client/openrouter"
+	"github.com/dyammarcano/crew-das-closures/internal/core"
+	"github.com/dyammarcano/crew-das-closures/internal/model"
+	"github.com/dyammarcano/crew-das-closures/internal/prompt/models"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	aks, err := core.NewCore("http://127.0.0.1:0", openrouter.WithAuth("test"))
+	if err != nil {
+		t.Fatalf("NewCore() error: %v", err)
+	}
+
+	monitor := aks.Selector.GetPerformanceMonitor()
+	monitor.RecordRequest(models.ModelMistral7B, 300*time.Millisecond, true, 0.0001)
+	monitor.RecordRequest(models.ModelMistral7B, 3*time.Second, false, 0.0001)
+	aks.Usage.Record("mistralai/mistral-7b-instruct", 3, 100, 10, 0.0002)
+	aks.Agreement.Record([]models.ModelOutcome{
+		{Model: models.ModelMistral7B, ServiceID: 3, Won: true},
+		{Model: models.ModelGPT4OMini, Cancelled: true},
+	}, 3, true)
+
+	requests := newRequestMetrics()
+	requests.observe(&model.FindServiceResponse{Success: true, Data: &model.ServiceData{ServiceID: 3}})
+	requests.observe(&model.FindServiceResponse{Success: true, Data: &model.ServiceData{ServiceID: 3}})
+	requests.observe(nil)
+
+	rec := httptest.NewRecorder()
+	prometheusHandler(aks, requests)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want Prometheus text format", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE crew_find_service_requests_total counter",
+		`crew_find_service_requests_total{result="success"} 2`,
+		`crew_find_service_requests_total{result="failure"} 1`,
+		`crew_classifications_total{service_id="3"} 2`,
+		`crew_model_requests_total{model="mistralai/mistral-7b-instruct",result="success"} 1`,
+		`crew_model_requests_total{model="mistralai/mistral-7b-instruct",result="failure"} 1`,
+		"# TYPE crew_model_request_duration_seconds histogram",
+		`crew_model_request_duration_seconds_bucket{model="mistralai/mistral-7b-instruct",le="0.5"} 1`,
+		`crew_model_request_duration_seconds_bucket{model="mistralai/mistral-7b-instruct",le="5"} 2`,
+		`crew_model_request_duration_seconds_bucket{model="mistralai/mistral-7b-instruct",le="+Inf"} 2`,
+		`crew_model_request_duration_seconds_sum{model="mistralai/mistral-7b-instruct"} 3.3`,
+		`crew_model_request_duration_seconds_count{model="mistralai/mistral-7b-instruct"} 2`,
+		`crew_model_cost_dollars_total{model="mistralai/mistral-7b-instruct"} 0.0002`,
+		`crew_model_tokens_total{model="mistralai/mistral-7b-instruct",type="prompt"} 100`,
+		`crew_ensemble_decisions_total{quorum="reached"} 1`,
+		`crew_model_agreement_total{model="mistralai/mistral-7b-instruct",result="agreed"} 1`,
+		`crew_model_agreement_total{model="openai/gpt-4o-mini",result="cancelled"} 1`,
+		`crew_model_race_wins_total{model="mistralai/mistral-7b-instruct"} 1`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("metrics missing %q\n%s", want, body)
+		}
+	}
+}
+
+func TestLabelsEscaping(t *testing.T) {
+	got := labels("model", "a\"b\\c\nd")
+	want := `{model="a\"b\\c\nd"}`
+	if got != want {
+		t.Errorf("labels() = %s, want %s", got, want)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dyammarcano/crew-das-closures/internal/client/openrouter"
 	"github.com/dyammarcano/crew-das-closures/internal/core"
+	"github.com/dyammarcano/crew-das-closures/internal/prompt/models"
 	"github.com/spf13/cobra"
 )
 
@@ -24,16 +28,77 @@ func Service(_ *cobra.Command, _ []string) error {
 		Handler: forceStatusOK(router),
 	}
 
-	urlStr := "https://openrouter.ai/api/v1"
-	opts := openrouter.WithAuth(openRouterKey)
+	urlStr := os.Getenv("OPENROUTER_BASE_URL")
+	if urlStr == "" {
+		urlStr = "https://openrouter.ai/api/v1"
+	}
+	// retry a transient upstream failure once before AskQuestion falls back to
+	// the other model; both attempts fit in the core attempt timeout
+	opts := []openrouter.Option{
+		openrouter.WithAuth(openRouterKey),
+		openrouter.WithAttemptTimeout(4 * time.Second),
+		openrouter.WithRetry(openrouter.RetryPolicy{MaxAttempts: 2, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}),
+	}
 
-	aks, err := core.NewCore(urlStr, opts)
+	aks, err := core.NewCore(urlStr, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize core: %w", err)
 	}
 
+	// empty values keep the selector defaults
+	aks.SetModelSelector(models.NewModelSelectorWithConfig(models.ModelConfig{
+		PrimaryModel:     os.Getenv("OPENROUTER_PRIMARY_MODEL"),
+		FallbackModel:    os.Getenv("OPENROUTER_FALLBACK_MODEL"),
+		EnableMonitoring: true,
+	}))
+
+	ensemble, err := ensembleConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if err := aks.SetEnsemble(ensemble); err != nil {
+		return fmt.Errorf("invalid ensemble configuration: %w", err)
+	}
+
+	requests := newRequestMetrics()
+
 	router.HandleFunc("GET /api/health", healthHandler)
-	router.HandleFunc("POST /api/find-service", findServiceHandler(aks))
+	router.HandleFunc("POST /api/find-service", findServiceHandler(aks, requests))
+	router.HandleFunc("GET /api/metrics", metricsHandler(aks))
+	router.HandleFunc("GET /metrics", prometheusHandler(aks, requests))
 
 	return server.ListenAndServe()
 }
+
+// ensembleConfigFromEnv reads the classification strategy. ENSEMBLE_STRATEGY
+// is single (default), race or vote; ENSEMBLE_MODELS is a comma-separated
+// list of models, the selector's primary and fallback when empty.
+func ensembleConfigFromEnv() (core.EnsembleConfig, error) {
+	cfg := core.EnsembleConfig{Strategy: core.Strategy(os.Getenv("ENSEMBLE_STRATEGY"))}
+
+	for _, name := range strings.Split(os.Getenv("ENSEMBLE_MODELS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.Models = append(cfg.Models, name)
+		}
+	}
+
+	for key, dst := range map[string]*int{"ENSEMBLE_SAMPLES": &cfg.Samples, "ENSEMBLE_QUORUM": &cfg.Quorum} {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid %s %q: %w", key, v, err)
+			}
+			*dst = n
+		}
+	}
+
+	if v := os.Getenv("ENSEMBLE_TEMPERATURE"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid ENSEMBLE_TEMPERATURE %q: %w", v, err)
+		}
+		cfg.Temperature = &t
+	}
+
+	return cfg, nil
+}
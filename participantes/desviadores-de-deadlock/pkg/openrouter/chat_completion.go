@@ -39,16 +39,16 @@ type (
 	}
 )
 
+// ChatCompletion classifica o intent pedindo uma resposta no schema do catálogo.
+// Se o modelo recusar json_schema, repete com json_object e repara a resposta
 func (c *Client) ChatCompletion(ctx context.Context, intent string) (*DataResponse, error) {
-	url := c.baseURL + "/chat/completions"
-
 	requestBody := OpenRouterRequest{
 		Model:          "anthropic/claude-haiku-4.5",
 		Temperature:    0.2,
 		MaxTokens:      512,
 		TopP:           1.0,
-		ResponseFormat: map[string]any{"type": "json_object"}, // <--- força JSON
-		Stop:           []string{"\n\n"},                      // opcional: para o modelo não narrar
+		ResponseFormat: ServiceResponseFormat(Services), // <--- força o schema do catálogo
+		Stop:           []string{"\n\n"},                // opcional: para o modelo não narrar
 		Messages: []struct {
 			Role    string `json:"role"`
 			Content string `json:"content"`
@@ -107,8 +107,8 @@ REGRAS ESPECÍFICAS PARA EVITAR ERROS OBSERVADOS
    – "quero pagar fatura" → 13
 • **16/Token**: "receber código do cartão", "número de token", "código de token da proposta" → 16
 
-CONFIDÊNCIA E EMPATE
-- Se confiança < 0.50 ou top-2 muito próximos (diferença < 0.05) → use Desconhecido.
+EMPATE
+- Se os dois serviços mais prováveis estiverem muito próximos, aplique as regras de desambiguação acima; na dúvida → 15.
 
 VERIFICAÇÃO (interna, sem mostrar)
 1) Confirme que termos sustentam o serviço escolhido e que nenhuma prioridade acima foi violada.
@@ -117,10 +117,8 @@ VERIFICAÇÃO (interna, sem mostrar)
 
 SAÍDA OBRIGATÓRIA (todas as chaves, nessa ordem exata):
 {
-  "service_id": <número ou null>,
-  "service_name": "<nome do serviço ou 'Desconhecido'>",
-  "confidence": <número entre 0 e 1 com duas casas>,
-  "explanation": "<frase curta explicando a decisão>"
+  "service_id": <número de 1 a 16>,
+  "service_name": "<nome exato do serviço>"
 }
 `,
 			},
@@ -128,32 +126,26 @@ SAÍDA OBRIGATÓRIA (todas as chaves, nessa ordem exata):
 		},
 	}
 
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
+	if structuredOutputsUnsupported.Load() {
+		requestBody.ResponseFormat = map[string]any{"type": "json_object"}
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	// Dica: se usar OpenRouter, inclua também Authorization e cabeçalhos recomendados (HTTP-Referer/X-Title) no c.Client.Do(...)
-
-	resp, err := c.Do(ctx, req)
+	body, status, err := c.send(ctx, &requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response: %v", err)
+	// Modelo sem structured outputs: volta para json_object e conta com o reparo
+	if status != http.StatusOK && !structuredOutputsUnsupported.Load() && unsupportedResponseFormat(status, body) {
+		structuredOutputsUnsupported.Store(true)
+		requestBody.ResponseFormat = map[string]any{"type": "json_object"}
+		if body, status, err = c.send(ctx, &requestBody); err != nil {
+			return nil, err
+		}
 	}
-	fmt.Println("body:", string(body))
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
 	}
 
 	var orResp OpenRouterResponse
@@ -164,13 +156,35 @@ SAÍDA OBRIGATÓRIA (todas as chaves, nessa ordem exata):
 		return nil, fmt.Errorf("no choices in response")
 	}
 
-	content := orResp.Choices[0].Message.Content
-	payload := extractJSON(content) // <--- remove cercas/pega primeiro objeto
+	return parseDataResponse(orResp.Choices[0].Message.Content)
+}
+
+func (c *Client) send(ctx context.Context, requestBody *OpenRouterRequest) ([]byte, int, error) {
+	url := c.baseURL + "/chat/completions"
+
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Dica: se usar OpenRouter, inclua também Authorization e cabeçalhos recomendados (HTTP-Referer/X-Title) no c.Client.Do(...)
+
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
 
-	var dataRes DataResponse
-	if err := json.Unmarshal([]byte(payload), &dataRes); err != nil {
-		return nil, fmt.Errorf("error unmarshaling data response: %v. content: %s", err, payload)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading response: %v", err)
 	}
+	fmt.Println("body:", string(body))
 
-	return &dataRes, nil
+	return body, resp.StatusCode, nil
 }
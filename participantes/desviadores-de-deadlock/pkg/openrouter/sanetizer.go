@@ -2,13 +2,16 @@ package openrouter
 
 import (
 	// ...
+	"encoding/json"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 var (
 	codeFenceRe = regexp.MustCompile("(?s)```(?:json)?\\s*(\\{.*?\\})\\s*```")
 	jsonObjRe   = regexp.MustCompile(`(?s)\{.*\}`)
+	serviceIDRe = regexp.MustCompile(`(?i)\b(?:service_id|id|serviço|servico)["']?\s*[:=\-]?\s*(\d{1,2})\b`)
 )
 
 func extractJSON(s string) string {
@@ -23,3 +26,47 @@ func extractJSON(s string) string {
 	// 3) fallback: trim cercas simples ou retorno direto
 	return strings.TrimSpace(s)
 }
+
+// repairDataResponse recupera a classificação de uma resposta fora do schema.
+// O nome sempre vem do catálogo
+func repairDataResponse(content string, services []Service) (*DataResponse, bool) {
+	// 1) objeto JSON no texto, mesmo com chaves extras ou nome divergente
+	var data struct {
+		ServiceID   json.Number `json:"service_id"`
+		ServiceName string      `json:"service_name"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(content)), &data); err == nil {
+		if id, err := strconv.Atoi(data.ServiceID.String()); err == nil {
+			if service, ok := findService(services, id); ok {
+				return service.dataResponse(), true
+			}
+		}
+		for _, s := range services {
+			if strings.EqualFold(strings.TrimSpace(data.ServiceName), s.Name) {
+				return s.dataResponse(), true
+			}
+		}
+	}
+
+	// 2) ID em prosa: "ID: 3", "serviço 3"
+	if m := serviceIDRe.FindStringSubmatch(content); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		if service, ok := findService(services, id); ok {
+			return service.dataResponse(), true
+		}
+	}
+
+	// 3) nome do serviço citado no texto; o mais longo vence
+	var best *Service
+	lower := strings.ToLower(content)
+	for i, s := range services {
+		if strings.Contains(lower, strings.ToLower(s.Name)) && (best == nil || len(s.Name) > len(best.Name)) {
+			best = &services[i]
+		}
+	}
+	if best != nil {
+		return best.dataResponse(), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,147 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Service é um serviço do catálogo fixo
+type Service struct {
+	ID   uint8
+	Name string
+}
+
+// Services é o catálogo de serviços usado no prompt, no schema e na validação
+var Services = []Service{
+	{1, "Consulta Limite / Vencimento do cartão / Melhor dia de compra"},
+	{2, "Segunda via de boleto de acordo"},
+	{3, "Segunda via de Fatura"},
+	{4, "Status de Entrega do Cartão"},
+	{5, "Status de cartão"},
+	{6, "Solicitação de aumento de limite"},
+	{7, "Cancelamento de cartão"},
+	{8, "Telefones de seguradoras"},
+	{9, "Desbloqueio de Cartão"},
+	{10, "Esqueceu senha / Troca de senha"},
+	{11, "Perda e roubo"},
+	{12, "Consulta do Saldo"},
+	{13, "Pagamento de contas"},
+	{14, "Reclamações"},
+	{15, "Atendimento humano"},
+	{16, "Token de proposta"},
+}
+
+// structuredOutputsUnsupported fica true depois que o modelo recusa json_schema.
+// É global porque o handler cria um Client por requisição
+var structuredOutputsUnsupported atomic.Bool
+
+// ServiceResponseFormat gera o response_format json_schema a partir do catálogo:
+// service_id é um dos IDs e service_name um dos nomes. O par é conferido em validateDataResponse
+func ServiceResponseFormat(services []Service) map[string]any {
+	ids := make([]int, len(services))
+	names := make([]string, len(services))
+	for i, s := range services {
+		ids[i], names[i] = int(s.ID), s.Name
+	}
+
+	return map[string]any{
+		"type": "json_schema",
+		"json_schema": map[string]any{
+			"name":   "service_classification",
+			"strict": true,
+			"schema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"service_id":   map[string]any{"type": "integer", "enum": ids},
+					"service_name": map[string]any{"type": "string", "enum": names},
+				},
+				"required":             []string{"service_id", "service_name"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// parseDataResponse valida a resposta contra o schema e, se não validar
+// (modelo sem suporte a structured outputs), tenta repará-la
+func parseDataResponse(content string) (*DataResponse, error) {
+	data, err := validateDataResponse([]byte(content), Services)
+	if err == nil {
+		return data, nil
+	}
+
+	if repaired, ok := repairDataResponse(content, Services); ok {
+		return repaired, nil
+	}
+
+	return nil, fmt.Errorf("error unmarshaling data response: %v. content: %s", err, content)
+}
+
+// validateDataResponse exige exatamente service_id do catálogo e o service_name correspondente
+func validateDataResponse(content []byte, services []Service) (*DataResponse, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"service_id", "service_name"} {
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("missing property %q", name)
+		}
+	}
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected properties in %s", content)
+	}
+
+	var id uint8
+	if err := json.Unmarshal(fields["service_id"], &id); err != nil {
+		return nil, fmt.Errorf("service_id: %v", err)
+	}
+
+	var name string
+	if err := json.Unmarshal(fields["service_name"], &name); err != nil {
+		return nil, fmt.Errorf("service_name: %v", err)
+	}
+
+	service, ok := findService(services, int(id))
+	if !ok {
+		return nil, fmt.Errorf("service_id %d is not in the catalog", id)
+	}
+	if name != service.Name {
+		return nil, fmt.Errorf("service_name %q does not match service_id %d", name, id)
+	}
+
+	return service.dataResponse(), nil
+}
+
+// unsupportedResponseFormat indica se a recusa foi por falta de suporte a structured outputs
+func unsupportedResponseFormat(status int, body []byte) bool {
+	if status != http.StatusBadRequest && status != http.StatusNotFound {
+		return false
+	}
+
+	lower := strings.ToLower(string(body))
+	for _, hint := range []string{"response_format", "json_schema", "structured output", "requested parameters"} {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Service) dataResponse() *DataResponse {
+	id := s.ID
+	return &DataResponse{ServiceID: &id, ServiceName: s.Name}
+}
+
+func findService(services []Service, id int) (Service, bool) {
+	for _, s := range services {
+		if int(s.ID) == id {
+			return s, true
+		}
+	}
+	return Service{}, false
+}
@@ -0,0 +1,126 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServiceResponseFormat(t *testing.T) {
+	raw, err := json.Marshal(ServiceResponseFormat(Services))
+	if err != nil {
+		t.Fatalf("failed to marshal response_format: %v", err)
+	}
+
+	var format struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Strict bool `json:"strict"`
+			Schema struct {
+				Properties map[string]struct {
+					Enum []any `json:"enum"`
+				} `json:"properties"`
+				AdditionalProperties bool `json:"additionalProperties"`
+			} `json:"schema"`
+		} `json:"json_schema"`
+	}
+	if err := json.Unmarshal(raw, &format); err != nil {
+		t.Fatalf("failed to unmarshal response_format: %v", err)
+	}
+
+	if format.Type != "json_schema" || !format.JSONSchema.Strict || format.JSONSchema.Schema.AdditionalProperties {
+		t.Errorf("expected a strict json_schema without additional properties, got %s", raw)
+	}
+
+	ids := format.JSONSchema.Schema.Properties["service_id"].Enum
+	if len(ids) != 16 || ids[0] != float64(1) || ids[15] != float64(16) {
+		t.Errorf("expected service_id enum 1..16, got %v", ids)
+	}
+	if names := format.JSONSchema.Schema.Properties["service_name"].Enum; len(names) != 16 {
+		t.Errorf("expected 16 service names, got %v", names)
+	}
+}
+
+func TestParseDataResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    uint8
+		wantErr bool
+	}{
+		{name: "schema", content: `{"service_id": 3, "service_name": "Segunda via de Fatura"}`, want: 3},
+		{name: "nome divergente", content: `{"service_id": 3, "service_name": "Fatura"}`, want: 3},
+		{name: "chaves extras", content: `{"service_id": 7, "service_name": "Cancelamento de cartão", "confidence": 0.91, "explanation": "cancelar"}`, want: 7},
+		{name: "cerca de código", content: "```json\n{\"service_id\": 12, \"service_name\": \"Consulta do Saldo\"}\n```", want: 12},
+		{name: "prosa", content: "ID: 3", want: 3},
+		{name: "nome no texto", content: "Trata-se de Perda e roubo.", want: 11},
+		{name: "fora do catálogo", content: `{"service_id": null, "service_name": "Desconhecido"}`, wantErr: true},
+		{name: "sem classificação", content: "não sei", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDataResponse(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			service, _ := findService(Services, int(tt.want))
+			if got.ServiceID == nil || *got.ServiceID != tt.want || got.ServiceName != service.Name {
+				t.Errorf("expected %d %q, got %+v", tt.want, service.Name, got)
+			}
+		})
+	}
+}
+
+func TestChatCompletion_FallsBackToJSONObject(t *testing.T) {
+	structuredOutputsUnsupported.Store(false)
+	t.Cleanup(func() { structuredOutputsUnsupported.Store(false) })
+
+	var schemaRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResponseFormat map[string]any `json:"response_format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		if req.ResponseFormat["type"] == "json_schema" {
+			schemaRequests.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "json_schema response_format is not supported"}}`))
+			return
+		}
+
+		content, _ := json.Marshal("Serviço 9 - Desbloqueio de Cartão")
+		fmt.Fprintf(w, `{"choices": [{"message": {"content": %s}}]}`, content)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	for i := 0; i < 2; i++ {
+		data, err := client.ChatCompletion(context.Background(), "desbloquear cartão")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data.ServiceID == nil || *data.ServiceID != 9 {
+			t.Errorf("expected service 9, got %+v", data)
+		}
+	}
+
+	if schemaRequests.Load() != 1 {
+		t.Errorf("expected 1 json_schema request, got %d", schemaRequests.Load())
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	_ "embed"
@@ -48,6 +49,7 @@ type openRouterRequest struct {
 	Model    string              `json:"model"`
 	Messages []openRouterMessage `json:"messages"`
 	Provider *openRouterProvider `json:"provider,omitempty"`
+	Stream   bool                `json:"stream,omitempty"`
 }
 
 type openRouterMessage struct {
@@ -80,6 +82,15 @@ type openRouterError struct {
 	Code    string `json:"code"`
 }
 
+type openRouterStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *openRouterError `json:"error,omitempty"`
+}
+
 func NewOpenRouterClient() *OpenRouterClient {
 	apiKey := os.Getenv("OPENROUTER_API_KEY")
 	if apiKey == "" {
@@ -113,6 +124,7 @@ func (c *OpenRouterClient) FindServiceByIntent(ctx context.Context, intent strin
 		Provider: &openRouterProvider{
 			Sort: "latency",
 		},
+		Stream: true,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -131,43 +143,29 @@ func (c *OpenRouterClient) FindServiceByIntent(ctx context.Context, intent strin
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Title", "Esquadrao do Scheduler")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		logger.Error("failed to send request", "error", err)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	// Closing the body ends the stream even if the model is still generating
 	defer resp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logger.Error("failed to read response body", "error", err)
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
 	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
 		logger.Error("openrouter returned non-200 status", "status", resp.StatusCode, "body", string(bodyBytes))
 		return nil, fmt.Errorf("openrouter returned status %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	logger.Info("openrouter api response", "body", string(bodyBytes))
-
-	var orResp openRouterResponse
-	if err := json.Unmarshal(bodyBytes, &orResp); err != nil {
-		logger.Error("failed to unmarshal openrouter response", "error", err, "body", string(bodyBytes))
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	if orResp.Error != nil {
-		logger.Error("openrouter returned error", "error", orResp.Error.Message, "type", orResp.Error.Type)
-		return nil, fmt.Errorf("openrouter error: %s", orResp.Error.Message)
+	content, ttft, err := readServiceID(resp.Body, start)
+	if err != nil {
+		logger.Error("failed to read openrouter stream", "error", err)
+		return nil, err
 	}
 
-	if len(orResp.Choices) == 0 {
-		logger.Error("no response received from model")
-		return nil, fmt.Errorf("no response received from model")
-	}
+	logger.Info("openrouter stream decided", "content", content, "ttft_ms", ttft.Milliseconds(), "duration_ms", time.Since(start).Milliseconds())
 
-	content := orResp.Choices[0].Message.Content
 	id, err := strconv.Atoi(content)
 	if err != nil {
 		logger.Error("service not found in map", "service_id", content)
@@ -186,3 +184,77 @@ func (c *OpenRouterClient) FindServiceByIntent(ctx context.Context, intent strin
 		ServiceName: serviceName,
 	}, nil
 }
+
+// readServiceID reads the SSE stream until the content holds a complete ID and
+// returns it without waiting for the rest of the completion. Digits are
+// complete once followed by whitespace, a quote or "}", since "1" may still
+// become "16" or "1a via". ttft is measured from start.
+func readServiceID(body io.Reader, start time.Time) (string, time.Duration, error) {
+	var content strings.Builder
+	var ttft time.Duration
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // blank separators and ": OPENROUTER PROCESSING" comments
+		}
+
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openRouterStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", ttft, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+
+		if chunk.Error != nil {
+			return "", ttft, fmt.Errorf("openrouter error: %s", chunk.Error.Message)
+		}
+
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		if ttft == 0 {
+			ttft = time.Since(start)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+
+		if id, ok := completeServiceID(content.String()); ok {
+			return id, ttft, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", ttft, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	if ttft == 0 {
+		return "", ttft, fmt.Errorf("no response received from model")
+	}
+
+	return strings.TrimSpace(content.String()), ttft, nil
+}
+
+// completeServiceID returns the leading ID once a terminator follows it.
+// Content that does not start with an ID, such as "2a via" or "1. O cliente",
+// is returned as is, to be rejected.
+func completeServiceID(content string) (string, bool) {
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	if trimmed == "" {
+		return "", false
+	}
+
+	end := strings.IndexFunc(trimmed, func(r rune) bool { return r < '0' || r > '9' })
+	switch {
+	case end == -1:
+		return "", false // the next token may extend the number
+	case end == 0 || !strings.ContainsRune(" \t\r\n\"'}", rune(trimmed[end])):
+		return strings.TrimSpace(trimmed), true
+	}
+
+	return trimmed[:end], true
+}
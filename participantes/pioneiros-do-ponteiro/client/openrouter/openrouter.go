@@ -33,8 +33,10 @@ func NewClient(baseURL string, opts ...Option) *Client {
 	return c
 }
 
+// Do runs the request through the option chain. ctx replaces the request
+// context, so cancelling it aborts the request, retries and waits included.
 func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
-	resp, err := c.doFunc(c, req)
+	resp, err := c.doFunc(c, req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
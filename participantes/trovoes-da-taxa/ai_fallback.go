@@ -73,9 +73,22 @@ func (c *AIClient) SetIntents(intents []Intent) {
 }
 
 type openRouterRequest struct {
-	Model     string    `json:"model"`
-	Messages  []message `json:"messages"`
-	MaxTokens int       `json:"max_tokens,omitempty"`
+	Model     string        `json:"model"`
+	Messages  []message     `json:"messages"`
+	MaxTokens int           `json:"max_tokens,omitempty"`
+	Usage     *usageOptions `json:"usage,omitempty"`
+}
+
+// usageOptions pede à OpenRouter o custo da chamada na resposta
+type usageOptions struct {
+	Include bool `json:"include"`
+}
+
+// AIUsage representa o consumo de uma chamada à IA
+type AIUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"` // USD
 }
 
 type message struct {
@@ -97,6 +110,7 @@ type openRouterResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage AIUsage `json:"usage"`
 }
 
 // buildPrompt constrói o prompt para a IA com as instruções precisas
@@ -231,10 +245,12 @@ func (c *AIClient) buildPrompt(intentText string, services map[int]string) strin
 	return sb.String()
 }
 
-// ClassifyWithAI usa a API da OpenRouter para classificar a intenção
-func (c *AIClient) ClassifyWithAI(ctx context.Context, intentText string, services map[int]string) (*ClassificationResult, error) {
+// ClassifyWithAI usa a API da OpenRouter para classificar a intenção.
+// O consumo é retornado sempre que a API responde, inclusive com erro de validação
+func (c *AIClient) ClassifyWithAI(ctx context.Context, intentText string, services map[int]string) (*ClassificationResult, AIUsage, error) {
+	var usage AIUsage
 	if c.apiKey == "" {
-		return nil, fmt.Errorf("OPENROUTER_API_KEY not configured")
+		return nil, usage, fmt.Errorf("OPENROUTER_API_KEY not configured")
 	}
 
 	prompt := c.buildPrompt(intentText, services)
@@ -252,17 +268,18 @@ func (c *AIClient) ClassifyWithAI(ctx context.Context, intentText string, servic
 			},
 		},
 		MaxTokens: 250, // Aumentado para suportar respostas com raciocínio
+		Usage:     &usageOptions{Include: true},
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
+		return nil, usage, fmt.Errorf("marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
 		c.baseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, usage, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -270,26 +287,27 @@ func (c *AIClient) ClassifyWithAI(ctx context.Context, intentText string, servic
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+		return nil, usage, fmt.Errorf("execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, usage, fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, usage, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var openRouterResp openRouterResponse
 	if err := json.Unmarshal(body, &openRouterResp); err != nil {
-		return nil, fmt.Errorf("unmarshal openrouter response: %w", err)
+		return nil, usage, fmt.Errorf("unmarshal openrouter response: %w", err)
 	}
+	usage = openRouterResp.Usage
 
 	if len(openRouterResp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in AI response")
+		return nil, usage, fmt.Errorf("no choices in AI response")
 	}
 
 	content := strings.TrimSpace(openRouterResp.Choices[0].Message.Content)
@@ -317,7 +335,7 @@ func (c *AIClient) ClassifyWithAI(ctx context.Context, intentText string, servic
 	var aiResp aiResponse
 	if err := json.Unmarshal([]byte(content), &aiResp); err != nil {
 		fmt.Printf("AI JSON Parse Error: %v\nRaw Content: %s\n", err, content)
-		return nil, fmt.Errorf("failed to parse AI JSON response: %w", err)
+		return nil, usage, fmt.Errorf("failed to parse AI JSON response: %w", err)
 	}
 
 	// Verificar se a IA conseguiu classificar
@@ -327,18 +345,18 @@ func (c *AIClient) ClassifyWithAI(ctx context.Context, intentText string, servic
 
 		// Retornar ValidationError para indicar que é um problema com o input,
 		// não um erro técnico da IA. Isso impede o fallback para NLP local.
-		return nil, NewValidationError(aiResp.Error)
+		return nil, usage, NewValidationError(aiResp.Error)
 	}
 
 	// Verificar se o ID é válido
 	serviceName, exists := services[aiResp.ServiceID]
 	if !exists {
-		return nil, fmt.Errorf("AI returned invalid service ID: %d", aiResp.ServiceID)
+		return nil, usage, fmt.Errorf("AI returned invalid service ID: %d", aiResp.ServiceID)
 	}
 
 	return &ClassificationResult{
 		ServiceID:   aiResp.ServiceID,
 		ServiceName: serviceName,
 		Confidence:  1.0, // AI não fornece confiança, usamos 1.0
-	}, nil
+	}, usage, nil
 }
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultBatchConcurrency é o número padrão de classificações simultâneas de um lote
+const defaultBatchConcurrency = 4
+
+// ConfigureBatch define as classificações simultâneas por lote e quantos jobs
+// de /api/jobs rodam ao mesmo tempo. Deve ser chamado antes de Start
+func (s *Server) ConfigureBatch(concurrency, maxRunningJobs int) error {
+	if concurrency <= 0 {
+		return fmt.Errorf("batch concurrency must be positive, got %d", concurrency)
+	}
+	if maxRunningJobs <= 0 {
+		return fmt.Errorf("max running jobs must be positive, got %d", maxRunningJobs)
+	}
+
+	s.batchConcurrency = concurrency
+	s.jobs = NewJobManager(s, maxRunningJobs)
+	return nil
+}
+
+// testCaseReader lê os casos de teste um a um, sem carregar o lote inteiro em memória.
+// Next retorna io.EOF ao final
+type testCaseReader interface {
+	Next() (TestCase, error)
+}
+
+// newTestCaseReader lê o corpo como CSV (Content-Type text/csv) no formato
+// service_id;service_name;intent ou como JSON {"test_cases": [...]}
+func newTestCaseReader(r *http.Request) testCaseReader {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		return newCSVTestCaseReader(r.Body)
+	}
+	return &jsonTestCaseReader{decoder: json.NewDecoder(r.Body)}
+}
+
+// readAllTestCases lê todos os casos de teste do reader
+func readAllTestCases(reader testCaseReader) ([]TestCase, error) {
+	var testCases []TestCase
+	for {
+		testCase, err := reader.Next()
+		if err == io.EOF {
+			return testCases, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		testCases = append(testCases, testCase)
+	}
+}
+
+// sliceTestCaseReader lê casos de teste já carregados
+type sliceTestCaseReader struct {
+	testCases []TestCase
+	next      int
+}
+
+func (r *sliceTestCaseReader) Next() (TestCase, error) {
+	if r.next >= len(r.testCases) {
+		return TestCase{}, io.EOF
+	}
+	r.next++
+	return r.testCases[r.next-1], nil
+}
+
+// jsonTestCaseReader decodifica um elemento de test_cases por vez
+type jsonTestCaseReader struct {
+	decoder *json.Decoder
+	inArray bool
+}
+
+func (r *jsonTestCaseReader) Next() (TestCase, error) {
+	if !r.inArray {
+		if err := r.findTestCases(); err != nil {
+			return TestCase{}, err
+		}
+		r.inArray = true
+	}
+
+	if !r.decoder.More() {
+		return TestCase{}, io.EOF
+	}
+
+	var testCase TestCase
+	if err := r.decoder.Decode(&testCase); err != nil {
+		return TestCase{}, fmt.Errorf("invalid request body: %w", err)
+	}
+	return testCase, nil
+}
+
+// findTestCases avança o decoder até o início do array test_cases,
+// ignorando as demais chaves do objeto
+func (r *jsonTestCaseReader) findTestCases() error {
+	if err := r.expectDelim('{'); err != nil {
+		return err
+	}
+
+	for r.decoder.More() {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid request body: %w", err)
+		}
+
+		if key, _ := token.(string); key == "test_cases" {
+			return r.expectDelim('[')
+		}
+
+		var skip json.RawMessage
+		if err := r.decoder.Decode(&skip); err != nil {
+			return fmt.Errorf("invalid request body: %w", err)
+		}
+	}
+
+	return io.EOF
+}
+
+func (r *jsonTestCaseReader) expectDelim(delim json.Delim) error {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("invalid request body: expected %q", delim)
+	}
+	return nil
+}
+
+// csvTestCaseReader lê o CSV de intenções linha a linha; o service_id é o
+// serviço esperado e pode ficar vazio quando desconhecido
+type csvTestCaseReader struct {
+	reader *csv.Reader
+	header bool
+}
+
+func newCSVTestCaseReader(r io.Reader) *csvTestCaseReader {
+	reader := csv.NewReader(r)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &csvTestCaseReader{reader: reader}
+}
+
+func (r *csvTestCaseReader) Next() (TestCase, error) {
+	for {
+		record, err := r.reader.Read()
+		if err == io.EOF {
+			return TestCase{}, io.EOF
+		}
+		if err != nil {
+			return TestCase{}, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		// Pular o cabeçalho e linhas inválidas
+		if !r.header {
+			r.header = true
+			continue
+		}
+		if len(record) < 3 {
+			continue
+		}
+
+		testCase := TestCase{Intent: record[2]}
+		if id := strings.TrimSpace(record[0]); id != "" {
+			line, _ := r.reader.FieldPos(0)
+			if testCase.ExpectedServiceID, err = strconv.Atoi(id); err != nil {
+				return TestCase{}, fmt.Errorf("invalid service_id at line %d: %w", line, err)
+			}
+		}
+		return testCase, nil
+	}
+}
+
+// batchItem é um caso de teste com sua posição no lote
+type batchItem struct {
+	index    int
+	testCase TestCase
+}
+
+// batchOutcome é a classificação de um caso de teste do lote
+type batchOutcome struct {
+	batchItem
+	classification classificationResult
+}
+
+// runBatch classifica os casos de teste com até concurrency classificações simultâneas.
+// emit é chamado sempre na mesma goroutine, na ordem em que as classificações terminam.
+// Com o contexto cancelado, nenhum caso novo é iniciado e as chamadas à IA em curso são canceladas
+func (s *Server) runBatch(ctx context.Context, reader testCaseReader, concurrency int, emit func(batchOutcome)) error {
+	items := make(chan batchItem)
+	outcomes := make(chan batchOutcome)
+
+	// Leitor: distribui os casos de teste para os workers
+	var readErr error
+	go func() {
+		defer close(items)
+		for index := 0; ; index++ {
+			testCase, err := reader.Next()
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+
+			select {
+			case items <- batchItem{index: index, testCase: testCase}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Workers: classificam os casos de teste
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if ctx.Err() != nil {
+					continue // Descartar os casos restantes
+				}
+				outcomes <- batchOutcome{
+					batchItem:      item,
+					classification: s.classifyParallel(ctx, item.testCase.Intent),
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	for outcome := range outcomes {
+		emit(outcome)
+	}
+
+	// Leitor e workers já terminaram
+	if readErr != nil {
+		return readErr
+	}
+	return ctx.Err()
+}
+
+// batchAccumulator calcula o resultado de cada caso de teste e as estatísticas do lote
+type batchAccumulator struct {
+	serviceMap      map[int]string
+	stats           TestBatchStats
+	totalConfidence float64
+}
+
+func newBatchAccumulator(serviceMap map[int]string) *batchAccumulator {
+	return &batchAccumulator{
+		serviceMap: serviceMap,
+		stats: TestBatchStats{
+			ByService: make(map[int]*ServiceTestStats),
+		},
+	}
+}
+
+// add registra a classificação de um caso de teste e retorna o seu resultado
+func (a *batchAccumulator) add(outcome batchOutcome) APITestResult {
+	testCase, classification := outcome.testCase, outcome.classification
+	stats := &a.stats
+
+	stats.Cascade.Record(classification)
+
+	// Atualizar contadores de uso
+	if classification.usedAI {
+		stats.AIUsageCount++
+	} else {
+		stats.LocalUsageCount++
+	}
+
+	// Criar resultado
+	result := APITestResult{
+		Index:         outcome.index,
+		Intent:        testCase.Intent,
+		PredictedID:   classification.serviceID,
+		PredictedName: classification.serviceName,
+		Confidence:    classification.confidence,
+		UsedAI:        classification.usedAI,
+	}
+
+	// Verificar se houve erro de validação
+	if classification.err != nil {
+		// Input foi rejeitado pela validação
+		result.PredictedID = 0
+		result.PredictedName = "REJECTED: " + classification.err.Error()
+		result.Confidence = 0.0
+
+		// Se esperávamos rejeição (expected_service_id == 0), considerar correto
+		if testCase.ExpectedServiceID == 0 {
+			result.IsCorrect = true
+			stats.CorrectPredictions++
+			if classification.usedAI {
+				stats.AICorrectPredictions++
+			} else {
+				stats.LocalCorrectPredictions++
+			}
+		} else {
+			// Esperávamos classificação mas foi rejeitado
+			result.ExpectedServiceID = testCase.ExpectedServiceID
+			result.IsCorrect = false
+		}
+	} else if testCase.ExpectedServiceID > 0 {
+		// Classificação normal com o esperado fornecido: calcular se está correto
+		result.ExpectedServiceID = testCase.ExpectedServiceID
+		result.IsCorrect = classification.serviceID == testCase.ExpectedServiceID
+
+		// Atualizar estatísticas por serviço
+		if _, exists := stats.ByService[testCase.ExpectedServiceID]; !exists {
+			stats.ByService[testCase.ExpectedServiceID] = &ServiceTestStats{
+				ServiceID:   testCase.ExpectedServiceID,
+				ServiceName: a.serviceMap[testCase.ExpectedServiceID],
+			}
+		}
+
+		serviceStats := stats.ByService[testCase.ExpectedServiceID]
+		serviceStats.TotalTests++
+		serviceStats.AverageConfidence += classification.confidence
+
+		if result.IsCorrect {
+			serviceStats.CorrectPredictions++
+			stats.CorrectPredictions++
+
+			// Atualizar métricas por método
+			if classification.usedAI {
+				stats.AICorrectPredictions++
+			} else {
+				stats.LocalCorrectPredictions++
+			}
+		}
+	}
+
+	// Atualizar estatísticas gerais
+	stats.TotalTests++
+	a.totalConfidence += classification.confidence
+
+	// Categorizar por confiança
+	if classification.confidence >= 0.8 {
+		stats.HighConfidence++
+	} else if classification.confidence >= 0.5 {
+		stats.MediumConfidence++
+	} else {
+		stats.LowConfidence++
+	}
+
+	return result
+}
+
+// finish calcula as taxas finais e retorna as estatísticas do lote
+func (a *batchAccumulator) finish() TestBatchStats {
+	stats := a.stats
+	stats.Cascade = stats.Cascade.clone()
+
+	stats.ByService = make(map[int]*ServiceTestStats, len(a.stats.ByService))
+	for id, serviceStats := range a.stats.ByService {
+		copied := *serviceStats
+		stats.ByService[id] = &copied
+	}
+
+	if stats.TotalTests > 0 {
+		stats.AverageConfidence = a.totalConfidence / float64(stats.TotalTests)
+
+		// Calcular percentuais de uso
+		stats.AIUsagePercentage = float64(stats.AIUsageCount) / float64(stats.TotalTests) * 100
+		stats.LocalUsagePercentage = float64(stats.LocalUsageCount) / float64(stats.TotalTests) * 100
+
+		stats.IncorrectPredictions = stats.TotalTests - stats.CorrectPredictions
+		stats.AccuracyRate = float64(stats.CorrectPredictions) / float64(stats.TotalTests) * 100
+
+		// Calcular taxas de acerto por método
+		if stats.AIUsageCount > 0 {
+			stats.AIAccuracyRate = float64(stats.AICorrectPredictions) / float64(stats.AIUsageCount) * 100
+		}
+		if stats.LocalUsageCount > 0 {
+			stats.LocalAccuracyRate = float64(stats.LocalCorrectPredictions) / float64(stats.LocalUsageCount) * 100
+		}
+	}
+
+	// Calcular taxas por serviço
+	for _, serviceStats := range stats.ByService {
+		if serviceStats.TotalTests > 0 {
+			serviceStats.AccuracyRate = float64(serviceStats.CorrectPredictions) / float64(serviceStats.TotalTests) * 100
+			serviceStats.AverageConfidence = serviceStats.AverageConfidence / float64(serviceStats.TotalTests)
+		}
+	}
+
+	return stats
+}
+
+// wantsNDJSON indica se o cliente pediu a resposta em streaming (?stream=true ou Accept: application/x-ndjson)
+func wantsNDJSON(r *http.Request) bool {
+	if stream, err := strconv.ParseBool(r.URL.Query().Get("stream")); err == nil {
+		return stream
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+}
+
+// streamTestBatch escreve cada resultado numa linha NDJSON assim que a
+// classificação termina, e as estatísticas na última linha
+func (s *Server) streamTestBatch(w http.ResponseWriter, r *http.Request, reader testCaseReader) {
+	// O corpo continua sendo lido enquanto os resultados são escritos
+	controller := http.NewResponseController(w)
+	controller.EnableFullDuplex()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	accumulator := newBatchAccumulator(s.serviceMap)
+
+	err := s.runBatch(r.Context(), reader, s.batchConcurrency, func(outcome batchOutcome) {
+		encoder.Encode(accumulator.add(outcome))
+		controller.Flush()
+	})
+
+	summary := TestBatchStreamSummary{}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		summary.Error = err.Error()
+	}
+
+	stats := accumulator.finish()
+	summary.Statistics = &stats
+	encoder.Encode(summary)
+
+	log.Printf("Streamed test batch completed - Total: %d, Accuracy: %.2f%%, AI Usage: %.1f%%, AI calls: %d, AI cost: $%.4f",
+		stats.TotalTests, stats.AccuracyRate, stats.AIUsagePercentage, stats.Cascade.AICalls, stats.Cascade.AICost)
+}
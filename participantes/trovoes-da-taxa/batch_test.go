@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTestCaseReaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []TestCase
+		wantErr     string
+	}{
+		{
+			name:        "json ignores the other keys",
+			contentType: "application/json",
+			body:        `{"name": "lote", "options": {"x": [1, 2]}, "test_cases": [{"intent": "saldo", "expected_service_id": 12}, {"intent": "oi"}]}`,
+			want:        []TestCase{{Intent: "saldo", ExpectedServiceID: 12}, {Intent: "oi"}},
+		},
+		{
+			name:        "json without test_cases",
+			contentType: "application/json",
+			body:        `{"name": "lote"}`,
+		},
+		{
+			name:        "json that is not an object",
+			contentType: "application/json",
+			body:        `[{"intent": "saldo"}]`,
+			wantErr:     "invalid request body",
+		},
+		{
+			name:        "json with a malformed test case",
+			contentType: "application/json",
+			body:        `{"test_cases": [{"intent": 3}]}`,
+			wantErr:     "invalid request body",
+		},
+		{
+			name:        "csv skips the header and short rows",
+			contentType: "text/csv; charset=utf-8",
+			body:        "service_id;service_name;intent\n3;Segunda via de Fatura;fatura\nlinha sem campos\n12;Consulta do Saldo;saldo\n",
+			want:        []TestCase{{Intent: "fatura", ExpectedServiceID: 3}, {Intent: "saldo", ExpectedServiceID: 12}},
+		},
+		{
+			name:        "csv with an empty service_id",
+			contentType: "text/csv",
+			body:        "service_id;service_name;intent\n;;intent sem rótulo\n",
+			want:        []TestCase{{Intent: "intent sem rótulo"}},
+		},
+		{
+			name:        "csv with a bad service_id",
+			contentType: "text/csv",
+			body:        "service_id;service_name;intent\n3;Segunda via de Fatura;fatura\ntrês;Segunda via de Fatura;fatura\n",
+			wantErr:     "invalid service_id at line 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/test-batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			got, err := readAllTestCases(newTestCaseReader(req))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readAllTestCases() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readAllTestCases() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readAllTestCases() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// CascadeMode define quando classifyParallel consulta a IA
+type CascadeMode string
+
+const (
+	CascadeLocal    CascadeMode = "local"    // Só NLP local, a IA nunca é chamada
+	CascadeFallback CascadeMode = "fallback" // IA só quando o resultado local é inseguro
+	CascadeHedged   CascadeMode = "hedged"   // IA também quando o local não decide em HedgeAfter
+	CascadeRace     CascadeMode = "race"     // Local e IA em paralelo desde o início
+)
+
+// Caminhos de decisão registrados nas estatísticas da cascata
+const (
+	pathLocal         = "local"          // Resultado local seguro (ou modo local)
+	pathAI            = "ai"             // Resultado da IA
+	pathLocalFallback = "local_fallback" // IA falhou ou expirou, resultado local inseguro
+	pathAIRejected    = "ai_rejected"    // IA rejeitou o input
+	pathLocalRejected = "local_rejected" // Modo local sem nenhum serviço parecido
+)
+
+// CascadePolicy configura a cascata entre NLP local e IA
+type CascadePolicy struct {
+	Mode       CascadeMode
+	HedgeAfter time.Duration // Modo hedged: espera pelo local antes de chamar a IA
+	AITimeout  time.Duration // Tempo máximo da chamada à IA
+}
+
+// DefaultCascadePolicy retorna a política padrão: IA só para resultados locais inseguros
+func DefaultCascadePolicy() CascadePolicy {
+	return CascadePolicy{
+		Mode:       CascadeFallback,
+		HedgeAfter: 50 * time.Millisecond,
+		AITimeout:  25 * time.Second,
+	}
+}
+
+// Validate verifica o modo e as durações da política
+func (p CascadePolicy) Validate() error {
+	switch p.Mode {
+	case CascadeLocal, CascadeFallback, CascadeHedged, CascadeRace:
+	default:
+		return fmt.Errorf("unknown cascade mode %q (expected local, fallback, hedged or race)", p.Mode)
+	}
+
+	if p.HedgeAfter < 0 {
+		return fmt.Errorf("hedge delay must not be negative, got %v", p.HedgeAfter)
+	}
+	if p.AITimeout <= 0 {
+		return fmt.Errorf("AI timeout must be positive, got %v", p.AITimeout)
+	}
+	return nil
+}
+
+// CascadeStats acumula as decisões da cascata: caminho, custo e latência
+type CascadeStats struct {
+	Decisions   int                          `json:"decisions"`
+	AICalls     int                          `json:"ai_calls"`     // Chamadas à IA iniciadas, inclusive descartadas
+	AICancelled int                          `json:"ai_cancelled"` // Chamadas à IA canceladas sem resposta
+	AICost      float64                      `json:"ai_cost"`      // Custo informado pela OpenRouter (USD)
+	ByPath      map[string]*CascadePathStats `json:"by_path"`
+}
+
+// CascadePathStats representa as decisões de um caminho da cascata
+type CascadePathStats struct {
+	Count            int     `json:"count"`
+	AICost           float64 `json:"ai_cost"`
+	AverageLatencyMs float64 `json:"average_latency_ms"`
+}
+
+// Record adiciona uma decisão às estatísticas
+func (s *CascadeStats) Record(result classificationResult) {
+	if s.ByPath == nil {
+		s.ByPath = make(map[string]*CascadePathStats)
+	}
+
+	s.Decisions++
+	if result.aiCalled {
+		s.AICalls++
+	}
+	if result.aiCancelled {
+		s.AICancelled++
+	}
+	s.AICost += result.cost
+
+	path, ok := s.ByPath[result.path]
+	if !ok {
+		path = &CascadePathStats{}
+		s.ByPath[result.path] = path
+	}
+
+	path.Count++
+	path.AICost += result.cost
+	latencyMs := float64(result.latency.Microseconds()) / 1000
+	path.AverageLatencyMs += (latencyMs - path.AverageLatencyMs) / float64(path.Count)
+}
+
+// clone retorna uma cópia que pode ser serializada fora do lock
+func (s CascadeStats) clone() CascadeStats {
+	byPath := make(map[string]*CascadePathStats, len(s.ByPath))
+	for name, path := range s.ByPath {
+		copied := *path
+		byPath[name] = &copied
+	}
+	s.ByPath = byPath
+	return s
+}
+
+// SetCascadePolicy define a política da cascata. Deve ser chamado antes de
+// Start: a política é lida sem lock a cada classificação
+func (s *Server) SetCascadePolicy(policy CascadePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	s.cascade = policy
+	return nil
+}
+
+// classifyParallel classifica a intenção seguindo a política da cascata e
+// registra a decisão nas estatísticas do servidor
+func (s *Server) classifyParallel(ctx context.Context, intentText string) classificationResult {
+	start := time.Now()
+
+	result := s.runCascade(ctx, intentText)
+	result.latency = time.Since(start)
+
+	s.statsMu.Lock()
+	s.stats.Record(result)
+	s.statsMu.Unlock()
+
+	return result
+}
+
+// runCascade executa NLP local e, conforme a política, a IA em goroutines.
+// A chamada à IA é cancelada pelo contexto assim que o resultado local seguro é escolhido
+func (s *Server) runCascade(ctx context.Context, intentText string) classificationResult {
+	policy := s.cascade
+
+	// Goroutine 1: Classificação NLP local (geralmente mais rápida)
+	localChan := make(chan classificationResult, 1)
+	go func() {
+		// Erro local (modelo não treinado) equivale a um resultado inseguro
+		serviceID, serviceName, confidence, safe, _ := s.knnService.ClassifyWithSafetyCheck(intentText)
+		localChan <- classificationResult{
+			serviceID:   serviceID,
+			serviceName: serviceName,
+			confidence:  confidence,
+			safe:        safe,
+		}
+	}()
+
+	if policy.Mode == CascadeLocal {
+		localResult := <-localChan
+		localResult.path = pathLocal
+		if localResult.serviceID == 0 {
+			localResult.path = pathLocalRejected
+			localResult.err = NewValidationError("intent not recognized")
+		}
+		return localResult
+	}
+
+	aiCtx, cancelAI := context.WithTimeout(ctx, policy.AITimeout)
+	defer cancelAI()
+
+	// Goroutine 2: Classificação com IA, iniciada conforme a política.
+	// Enquanto não iniciada, aiChan é nil e nunca é selecionado
+	var aiChan chan classificationResult
+	var aiAnswered bool
+	startAI := func(reason string) {
+		if aiChan != nil {
+			return
+		}
+		log.Printf("Calling AI (%s) - Intent: %q", reason, intentText)
+
+		aiChan = make(chan classificationResult, 1)
+		go func(aiChan chan<- classificationResult) {
+			aiResponse, usage, err := s.aiClient.ClassifyWithAI(aiCtx, intentText, s.serviceMap)
+			if err != nil {
+				aiChan <- classificationResult{cost: usage.Cost, err: err}
+				return
+			}
+			aiChan <- classificationResult{
+				serviceID:   aiResponse.ServiceID,
+				serviceName: aiResponse.ServiceName,
+				confidence:  aiResponse.Confidence,
+				usedAI:      true,
+				cost:        usage.Cost,
+			}
+		}(aiChan)
+	}
+
+	var hedge <-chan time.Time
+	switch policy.Mode {
+	case CascadeRace:
+		startAI("race")
+	case CascadeHedged:
+		timer := time.NewTimer(policy.HedgeAfter)
+		defer timer.Stop()
+		hedge = timer.C
+	}
+
+	// decide completa o resultado com o caminho e o estado da chamada à IA
+	decide := func(result classificationResult, path string) classificationResult {
+		result.path = path
+		result.aiCalled = aiChan != nil
+		if aiChan != nil && !aiAnswered {
+			// A IA ainda não respondeu: cancelar para não pagar por um resultado descartado
+			cancelAI()
+			result.aiCancelled = true
+		}
+		return result
+	}
+
+	// fallback usa o resultado local, esperando por ele se necessário
+	var localResult classificationResult
+	var hasLocalResult bool
+	fallback := func(reason string, aiResult classificationResult) classificationResult {
+		if !hasLocalResult {
+			log.Printf("%s, waiting for LOCAL...", reason)
+			localResult = <-localChan
+		} else {
+			log.Printf("%s, using LOCAL fallback", reason)
+		}
+		localResult.cost = aiResult.cost
+		if localResult.safe {
+			return decide(localResult, pathLocal)
+		}
+		return decide(localResult, pathLocalFallback)
+	}
+
+	for {
+		select {
+		case localResult = <-localChan:
+			hasLocalResult = true
+			localChan = nil
+
+			// Verificar se o resultado local é confiável (confiança e margem de ambiguidade)
+			if localResult.safe {
+				log.Printf("Using LOCAL - Intent: %q, Confidence: %.4f", intentText, localResult.confidence)
+				return decide(localResult, pathLocal)
+			}
+
+			// Resultado inseguro, a IA decide
+			log.Printf("UNSAFE LOCAL (%.4f), waiting for AI...", localResult.confidence)
+			startAI("unsafe local")
+
+		case <-hedge:
+			hedge = nil
+			startAI(fmt.Sprintf("no local decision after %v", policy.HedgeAfter))
+
+		case aiResult := <-aiChan:
+			aiAnswered = true
+			if aiResult.err != nil {
+				// Erro de validação: input é inválido
+				// NÃO fazer fallback para NLP local, propagar o erro
+				if IsValidationError(aiResult.err) {
+					log.Printf("AI Validation Error: %v - Input rejected", aiResult.err)
+					aiResult.usedAI = true
+					return decide(aiResult, pathAIRejected)
+				}
+
+				// Erro técnico (API, parse): usar o NLP local
+				return fallback(fmt.Sprintf("AI technical error: %v", aiResult.err), aiResult)
+			}
+
+			log.Printf("Using AI - Intent: %q", intentText)
+			if hasLocalResult {
+				aiResult.confidence = localResult.confidence // Preservar confiança do NLP para estatísticas
+			}
+			return decide(aiResult, pathAI)
+
+		case <-aiCtx.Done():
+			// Timeout da IA ou requisição cancelada pelo cliente
+			return fallback(fmt.Sprintf("AI call ended: %v", context.Cause(aiCtx)), classificationResult{})
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubAI is an OpenRouter stand-in that answers every call with content
+// after delay, recording when calls start and how they ended
+type stubAI struct {
+	content   string
+	delay     time.Duration
+	calls     atomic.Int32
+	answered  atomic.Int32
+	cancelled atomic.Int32
+	firstCall atomic.Int64 // UnixNano of the first call
+}
+
+func newStubAIClient(t *testing.T, stub *stubAI) *AIClient {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.firstCall.CompareAndSwap(0, time.Now().UnixNano())
+		stub.calls.Add(1)
+
+		// The server only notices a closed connection once the body is read
+		io.Copy(io.Discard, r.Body)
+
+		select {
+		case <-r.Context().Done():
+			stub.cancelled.Add(1)
+			return
+		case <-time.After(stub.delay):
+		}
+
+		stub.answered.Add(1)
+		encoded, _ := json.Marshal(stub.content)
+		fmt.Fprintf(w, `{"choices": [{"message": {"content": %s}}], "usage": {"prompt_tokens": 900, "completion_tokens": 20, "cost": 0.002}}`, encoded)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &AIClient{baseURL: srv.URL, apiKey: "test", httpClient: srv.Client(), model: "stub"}
+}
+
+func TestRunCascade(t *testing.T) {
+	const (
+		aiService       = `{"success": true, "service_id": 3}`
+		aiRejection     = `{"success": false, "error": "intent incoerente"}`
+		aiMalformed     = `não é JSON`
+		unsafeThreshold = 1.1 // no local result reaches it
+	)
+
+	tests := []struct {
+		name   string
+		policy CascadePolicy
+		// unsafe makes every local result unsafe; blockLocal holds the local
+		// classification until runCascade returns
+		unsafe     bool
+		blockLocal bool
+		aiContent  string
+		aiDelay    time.Duration
+
+		wantPath      string
+		wantServiceID int
+		wantAICalled  bool
+		wantCancelled bool
+		wantRejected  bool
+		// wantHedge is the minimum delay before the AI call
+		wantHedge time.Duration
+	}{
+		{
+			name:          "safe local cancels the racing AI call",
+			policy:        CascadePolicy{Mode: CascadeRace, AITimeout: 5 * time.Second},
+			aiContent:     aiService,
+			aiDelay:       2 * time.Second,
+			wantPath:      pathLocal,
+			wantServiceID: 7,
+			wantAICalled:  true,
+			wantCancelled: true,
+		},
+		{
+			name:          "safe local never calls the AI in fallback mode",
+			policy:        CascadePolicy{Mode: CascadeFallback, AITimeout: 5 * time.Second},
+			aiContent:     aiService,
+			wantPath:      pathLocal,
+			wantServiceID: 7,
+		},
+		{
+			name:          "hedge calls the AI after HedgeAfter",
+			policy:        CascadePolicy{Mode: CascadeHedged, HedgeAfter: 30 * time.Millisecond, AITimeout: 5 * time.Second},
+			blockLocal:    true,
+			aiContent:     aiService,
+			wantPath:      pathAI,
+			wantServiceID: 3,
+			wantAICalled:  true,
+			wantHedge:     30 * time.Millisecond,
+		},
+		{
+			name:          "AI timeout falls back to the unsafe local result",
+			policy:        CascadePolicy{Mode: CascadeFallback, AITimeout: 50 * time.Millisecond},
+			unsafe:        true,
+			aiContent:     aiService,
+			aiDelay:       2 * time.Second,
+			wantPath:      pathLocalFallback,
+			wantServiceID: 7,
+			wantAICalled:  true,
+			wantCancelled: true,
+		},
+		{
+			name:          "AI technical error falls back to local",
+			policy:        CascadePolicy{Mode: CascadeFallback, AITimeout: 5 * time.Second},
+			unsafe:        true,
+			aiContent:     aiMalformed,
+			wantPath:      pathLocalFallback,
+			wantServiceID: 7,
+			wantAICalled:  true,
+		},
+		{
+			name:         "AI validation error is not downgraded to local",
+			policy:       CascadePolicy{Mode: CascadeFallback, AITimeout: 5 * time.Second},
+			unsafe:       true,
+			aiContent:    aiRejection,
+			wantPath:     pathAIRejected,
+			wantAICalled: true,
+			wantRejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knnService := newTestKNNService(t)
+			if tt.unsafe {
+				config := knnService.Config()
+				config.ConfidenceThreshold = unsafeThreshold
+				if err := knnService.SetConfig(config); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stub := &stubAI{content: tt.aiContent, delay: tt.aiDelay}
+			server := NewServer(knnService, newStubAIClient(t, stub), testIntents)
+			if err := server.SetCascadePolicy(tt.policy); err != nil {
+				t.Fatalf("SetCascadePolicy() error = %v", err)
+			}
+
+			if tt.blockLocal {
+				knnService.mu.Lock()
+			}
+
+			start := time.Now()
+			result := server.runCascade(context.Background(), "quero cancelar meu cartão")
+
+			if tt.blockLocal {
+				knnService.mu.Unlock()
+			}
+
+			if result.path != tt.wantPath || result.serviceID != tt.wantServiceID {
+				t.Errorf("runCascade() = path %q service %d, want path %q service %d (err %v)",
+					result.path, result.serviceID, tt.wantPath, tt.wantServiceID, result.err)
+			}
+			if result.aiCalled != tt.wantAICalled || result.aiCancelled != tt.wantCancelled {
+				t.Errorf("aiCalled = %v, aiCancelled = %v, want %v, %v",
+					result.aiCalled, result.aiCancelled, tt.wantAICalled, tt.wantCancelled)
+			}
+			if IsValidationError(result.err) != tt.wantRejected {
+				t.Errorf("err = %v, want validation error %v", result.err, tt.wantRejected)
+			}
+
+			if tt.wantHedge > 0 {
+				if waited := time.Duration(stub.firstCall.Load() - start.UnixNano()); waited < tt.wantHedge {
+					t.Errorf("AI called after %v, want at least %v", waited, tt.wantHedge)
+				}
+			}
+
+			if tt.wantCancelled {
+				// The stub notices the cancelled request asynchronously, and a
+				// request cancelled before being sent never reaches it
+				deadline := time.Now().Add(time.Second)
+				for stub.cancelled.Load() != stub.calls.Load() && time.Now().Before(deadline) {
+					time.Sleep(5 * time.Millisecond)
+				}
+				if stub.answered.Load() != 0 || stub.cancelled.Load() != stub.calls.Load() {
+					t.Errorf("AI calls = %d, answered = %d, want every call cancelled",
+						stub.calls.Load(), stub.answered.Load())
+				}
+			}
+			if !tt.wantAICalled && stub.calls.Load() != 0 {
+				t.Errorf("AI calls = %d, want none", stub.calls.Load())
+			}
+		})
+	}
+}
+
+func TestClassifyParallelRecordsStats(t *testing.T) {
+	stub := &stubAI{content: `{"success": true, "service_id": 12}`}
+	server := NewServer(newTestKNNService(t), newStubAIClient(t, stub), testIntents)
+
+	server.classifyParallel(context.Background(), "quero cancelar meu cartão")
+	server.classifyParallel(context.Background(), "xyz")
+
+	stats := server.stats.clone()
+	if stats.Decisions != 2 || stats.ByPath[pathLocal].Count != 1 {
+		t.Errorf("stats = %+v, want 2 decisions, 1 local", stats)
+	}
+	if ai := stats.ByPath[pathAI]; ai == nil || ai.Count != 1 || ai.AICost != 0.002 {
+		t.Errorf("ai path = %+v, want 1 decision costing 0.002", ai)
+	}
+}
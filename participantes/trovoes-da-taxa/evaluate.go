@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// vectorizerEvaluation é o desempenho de uma vetorização no CSV de validação
+type vectorizerEvaluation struct {
+	name           string
+	vocabulary     int
+	top1, top3     float64
+	typoTop1       float64
+	typoTop3       float64
+	avgMicrosecond float64
+}
+
+// runEvaluate treina cada vetorização no CSV de treino e mede a taxa de acerto
+// no CSV de validação, original e com erros de digitação simulados
+func runEvaluate(args []string) {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	csvPath := fs.String("csv", defaultCSVPath(), "CSV de intenções usado no treino")
+	holdoutPath := fs.String("holdout", filepath.Join("..", "..", "assets", "extra_intents.csv"), "CSV de validação, fora do treino")
+	stemmer := fs.String("stemmer", os.Getenv("NLP_STEMMER"), "Stemmer do pré-processamento: rslp, simple ou none")
+	vectorizers := fs.String("vectorizers", "tfidf,char,bm25,hybrid", "Vetorizações comparadas (lista separada por vírgula)")
+	typoRate := fs.Float64("typo-rate", 0.1, "Probabilidade de erro de digitação por caractere na validação com ruído")
+	seed := fs.Int64("seed", 42, "Semente dos erros de digitação")
+	fs.Parse(args)
+
+	intents, err := loadIntentsFromCSV(*csvPath)
+	if err != nil {
+		log.Fatalf("Failed to load intents: %v", err)
+	}
+
+	holdout, err := loadIntentsFromCSV(*holdoutPath)
+	if err != nil {
+		log.Fatalf("Failed to load holdout: %v", err)
+	}
+
+	// Os mesmos erros de digitação para todas as vetorizações
+	rng := rand.New(rand.NewSource(*seed))
+	noisy := make([]Intent, len(holdout))
+	for i, intent := range holdout {
+		noisy[i] = intent
+		noisy[i].IntentText = addTypos(rng, intent.IntentText, *typoRate)
+	}
+
+	var results []vectorizerEvaluation
+	for _, name := range strings.Split(*vectorizers, ",") {
+		name = strings.TrimSpace(name)
+
+		knnService, err := NewKNNService(*stemmer, name)
+		if err != nil {
+			log.Fatalf("Failed to create KNN service: %v", err)
+		}
+
+		if err := knnService.LoadIntents(intents); err != nil {
+			log.Fatalf("Failed to train pipeline with %s: %v", name, err)
+		}
+
+		result := vectorizerEvaluation{name: name, vocabulary: knnService.VocabularySize()}
+
+		start := time.Now()
+		result.top1, result.top3 = evaluateAccuracy(knnService, holdout)
+		result.avgMicrosecond = float64(time.Since(start).Microseconds()) / float64(len(holdout))
+
+		result.typoTop1, result.typoTop3 = evaluateAccuracy(knnService, noisy)
+
+		results = append(results, result)
+	}
+
+	fmt.Printf("Train: %s (%d intents), holdout: %s (%d intents), typo rate: %.0f%%\n\n",
+		*csvPath, len(intents), *holdoutPath, len(holdout), *typoRate*100)
+	fmt.Printf("%-8s %8s %8s %8s %10s %10s %10s\n", "VECTOR", "VOCAB", "TOP-1", "TOP-3", "TYPO TOP-1", "TYPO TOP-3", "LATENCY")
+
+	for _, r := range results {
+		fmt.Printf("%-8s %8d %7.1f%% %7.1f%% %9.1f%% %9.1f%% %8.0fµs\n",
+			r.name, r.vocabulary, r.top1*100, r.top3*100, r.typoTop1*100, r.typoTop3*100, r.avgMicrosecond)
+	}
+}
+
+// evaluateAccuracy retorna a fração de intents cujo serviço é o mais votado
+// (top-1) e está entre os três serviços dos vizinhos mais próximos (top-3)
+func evaluateAccuracy(knnService *KNNService, intents []Intent) (top1, top3 float64) {
+	var hits1, hits3 int
+
+	for _, intent := range intents {
+		if knnService.Classify(intent.IntentText).ServiceID == intent.ServiceID {
+			hits1++
+		}
+
+		// Vizinhos suficientes para cobrir três serviços distintos na maioria dos casos
+		var services []int
+		for _, match := range knnService.ClassifyTopK(intent.IntentText, 10) {
+			if !slices.Contains(services, match.ServiceID) {
+				services = append(services, match.ServiceID)
+			}
+		}
+
+		if slices.Contains(services[:min(3, len(services))], intent.ServiceID) {
+			hits3++
+		}
+	}
+
+	total := float64(len(intents))
+	return float64(hits1) / total, float64(hits3) / total
+}
+
+// addTypos simula erros de transcrição: cada letra pode ser apagada,
+// duplicada, trocada com a seguinte ou substituída por uma vizinha no teclado
+func addTypos(rng *rand.Rand, text string, rate float64) string {
+	const neighbours = "qwertyuiopasdfghjklzxcvbnm"
+
+	runes := []rune(text)
+	var out []rune
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == ' ' || rng.Float64() >= rate {
+			out = append(out, r)
+			continue
+		}
+
+		switch rng.Intn(4) {
+		case 0: // apagar
+		case 1: // duplicar
+			out = append(out, r, r)
+		case 2: // trocar com a seguinte
+			if i+1 < len(runes) && runes[i+1] != ' ' {
+				out = append(out, runes[i+1], r)
+				i++
+			} else {
+				out = append(out, r)
+			}
+		default: // substituir por uma letra vizinha no teclado
+			idx := strings.IndexRune(neighbours, r)
+			if idx < 0 {
+				out = append(out, r)
+				break
+			}
+			out = append(out, rune(neighbours[(idx+1)%len(neighbours)]))
+		}
+	}
+
+	return string(out)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxExamplesBodySize limita o corpo das requisições de exemplos (10 MB)
+const maxExamplesBodySize = 10 << 20
+
+// ExampleStore grava os exemplos adicionados em execução num arquivo
+// append-only (JSON Lines), reaplicado no boot
+type ExampleStore struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenExampleStore abre (ou cria) o arquivo de exemplos e retorna os exemplos já gravados.
+// Uma última linha incompleta, de uma escrita interrompida, é descartada do arquivo
+func OpenExampleStore(path string) (*ExampleStore, []Intent, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open examples file: %w", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to read examples file: %w", err)
+	}
+
+	// Tudo após a última quebra de linha é uma escrita incompleta
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		log.Printf("WARNING: discarding incomplete last line of examples file %s", path)
+		if err := file.Truncate(int64(complete)); err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to truncate examples file: %w", err)
+		}
+	}
+
+	var examples []Intent
+	for i, line := range bytes.Split(data[:complete], []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var record ExampleRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("invalid example at line %d: %w", i+1, err)
+		}
+
+		examples = append(examples, Intent{
+			ServiceID:   record.ServiceID,
+			ServiceName: record.ServiceName,
+			IntentText:  record.Intent,
+		})
+	}
+
+	// Novas escritas sempre no fim do arquivo
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to seek examples file: %w", err)
+	}
+
+	return &ExampleStore{file: file}, examples, nil
+}
+
+// Append grava os exemplos numa única escrita e sincroniza o arquivo com o disco
+func (s *ExampleStore) Append(examples []Intent) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	now := time.Now().UTC()
+	for _, example := range examples {
+		record := ExampleRecord{
+			ServiceID:   example.ServiceID,
+			ServiceName: example.ServiceName,
+			Intent:      example.IntentText,
+			AddedAt:     now,
+		}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		// Desfazer a escrita parcial para não corromper as próximas linhas
+		s.file.Truncate(offset)
+		s.file.Seek(offset, io.SeekStart)
+		return err
+	}
+
+	return s.file.Sync()
+}
+
+// Close fecha o arquivo de exemplos
+func (s *ExampleStore) Close() error {
+	return s.file.Close()
+}
+
+// openExamplesFromEnv abre o arquivo de EXAMPLES_PATH, quando definido, e
+// reaplica no modelo os exemplos gravados. Exemplos de serviços que não
+// existem mais no modelo são ignorados
+func openExamplesFromEnv(knnService *KNNService) (*ExampleStore, error) {
+	path := os.Getenv("EXAMPLES_PATH")
+	if path == "" {
+		return nil, nil
+	}
+
+	store, replayed, err := OpenExampleStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make([]Intent, 0, len(replayed))
+	for _, example := range replayed {
+		if _, err := knnService.validateExamples([]Intent{example}); err != nil {
+			log.Printf("WARNING: skipping example %q from %s: %v", example.IntentText, path, err)
+			continue
+		}
+		valid = append(valid, example)
+	}
+
+	if len(valid) > 0 {
+		if _, err := knnService.AddExamples(valid, nil); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("failed to replay examples: %w", err)
+		}
+	}
+
+	log.Printf("Replayed %d examples from %s", len(valid), path)
+	return store, nil
+}
+
+// EnableExamples habilita POST /api/examples e /api/examples/import,
+// autenticados com o token no header Authorization: Bearer <token>.
+// Com store nil, os exemplos ficam apenas em memória
+func (s *Server) EnableExamples(store *ExampleStore, token string) {
+	s.examples = store
+	s.examplesToken = token
+}
+
+// authorizeExamples confere o token e escreve a resposta de erro quando inválido
+func (s *Server) authorizeExamples(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		writeExamplesResponse(w, http.StatusMethodNotAllowed, ExamplesResponse{Error: "method not allowed"})
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.examplesToken)) != 1 {
+		writeExamplesResponse(w, http.StatusUnauthorized, ExamplesResponse{Error: "unauthorized"})
+		return false
+	}
+
+	return true
+}
+
+// examplesHandler responde ao endpoint /api/examples.
+// Aceita um exemplo {"service_id": 1, "intent": "..."} ou {"examples": [...]}
+func (s *Server) examplesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeExamples(w, r) {
+		return
+	}
+
+	var req ExamplesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxExamplesBodySize)).Decode(&req); err != nil {
+		writeExamplesResponse(w, http.StatusBadRequest, ExamplesResponse{Error: "invalid request body"})
+		return
+	}
+
+	if req.Intent != "" || req.ServiceID != 0 {
+		req.Examples = append(req.Examples, req.ExampleRequest)
+	}
+
+	examples := make([]Intent, len(req.Examples))
+	for i, example := range req.Examples {
+		examples[i] = Intent{ServiceID: example.ServiceID, IntentText: example.Intent}
+	}
+
+	s.addExamples(w, examples)
+}
+
+// importExamplesHandler responde ao endpoint /api/examples/import com um CSV
+// no mesmo formato do CSV de intenções (service_id;service_name;intent)
+func (s *Server) importExamplesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeExamples(w, r) {
+		return
+	}
+
+	examples, err := parseIntentsCSV(http.MaxBytesReader(w, r.Body, maxExamplesBodySize))
+	if err != nil {
+		writeExamplesResponse(w, http.StatusBadRequest, ExamplesResponse{Error: err.Error()})
+		return
+	}
+
+	s.addExamples(w, examples)
+}
+
+// addExamples retreina o modelo com os exemplos, gravando-os no arquivo antes da troca
+func (s *Server) addExamples(w http.ResponseWriter, examples []Intent) {
+	start := time.Now()
+
+	var persist func([]Intent) error
+	if s.examples != nil {
+		persist = s.examples.Append
+	}
+
+	added, err := s.knnService.AddExamples(examples, persist)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if IsValidationError(err) {
+			status = http.StatusBadRequest
+		}
+
+		log.Printf("EXAMPLES ERROR - %v", err)
+		writeExamplesResponse(w, status, ExamplesResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("EXAMPLES - Added %d examples, retrained in %v", len(added), time.Since(start))
+
+	writeExamplesResponse(w, http.StatusOK, ExamplesResponse{
+		Success: true,
+		Data: &ExamplesData{
+			Added:          len(added),
+			TotalIntents:   len(s.knnService.Intents()),
+			VocabularySize: s.knnService.VocabularySize(),
+		},
+	})
+}
+
+func writeExamplesResponse(w http.ResponseWriter, status int, response ExamplesResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
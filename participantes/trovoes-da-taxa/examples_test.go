@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testIntents is a small training set with two services per topic, enough to
+// train the pipeline in milliseconds
+var testIntents = []Intent{
+	{ServiceID: 3, ServiceName: "Segunda via de Fatura", IntentText: "quero a segunda via da fatura"},
+	{ServiceID: 3, ServiceName: "Segunda via de Fatura", IntentText: "manda o boleto da fatura do cartão"},
+	{ServiceID: 7, ServiceName: "Cancelamento de cartão", IntentText: "quero cancelar meu cartão"},
+	{ServiceID: 7, ServiceName: "Cancelamento de cartão", IntentText: "cancelamento do cartão de crédito"},
+	{ServiceID: 12, ServiceName: "Consulta do Saldo", IntentText: "qual é o meu saldo"},
+	{ServiceID: 12, ServiceName: "Consulta do Saldo", IntentText: "consultar saldo da conta"},
+}
+
+func newTestKNNService(t *testing.T) *KNNService {
+	t.Helper()
+
+	knnService, err := NewKNNService("rslp", "tfidf")
+	if err != nil {
+		t.Fatalf("NewKNNService() error = %v", err)
+	}
+	if err := knnService.LoadIntents(testIntents); err != nil {
+		t.Fatalf("LoadIntents() error = %v", err)
+	}
+
+	return knnService
+}
+
+func TestExampleStoreReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "examples.jsonl")
+
+	// The last line was cut by a crash in the middle of a write
+	content := `{"service_id":3,"service_name":"Segunda via de Fatura","intent":"fatura atrasada","added_at":"2025-10-01T12:00:00Z"}
+{"service_id":7,"service_name":"Cancelamento de cartão","intent":"encerrar o cartão","added_at":"2025-10-01T12:00:00Z"}
+{"service_id":12,"service_na`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, replayed, err := OpenExampleStore(path)
+	if err != nil {
+		t.Fatalf("OpenExampleStore() error = %v", err)
+	}
+	if len(replayed) != 2 || replayed[1].ServiceID != 7 || replayed[1].IntentText != "encerrar o cartão" {
+		t.Fatalf("replayed = %+v, want the 2 complete lines", replayed)
+	}
+
+	if err := store.Append([]Intent{{ServiceID: 12, ServiceName: "Consulta do Saldo", IntentText: "ver o saldo"}}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	store.Close()
+
+	// The new line follows the complete ones, not the discarded fragment
+	store, replayed, err = OpenExampleStore(path)
+	if err != nil {
+		t.Fatalf("OpenExampleStore() after Append error = %v", err)
+	}
+	defer store.Close()
+
+	if len(replayed) != 3 || replayed[2].IntentText != "ver o saldo" {
+		t.Errorf("replayed = %+v, want the appended example last", replayed)
+	}
+}
+
+func TestOpenExamplesFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "examples.jsonl")
+	content := `{"service_id":3,"service_name":"Segunda via de Fatura","intent":"fatura atrasada"}
+{"service_id":99,"service_name":"Removido","intent":"serviço que saiu do modelo"}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EXAMPLES_PATH", path)
+
+	knnService := newTestKNNService(t)
+	store, err := openExamplesFromEnv(knnService)
+	if err != nil {
+		t.Fatalf("openExamplesFromEnv() error = %v", err)
+	}
+	defer store.Close()
+
+	// The example of an unknown service is skipped, not fatal
+	if got := len(knnService.Intents()); got != len(testIntents)+1 {
+		t.Errorf("intents = %d, want %d", got, len(testIntents)+1)
+	}
+}
+
+func TestAuthorizeExamples(t *testing.T) {
+	server := &Server{examplesToken: "secret"}
+
+	tests := []struct {
+		name       string
+		method     string
+		auth       string
+		want       bool
+		wantStatus int
+	}{
+		{name: "valid token", method: http.MethodPost, auth: "Bearer secret", want: true},
+		{name: "wrong method", method: http.MethodGet, auth: "Bearer secret", wantStatus: http.StatusMethodNotAllowed},
+		{name: "missing header", method: http.MethodPost, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodPost, auth: "Bearer secreto", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", method: http.MethodPost, auth: "secret", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/examples", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+
+			if got := server.authorizeExamples(rec, req); got != tt.want {
+				t.Fatalf("authorizeExamples() = %v, want %v", got, tt.want)
+			}
+			if !tt.want && rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestImportExamplesHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantAdded  int
+	}{
+		{
+			name:       "csv with header",
+			body:       "service_id;service_name;intent\n3;Segunda via de Fatura;fatura do mês\n12;Consulta do Saldo;quanto tenho na conta\n",
+			wantStatus: http.StatusOK,
+			wantAdded:  2,
+		},
+		{
+			name:       "unknown service",
+			body:       "service_id;service_name;intent\n42;Outro;não existe\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid service_id",
+			body:       "service_id;service_name;intent\ntrês;Segunda via de Fatura;fatura\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "header only",
+			body:       "service_id;service_name;intent\n",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _, err := OpenExampleStore(filepath.Join(t.TempDir(), "examples.jsonl"))
+			if err != nil {
+				t.Fatalf("OpenExampleStore() error = %v", err)
+			}
+			defer store.Close()
+
+			server := NewServer(newTestKNNService(t), nil, testIntents)
+			server.EnableExamples(store, "secret")
+
+			req := httptest.NewRequest(http.MethodPost, "/api/examples/import", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			server.importExamplesHandler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			var response ExamplesResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+
+			added := 0
+			if response.Data != nil {
+				added = response.Data.Added
+			}
+			if added != tt.wantAdded {
+				t.Errorf("added = %d, want %d", added, tt.wantAdded)
+			}
+
+			// Rejected imports leave the model and the file untouched
+			if got := len(server.knnService.Intents()); got != len(testIntents)+tt.wantAdded {
+				t.Errorf("intents = %d, want %d", got, len(testIntents)+tt.wantAdded)
+			}
+			if info, _ := store.file.Stat(); (info.Size() > 0) != (tt.wantAdded > 0) {
+				t.Errorf("examples file size = %d, want examples only when added", info.Size())
+			}
+		})
+	}
+}
+
+func TestAddExamplesRecalibrates(t *testing.T) {
+	knnService := newTestKNNService(t)
+
+	holdout := []Intent{
+		{ServiceID: 3, IntentText: "segunda via da fatura do cartão"},
+		{ServiceID: 7, IntentText: "cancelar o cartão"},
+		{ServiceID: 12, IntentText: "saldo da conta"},
+		{ServiceID: 3, IntentText: "saldo da fatura"},
+	}
+	if err := knnService.Calibrate(holdout, "isotonic"); err != nil {
+		t.Fatalf("Calibrate() error = %v", err)
+	}
+	before := knnService.calibrator
+
+	if _, err := knnService.AddExamples([]Intent{{ServiceID: 12, IntentText: "quanto tenho disponível"}}, nil); err != nil {
+		t.Fatalf("AddExamples() error = %v", err)
+	}
+
+	// The new pipeline scores differently, so the old fit would be stale
+	if knnService.calibrator == nil || knnService.calibrator == before {
+		t.Error("calibrator was not refitted on the retrained pipeline")
+	}
+}
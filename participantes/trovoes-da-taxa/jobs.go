@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxRetainedJobs limita quantos jobs ficam em memória; os finalizados mais
+// antigos são descartados primeiro
+const maxRetainedJobs = 100
+
+// maxJobBodySize limita o corpo de POST /api/jobs (512 KB, uns 5 mil casos de
+// teste): os casos ficam em memória enquanto o job é retido, e 100 jobs no
+// limite ainda cabem nos 128 MB do contêiner
+const maxJobBodySize = 512 << 10
+
+// ErrTooManyJobs é retornado por Submit quando o limite de jobs em memória foi
+// atingido e todos ainda estão em execução ou na fila
+var ErrTooManyJobs = errors.New("too many unfinished jobs")
+
+// JobStatus representa o estado de um job de classificação em lote
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobCancelled JobStatus = "cancelled"
+	JobFailed    JobStatus = "failed"
+)
+
+// batchJob é um lote classificado em background
+type batchJob struct {
+	id     string
+	cancel context.CancelFunc
+
+	mu          sync.Mutex
+	status      JobStatus
+	testCases   []TestCase
+	results     []*APITestResult // Por índice; nil enquanto não classificado
+	completed   int
+	accumulator *batchAccumulator
+	stats       *TestBatchStats
+	err         string
+	createdAt   time.Time
+	startedAt   time.Time
+	finishedAt  time.Time
+}
+
+// finished indica se o job terminou (com sucesso, cancelado ou com falha)
+func (j *batchJob) finished() bool {
+	return j.status == JobCompleted || j.status == JobCancelled || j.status == JobFailed
+}
+
+// response monta o estado do job; as estatísticas só existem após o fim
+func (j *batchJob) response() JobResponse {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	response := JobResponse{
+		ID:         j.id,
+		Status:     j.status,
+		Total:      len(j.testCases),
+		Completed:  j.completed,
+		Statistics: j.stats,
+		Error:      j.err,
+		CreatedAt:  j.createdAt,
+	}
+
+	if response.Total > 0 {
+		response.Progress = float64(j.completed) / float64(response.Total) * 100
+	}
+	if !j.startedAt.IsZero() {
+		response.StartedAt = &j.startedAt
+	}
+	if !j.finishedAt.IsZero() {
+		response.FinishedAt = &j.finishedAt
+	}
+
+	return response
+}
+
+// JobManager executa os jobs em lote com no máximo maxRunning jobs ao mesmo tempo
+type JobManager struct {
+	server      *Server
+	slots       chan struct{}
+	maxRetained int
+
+	mu    sync.Mutex
+	jobs  map[string]*batchJob
+	order []string // IDs por ordem de criação
+}
+
+// NewJobManager cria um gerenciador de jobs para o servidor
+func NewJobManager(server *Server, maxRunning int) *JobManager {
+	return &JobManager{
+		server:      server,
+		slots:       make(chan struct{}, max(maxRunning, 1)),
+		maxRetained: maxRetainedJobs,
+		jobs:        make(map[string]*batchJob),
+	}
+}
+
+// Submit enfileira os casos de teste e retorna o job criado. Jobs não
+// finalizados nunca são descartados, então com o limite atingido só por eles
+// o job é recusado com ErrTooManyJobs
+func (m *JobManager) Submit(testCases []TestCase) (*batchJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &batchJob{
+		id:          id,
+		cancel:      cancel,
+		status:      JobQueued,
+		testCases:   testCases,
+		results:     make([]*APITestResult, len(testCases)),
+		accumulator: newBatchAccumulator(m.server.serviceMap),
+		createdAt:   time.Now().UTC(),
+	}
+
+	m.mu.Lock()
+	m.evict()
+	if len(m.order) >= m.maxRetained {
+		m.mu.Unlock()
+		cancel()
+		return nil, ErrTooManyJobs
+	}
+	m.jobs[id] = job
+	m.order = append(m.order, id)
+	m.mu.Unlock()
+
+	go m.run(ctx, job)
+	return job, nil
+}
+
+// Get retorna o job pelo ID
+func (m *JobManager) Get(id string) (*batchJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// evict descarta os jobs finalizados mais antigos acima do limite. Chamado com m.mu travado
+func (m *JobManager) evict() {
+	for i := 0; len(m.order) >= m.maxRetained && i < len(m.order); {
+		job := m.jobs[m.order[i]]
+
+		job.mu.Lock()
+		finished := job.finished()
+		job.mu.Unlock()
+
+		if !finished {
+			i++
+			continue
+		}
+
+		delete(m.jobs, job.id)
+		m.order = append(m.order[:i], m.order[i+1:]...)
+	}
+}
+
+// run espera uma vaga e classifica o lote do job
+func (m *JobManager) run(ctx context.Context, job *batchJob) {
+	defer job.cancel()
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		m.finish(job, ctx.Err())
+		return
+	}
+
+	job.mu.Lock()
+	job.status = JobRunning
+	job.startedAt = time.Now().UTC()
+	job.mu.Unlock()
+
+	log.Printf("JOB %s - Started, %d test cases", job.id, len(job.testCases))
+
+	err := m.server.runBatch(ctx, &sliceTestCaseReader{testCases: job.testCases}, m.server.batchConcurrency, func(outcome batchOutcome) {
+		job.mu.Lock()
+		defer job.mu.Unlock()
+
+		result := job.accumulator.add(outcome)
+		job.results[outcome.index] = &result
+		job.completed++
+	})
+
+	m.finish(job, err)
+}
+
+// finish registra o estado final e as estatísticas do job
+func (m *JobManager) finish(job *batchJob, err error) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	switch {
+	case err == nil:
+		job.status = JobCompleted
+	case errors.Is(err, context.Canceled):
+		job.status = JobCancelled
+	default:
+		job.status = JobFailed
+		job.err = err.Error()
+	}
+
+	stats := job.accumulator.finish()
+	job.stats = &stats
+	job.finishedAt = time.Now().UTC()
+
+	log.Printf("JOB %s - %s, %d/%d test cases, Accuracy: %.2f%%, AI cost: $%.4f",
+		job.id, job.status, job.completed, len(job.testCases), stats.AccuracyRate, stats.Cascade.AICost)
+}
+
+// newJobID gera um ID aleatório de 16 caracteres hexadecimais
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// createJobHandler responde a POST /api/jobs com o mesmo corpo de /api/test-batch (JSON ou CSV)
+func (s *Server) createJobHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJobBodySize)
+
+	testCases, err := readAllTestCases(newTestCaseReader(r))
+	if err != nil {
+		writeJobResponse(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if len(testCases) == 0 {
+		writeJobResponse(w, http.StatusBadRequest, map[string]string{"error": "test_cases cannot be empty"})
+		return
+	}
+
+	job, err := s.jobs.Submit(testCases)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrTooManyJobs) {
+			status = http.StatusTooManyRequests
+		}
+		writeJobResponse(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Location", "/api/jobs/"+job.id)
+	writeJobResponse(w, http.StatusAccepted, job.response())
+}
+
+// getJobHandler responde a GET /api/jobs/{id} com o estado e o progresso do job
+func (s *Server) getJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		writeJobResponse(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+
+	writeJobResponse(w, http.StatusOK, job.response())
+}
+
+// jobResultsHandler responde a GET /api/jobs/{id}/results com os resultados já
+// classificados em NDJSON, na ordem do lote
+func (s *Server) jobResultsHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		writeJobResponse(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+
+	// Copiar os resultados para não segurar o lock durante a escrita
+	job.mu.Lock()
+	results := make([]*APITestResult, 0, job.completed)
+	for _, result := range job.results {
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+	job.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			return
+		}
+	}
+}
+
+// cancelJobHandler responde a DELETE /api/jobs/{id} cancelando o job.
+// Os casos já classificados continuam disponíveis
+func (s *Server) cancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		writeJobResponse(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+
+	job.cancel()
+	log.Printf("JOB %s - Cancellation requested", job.id)
+
+	writeJobResponse(w, http.StatusAccepted, job.response())
+}
+
+func writeJobResponse(w http.ResponseWriter, status int, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSlowServer returns a server whose every classification waits aiDelay
+// for the AI, since no local result is safe
+func newSlowServer(t *testing.T, aiDelay time.Duration) *Server {
+	t.Helper()
+
+	knnService := newTestKNNService(t)
+	config := knnService.Config()
+	config.ConfidenceThreshold = 1.1
+	if err := knnService.SetConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &stubAI{content: `{"success": true, "service_id": 7}`, delay: aiDelay}
+	return NewServer(knnService, newStubAIClient(t, stub), testIntents)
+}
+
+// waitJob waits until the job finishes and returns its final state
+func waitJob(t *testing.T, job *batchJob) JobResponse {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job.mu.Lock()
+		finished := job.finished()
+		job.mu.Unlock()
+
+		if finished {
+			return job.response()
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("job %s did not finish", job.id)
+	return JobResponse{}
+}
+
+func repeatTestCases(n int) []TestCase {
+	testCases := make([]TestCase, n)
+	for i := range testCases {
+		testCases[i] = TestCase{Intent: "quero cancelar meu cartão", ExpectedServiceID: 7}
+	}
+	return testCases
+}
+
+func TestJobCancelKeepsPartialResults(t *testing.T) {
+	server := newSlowServer(t, 20*time.Millisecond)
+	if err := server.ConfigureBatch(1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := server.jobs.Submit(repeatTestCases(50))
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	for job.response().Completed < 2 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/jobs/"+job.id, nil)
+	req.SetPathValue("id", job.id)
+	server.cancelJobHandler(httptest.NewRecorder(), req)
+
+	response := waitJob(t, job)
+	if response.Status != JobCancelled || response.Completed < 2 || response.Completed >= response.Total {
+		t.Fatalf("job = %s with %d/%d completed, want cancelled part way", response.Status, response.Completed, response.Total)
+	}
+	if response.Statistics == nil || response.Statistics.TotalTests != response.Completed {
+		t.Errorf("statistics = %+v, want the %d completed test cases", response.Statistics, response.Completed)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/jobs/"+job.id+"/results", nil)
+	req.SetPathValue("id", job.id)
+	rec := httptest.NewRecorder()
+	server.jobResultsHandler(rec, req)
+
+	if lines := strings.Count(rec.Body.String(), "\n"); lines != response.Completed {
+		t.Errorf("results = %d lines, want the %d completed test cases", lines, response.Completed)
+	}
+}
+
+func TestJobManagerEvictsFinishedJobs(t *testing.T) {
+	server := newSlowServer(t, 0)
+	if err := server.SetCascadePolicy(CascadePolicy{Mode: CascadeLocal, AITimeout: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	server.jobs.maxRetained = 2
+
+	var jobs []*batchJob
+	for range 3 {
+		job, err := server.jobs.Submit(repeatTestCases(1))
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		waitJob(t, job)
+		jobs = append(jobs, job)
+	}
+
+	if _, ok := server.jobs.Get(jobs[0].id); ok {
+		t.Error("oldest finished job was not evicted")
+	}
+	for _, job := range jobs[1:] {
+		if _, ok := server.jobs.Get(job.id); !ok {
+			t.Errorf("job %s was evicted, want it retained", job.id)
+		}
+	}
+}
+
+func TestJobManagerRejectsWhenFull(t *testing.T) {
+	server := newSlowServer(t, 2*time.Second)
+	server.jobs.maxRetained = 2
+
+	var jobs []*batchJob
+	t.Cleanup(func() {
+		for _, job := range jobs {
+			job.cancel()
+			waitJob(t, job)
+		}
+	})
+
+	// One job runs and the other waits for a slot: neither can be evicted
+	for range 2 {
+		job, err := server.jobs.Submit(repeatTestCases(1))
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if _, err := server.jobs.Submit(repeatTestCases(1)); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("Submit() error = %v, want ErrTooManyJobs", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(`{"test_cases": [{"intent": "saldo"}]}`))
+	rec := httptest.NewRecorder()
+	server.createJobHandler(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestCreateJobRejectsLargeBody(t *testing.T) {
+	server := newSlowServer(t, 0)
+
+	body := "service_id;service_name;intent\n" + strings.Repeat("7;Cancelamento de cartão;quero cancelar meu cartão\n", maxJobBodySize/40)
+	req := httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	server.createJobHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if len(server.jobs.jobs) != 0 {
+		t.Errorf("jobs = %d, want the oversized body not to create a job", len(server.jobs.jobs))
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 )
@@ -15,7 +16,12 @@ func loadIntentsFromCSV(filePath string) ([]Intent, error) {
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
+	return parseIntentsCSV(file)
+}
+
+// parseIntentsCSV lê intenções no formato service_id;service_name;intent com cabeçalho
+func parseIntentsCSV(r io.Reader) ([]Intent, error) {
+	reader := csv.NewReader(r)
 	reader.Comma = ';'             // CSV usa ponto e vírgula como delimitador
 	reader.FieldsPerRecord = -1    // Permite número variável de campos
 	reader.TrimLeadingSpace = true // Remove espaços em branco
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,36 +18,39 @@ func main() {
 		log.Printf("Note: .env file not found, using system environment variables")
 	}
 
-	// Determinar o caminho do arquivo CSV
-	csvPath := os.Getenv("INTENTS_CSV_PATH")
-	if csvPath == "" {
-		// Caminho padrão relativo ao projeto
-		csvPath = filepath.Join("..", "..", "assets", "intents_pre_loaded.csv")
+	// Subcomandos: train gera o artefato do modelo offline, sweep ajusta a votação k-NN
+	// e evaluate compara as vetorizações
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "train":
+			runTrain(os.Args[2:])
+			return
+		case "sweep":
+			runSweep(os.Args[2:])
+			return
+		case "evaluate":
+			runEvaluate(os.Args[2:])
+			return
+		}
 	}
 
-	log.Printf("Loading intents from: %s", csvPath)
-
-	// Carregar intenções do CSV
-	intents, err := loadIntentsFromCSV(csvPath)
+	knnService, err := newKNNServiceFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to load intents: %v", err)
+		log.Fatalf("Failed to initialize KNN service: %v", err)
 	}
+	log.Printf("NLP pipeline ready - Vocabulary size: %d", knnService.VocabularySize())
 
-	log.Printf("Loaded %d intents from CSV", len(intents))
+	if err := configureKNNFromEnv(knnService); err != nil {
+		log.Fatalf("Failed to configure KNN voting: %v", err)
+	}
 
-	// Criar serviço KNN com pipeline NLP
-	log.Println("Initializing NLP-based KNN service...")
-	knnService, err := NewKNNService()
+	// Reaplicar os exemplos adicionados em execução antes de servir
+	examples, err := openExamplesFromEnv(knnService)
 	if err != nil {
-		log.Fatalf("Failed to create KNN service: %v", err)
+		log.Fatalf("Failed to load examples: %v", err)
 	}
 
-	// Carregar e treinar com as intents
-	log.Println("Training NLP pipeline with intents...")
-	if err := knnService.LoadIntents(intents); err != nil {
-		log.Fatalf("Failed to load intents into service: %v", err)
-	}
-	log.Printf("NLP pipeline ready - Vocabulary size: %d", knnService.VocabularySize())
+	intents := knnService.Intents()
 
 	// Criar cliente AI para fallback
 	aiClient := NewAIClient()
@@ -56,6 +61,22 @@ func main() {
 	// Criar servidor
 	server := NewServer(knnService, aiClient, intents)
 
+	if err := configureCascadeFromEnv(server); err != nil {
+		log.Fatalf("Failed to configure cascade: %v", err)
+	}
+
+	if err := configureBatchFromEnv(server); err != nil {
+		log.Fatalf("Failed to configure batch classification: %v", err)
+	}
+
+	if token := os.Getenv("EXAMPLES_API_TOKEN"); token != "" {
+		if examples == nil {
+			log.Println("WARNING: EXAMPLES_PATH not set, examples added at runtime will be lost on restart")
+		}
+		server.EnableExamples(examples, token)
+		log.Println("Examples API enabled at /api/examples")
+	}
+
 	// Obter porta do ambiente ou usar padrão
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -68,3 +89,171 @@ func main() {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// newKNNServiceFromEnv carrega o artefato de MODEL_PATH quando definido,
+// senão treina o pipeline com o CSV de INTENTS_CSV_PATH
+func newKNNServiceFromEnv() (*KNNService, error) {
+	if modelPath := os.Getenv("MODEL_PATH"); modelPath != "" {
+		log.Printf("Loading model from: %s", modelPath)
+
+		file, err := os.Open(modelPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open model: %w", err)
+		}
+		defer file.Close()
+
+		knnService, err := LoadModel(file)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("Loaded %d intents from model", len(knnService.Intents()))
+		return knnService, nil
+	}
+
+	csvPath := defaultCSVPath()
+	log.Printf("Loading intents from: %s", csvPath)
+
+	// Carregar intenções do CSV
+	intents, err := loadIntentsFromCSV(csvPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load intents: %w", err)
+	}
+
+	log.Printf("Loaded %d intents from CSV", len(intents))
+
+	// Criar serviço KNN com pipeline NLP
+	log.Println("Initializing NLP-based KNN service...")
+	knnService, err := NewKNNService(os.Getenv("NLP_STEMMER"), os.Getenv("NLP_VECTORIZER"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create KNN service: %w", err)
+	}
+
+	// Carregar e treinar com as intents
+	log.Println("Training NLP pipeline with intents...")
+	if err := knnService.LoadIntents(intents); err != nil {
+		return nil, fmt.Errorf("failed to load intents into service: %w", err)
+	}
+
+	return knnService, nil
+}
+
+// configureKNNFromEnv aplica KNN_K, KNN_WEIGHT_POWER, KNN_CONFIDENCE_THRESHOLD e
+// KNN_AMBIGUITY_MARGIN e ajusta a calibração com CALIBRATION_CSV, quando definidos
+func configureKNNFromEnv(knnService *KNNService) error {
+	config := knnService.Config()
+
+	if v := os.Getenv("KNN_K"); v != "" {
+		k, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid KNN_K: %w", err)
+		}
+		config.K = k
+	}
+
+	floats := []struct {
+		name  string
+		value *float64
+	}{
+		{"KNN_WEIGHT_POWER", &config.WeightPower},
+		{"KNN_CONFIDENCE_THRESHOLD", &config.ConfidenceThreshold},
+		{"KNN_AMBIGUITY_MARGIN", &config.AmbiguityMargin},
+	}
+	for _, f := range floats {
+		if v := os.Getenv(f.name); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", f.name, err)
+			}
+			*f.value = parsed
+		}
+	}
+
+	if err := knnService.SetConfig(config); err != nil {
+		return err
+	}
+
+	log.Printf("KNN voting: K=%d, weight power=%g, confidence threshold=%g, ambiguity margin=%g",
+		config.K, config.WeightPower, config.ConfidenceThreshold, config.AmbiguityMargin)
+
+	calibrationPath := os.Getenv("CALIBRATION_CSV")
+	if calibrationPath == "" {
+		return nil
+	}
+
+	holdout, err := loadIntentsFromCSV(calibrationPath)
+	if err != nil {
+		return fmt.Errorf("failed to load calibration intents: %w", err)
+	}
+
+	method := os.Getenv("CALIBRATION_METHOD")
+	if err := knnService.Calibrate(holdout, method); err != nil {
+		return err
+	}
+
+	log.Printf("Confidence calibrated on %d intents from %s", len(holdout), calibrationPath)
+	return nil
+}
+
+// configureCascadeFromEnv aplica CASCADE_MODE, CASCADE_HEDGE_AFTER_MS e
+// CASCADE_AI_TIMEOUT_MS, quando definidos, à política da cascata local/IA
+func configureCascadeFromEnv(server *Server) error {
+	policy := DefaultCascadePolicy()
+
+	if v := os.Getenv("CASCADE_MODE"); v != "" {
+		policy.Mode = CascadeMode(v)
+	}
+
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"CASCADE_HEDGE_AFTER_MS", &policy.HedgeAfter},
+		{"CASCADE_AI_TIMEOUT_MS", &policy.AITimeout},
+	}
+	for _, d := range durations {
+		if v := os.Getenv(d.name); v != "" {
+			ms, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", d.name, err)
+			}
+			*d.value = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if err := server.SetCascadePolicy(policy); err != nil {
+		return err
+	}
+
+	log.Printf("Cascade policy: mode=%s, hedge after=%v, AI timeout=%v", policy.Mode, policy.HedgeAfter, policy.AITimeout)
+	return nil
+}
+
+// configureBatchFromEnv aplica BATCH_CONCURRENCY e JOBS_MAX_RUNNING, quando definidos
+func configureBatchFromEnv(server *Server) error {
+	concurrency, maxRunningJobs := defaultBatchConcurrency, 1
+
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"BATCH_CONCURRENCY", &concurrency},
+		{"JOBS_MAX_RUNNING", &maxRunningJobs},
+	}
+	for _, i := range ints {
+		if v := os.Getenv(i.name); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", i.name, err)
+			}
+			*i.value = parsed
+		}
+	}
+
+	if err := server.ConfigureBatch(concurrency, maxRunningJobs); err != nil {
+		return err
+	}
+
+	log.Printf("Batch classification: %d concurrent classifications, %d concurrent jobs", concurrency, maxRunningJobs)
+	return nil
+}
@@ -0,0 +1,413 @@
+package nlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"slices"
+)
+
+// Model artifact layout. All integers are unsigned varints unless noted and
+// strings are a varint length followed by UTF-8 bytes.
+//
+//	magic      4 bytes "KNNM"
+//	version    uint16, little endian
+//	config     language code, stemmer name
+//	vectorizer kind name and state (see below)
+//	intents    intent count, then original, processed, category and the
+//	           vector as entry count, delta-encoded indices and values
+//	labels     label count, then category and name sorted by category
+//	checksum   CRC-32 (IEEE) of all previous bytes, uint32 little endian
+//
+// Vectorizer states, where a vocabulary is a term count followed by each
+// term and its IDF in index order:
+//
+//	tfidf      n-gram min and max sizes (0 for words), normalized flag
+//	           (1 byte), document count, vocabulary
+//	bm25       normalized flag, k1, b, average document length, document
+//	           count, vocabulary
+//	hybrid     word weight, then the word and char vectorizers
+//
+// Version 1 artifacts hold a word tfidf state without kind and n-gram sizes.
+//
+// Floats are stored as IEEE 754 float64 bits, little endian, so a loaded
+// pipeline predicts exactly like the one that was saved.
+const (
+	artifactMagic   = "KNNM"
+	artifactVersion = 2
+)
+
+// ErrArtifactChecksum is returned by Load when the artifact checksum does not
+// match its contents.
+var ErrArtifactChecksum = errors.New("model artifact checksum mismatch")
+
+// Save writes the trained pipeline as a versioned binary artifact that Load
+// can restore without refitting.
+func (p *Pipeline) Save(w io.Writer) error {
+	if p.index == nil {
+		return fmt.Errorf("pipeline must be trained before saving")
+	}
+
+	var e artifactEncoder
+	e.buf = append(e.buf, artifactMagic...)
+	e.buf = binary.LittleEndian.AppendUint16(e.buf, artifactVersion)
+
+	// Preprocessing config
+	e.string(p.Preprocessor.lang)
+	e.string(p.Preprocessor.stemmer.Name())
+
+	if err := e.vectorizer(p.Vectorizer); err != nil {
+		return err
+	}
+
+	e.uvarint(uint64(len(p.IntentVectors)))
+	for _, iv := range p.IntentVectors {
+		e.string(iv.Original)
+		e.string(iv.Processed)
+		e.string(iv.Category)
+		e.vector(iv.Vector)
+	}
+
+	categories := make([]string, 0, len(p.Labels))
+	for category := range p.Labels {
+		categories = append(categories, category)
+	}
+	slices.Sort(categories)
+
+	e.uvarint(uint64(len(categories)))
+	for _, category := range categories {
+		e.string(category)
+		e.string(p.Labels[category])
+	}
+
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, crc32.ChecksumIEEE(e.buf))
+
+	_, err := w.Write(e.buf)
+	return err
+}
+
+// Load reads a pipeline saved with Save and rebuilds its search index.
+func Load(r io.Reader) (*Pipeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading model artifact: %w", err)
+	}
+
+	if len(data) < len(artifactMagic)+2+4 || string(data[:len(artifactMagic)]) != artifactMagic {
+		return nil, fmt.Errorf("not a model artifact")
+	}
+
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, ErrArtifactChecksum
+	}
+
+	version := binary.LittleEndian.Uint16(body[len(artifactMagic):])
+	if version == 0 || version > artifactVersion {
+		return nil, fmt.Errorf("unsupported model artifact version %d (expected %d)", version, artifactVersion)
+	}
+
+	d := artifactDecoder{buf: body[len(artifactMagic)+2:]}
+
+	lang := d.string()
+	stemmerName := d.string()
+
+	var vectorizer Vectorizer
+	if version == 1 {
+		vectorizer = d.tfidf(false)
+	} else {
+		vectorizer = d.vectorizer()
+	}
+
+	vocabSize := 0
+	if d.err == nil {
+		vocabSize = vectorizer.VocabularySize()
+	}
+
+	intentCount := d.count()
+	intentVectors := make([]IntentVector, 0, intentCount)
+	vectors := make([]SparseVector, 0, intentCount)
+	for i := 0; i < intentCount && d.err == nil; i++ {
+		iv := IntentVector{
+			Original:  d.string(),
+			Processed: d.string(),
+			Category:  d.string(),
+			Vector:    d.vector(vocabSize),
+			Metadata:  make(map[string]interface{}),
+		}
+		intentVectors = append(intentVectors, iv)
+		vectors = append(vectors, iv.Vector)
+	}
+
+	labelCount := d.count()
+	labels := make(map[string]string, labelCount)
+	for i := 0; i < labelCount && d.err == nil; i++ {
+		category := d.string()
+		labels[category] = d.string()
+	}
+
+	if d.err == nil && len(d.buf) != 0 {
+		d.fail(fmt.Errorf("%d trailing bytes", len(d.buf)))
+	}
+
+	if d.err != nil {
+		return nil, fmt.Errorf("invalid model artifact: %w", d.err)
+	}
+
+	stemmer, err := NewStemmer(stemmerName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model artifact: %w", err)
+	}
+
+	return &Pipeline{
+		Preprocessor:  &Preprocessor{lang: lang, stemmer: stemmer},
+		Vectorizer:    vectorizer,
+		IntentVectors: intentVectors,
+		Labels:        labels,
+		index:         NewInvertedIndex(vectors),
+	}, nil
+}
+
+// artifactEncoder appends artifact fields to a buffer.
+type artifactEncoder struct {
+	buf []byte
+}
+
+func (e *artifactEncoder) uvarint(x uint64) {
+	e.buf = binary.AppendUvarint(e.buf, x)
+}
+
+func (e *artifactEncoder) float64(f float64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+}
+
+func (e *artifactEncoder) bool(b bool) {
+	if b {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *artifactEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *artifactEncoder) vector(v SparseVector) {
+	e.uvarint(uint64(len(v.Indices)))
+
+	prev := int32(0)
+	for i, idx := range v.Indices {
+		e.uvarint(uint64(idx - prev))
+		e.float64(v.Values[i])
+		prev = idx
+	}
+}
+
+// vectorizer writes the kind and state of a vectorizer implemented in this package.
+func (e *artifactEncoder) vectorizer(v Vectorizer) error {
+	switch v := v.(type) {
+	case *TFIDFVectorizer:
+		v.mu.RLock()
+		defer v.mu.RUnlock()
+
+		e.string("tfidf")
+		e.uvarint(uint64(v.ngramMin))
+		e.uvarint(uint64(v.ngramMax))
+		e.bool(v.normalized)
+		e.uvarint(uint64(v.documentCount))
+		e.vocabulary(v.vocabulary, v.idf)
+
+	case *BM25Vectorizer:
+		v.mu.RLock()
+		defer v.mu.RUnlock()
+
+		e.string("bm25")
+		e.bool(v.normalized)
+		e.float64(v.k1)
+		e.float64(v.b)
+		e.float64(v.avgDocLength)
+		e.uvarint(uint64(v.documentCount))
+		e.vocabulary(v.vocabulary, v.idf)
+
+	case *HybridVectorizer:
+		e.string("hybrid")
+		e.float64(v.wordWeight)
+		if err := e.vectorizer(v.word); err != nil {
+			return err
+		}
+		return e.vectorizer(v.char)
+
+	default:
+		return fmt.Errorf("vectorizer %T cannot be saved", v)
+	}
+
+	return nil
+}
+
+// vocabulary writes the terms in index order with their IDF.
+func (e *artifactEncoder) vocabulary(vocabulary map[string]int, idf map[string]float64) {
+	terms := make([]string, len(vocabulary))
+	for term, idx := range vocabulary {
+		terms[idx] = term
+	}
+
+	e.uvarint(uint64(len(terms)))
+	for _, term := range terms {
+		e.string(term)
+		e.float64(idf[term])
+	}
+}
+
+// artifactDecoder reads artifact fields from a buffer. The first error is
+// kept and every later read returns a zero value.
+type artifactDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *artifactDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *artifactDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	x, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail(io.ErrUnexpectedEOF)
+		return 0
+	}
+
+	d.buf = d.buf[n:]
+	return x
+}
+
+// count reads a length prefix, rejecting values that cannot fit in the
+// remaining bytes so a corrupt artifact cannot force a huge allocation.
+func (d *artifactDecoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.fail(io.ErrUnexpectedEOF)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *artifactDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n > len(d.buf) {
+		d.fail(io.ErrUnexpectedEOF)
+		return nil
+	}
+
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *artifactDecoder) float64() float64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func (d *artifactDecoder) bool() bool {
+	b := d.bytes(1)
+	return b != nil && b[0] != 0
+}
+
+func (d *artifactDecoder) string() string {
+	return string(d.bytes(d.count()))
+}
+
+func (d *artifactDecoder) vector(vocabSize int) SparseVector {
+	n := d.count()
+	v := SparseVector{
+		Indices: make([]int32, 0, n),
+		Values:  make([]float64, 0, n),
+	}
+
+	idx := uint64(0)
+	for i := 0; i < n && d.err == nil; i++ {
+		delta := d.uvarint()
+		if i > 0 && delta == 0 {
+			d.fail(fmt.Errorf("vector indices are not ascending"))
+		}
+
+		idx += delta
+		if idx >= uint64(vocabSize) {
+			d.fail(fmt.Errorf("vector index %d out of vocabulary", idx))
+		}
+
+		v.Indices = append(v.Indices, int32(idx))
+		v.Values = append(v.Values, d.float64())
+	}
+
+	return v
+}
+
+func (d *artifactDecoder) vectorizer() Vectorizer {
+	switch kind := d.string(); kind {
+	case "tfidf":
+		return d.tfidf(true)
+
+	case "bm25":
+		v := NewBM25Vectorizer(d.bool())
+		v.k1 = d.float64()
+		v.b = d.float64()
+		v.avgDocLength = d.float64()
+		v.documentCount = int(d.uvarint())
+		d.vocabulary(v.vocabulary, v.idf)
+		return v
+
+	case "hybrid":
+		weight := d.float64()
+		word := d.vectorizer()
+		char := d.vectorizer()
+		return NewHybridVectorizer(word, char, weight)
+
+	default:
+		d.fail(fmt.Errorf("unknown vectorizer %q", kind))
+		return nil
+	}
+}
+
+// tfidf reads a TF-IDF state; version 1 artifacts have no n-gram sizes.
+func (d *artifactDecoder) tfidf(ngrams bool) *TFIDFVectorizer {
+	var minN, maxN int
+	if ngrams {
+		minN, maxN = int(d.uvarint()), int(d.uvarint())
+	}
+
+	v := NewCharNGramVectorizer(minN, maxN, d.bool())
+	v.documentCount = int(d.uvarint())
+	d.vocabulary(v.vocabulary, v.idf)
+	return v
+}
+
+func (d *artifactDecoder) vocabulary(vocabulary map[string]int, idf map[string]float64) {
+	size := d.count()
+	for i := 0; i < size && d.err == nil; i++ {
+		term := d.string()
+		vocabulary[term] = i
+		idf[term] = d.float64()
+	}
+
+	if d.err == nil && len(vocabulary) != size {
+		d.fail(fmt.Errorf("duplicate vocabulary terms"))
+	}
+}
@@ -0,0 +1,131 @@
+package nlp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// trainedPipeline returns a pipeline trained on data-set.csv with the simple
+// stemmer and the named vectorizer.
+func trainedPipeline(t *testing.T, vectorizerName string) *Pipeline {
+	t.Helper()
+
+	intents, categories := loadDataset(t)
+
+	vectorizer, err := NewVectorizer(vectorizerName, true)
+	if err != nil {
+		t.Fatalf("NewVectorizer() error = %v", err)
+	}
+
+	pipeline, err := NewPipeline("portuguese", true, WithVectorizer(vectorizer), WithStemmer(SimpleStemmer{}))
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	pipeline.Labels["1"] = "Consulta Limite / Vencimento do cartão / Melhor dia de compra"
+
+	if err := pipeline.Train(intents, categories); err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+
+	return pipeline
+}
+
+func TestArtifactRoundTrip(t *testing.T) {
+	for _, name := range []string{"tfidf", "char", "bm25", "hybrid"} {
+		t.Run(name, func(t *testing.T) {
+			testArtifactRoundTrip(t, trainedPipeline(t, name))
+		})
+	}
+}
+
+func testArtifactRoundTrip(t *testing.T, pipeline *Pipeline) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := pipeline.Save(&buf); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := loaded.Preprocessor.stemmer.Name(); got != "simple" {
+		t.Errorf("stemmer = %q, want simple", got)
+	}
+
+	if loaded.Vectorizer.VocabularySize() != pipeline.Vectorizer.VocabularySize() {
+		t.Errorf("vocabulary size = %d, want %d", loaded.Vectorizer.VocabularySize(), pipeline.Vectorizer.VocabularySize())
+	}
+
+	if loaded.Labels["1"] != pipeline.Labels["1"] {
+		t.Errorf("Labels[1] = %q, want %q", loaded.Labels["1"], pipeline.Labels["1"])
+	}
+
+	if len(loaded.IntentVectors) != len(pipeline.IntentVectors) {
+		t.Fatalf("intent vectors = %d, want %d", len(loaded.IntentVectors), len(pipeline.IntentVectors))
+	}
+
+	// The loaded model must predict exactly like the saved one
+	for _, iv := range pipeline.IntentVectors {
+		wantMatches, wantSims, err := pipeline.PredictTopK(iv.Original, 3)
+		if err != nil {
+			t.Fatalf("PredictTopK() error = %v", err)
+		}
+
+		gotMatches, gotSims, err := loaded.PredictTopK(iv.Original, 3)
+		if err != nil {
+			t.Fatalf("loaded PredictTopK() error = %v", err)
+		}
+
+		for i := range wantMatches {
+			if gotMatches[i].Original != wantMatches[i].Original || gotSims[i] != wantSims[i] {
+				t.Fatalf("PredictTopK(%q)[%d] = %q (%v), want %q (%v)",
+					iv.Original, i, gotMatches[i].Original, gotSims[i], wantMatches[i].Original, wantSims[i])
+			}
+		}
+	}
+
+	// Saving again produces the same bytes
+	var again bytes.Buffer
+	if err := loaded.Save(&again); err != nil {
+		t.Fatalf("Save() after Load error = %v", err)
+	}
+
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Error("Save() after Load produced a different artifact")
+	}
+}
+
+func TestArtifactErrors(t *testing.T) {
+	untrained, err := NewPipeline("portuguese", true)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	if err := untrained.Save(&bytes.Buffer{}); err == nil {
+		t.Error("Save() before Train error = nil, want error")
+	}
+
+	var buf bytes.Buffer
+	if err := trainedPipeline(t, "tfidf").Save(&buf); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	artifact := buf.Bytes()
+
+	corrupted := bytes.Clone(artifact)
+	corrupted[len(corrupted)/2] ^= 0xff
+	if _, err := Load(bytes.NewReader(corrupted)); !errors.Is(err, ErrArtifactChecksum) {
+		t.Errorf("Load(corrupted) error = %v, want ErrArtifactChecksum", err)
+	}
+
+	if _, err := Load(bytes.NewReader(artifact[:len(artifact)-10])); err == nil {
+		t.Error("Load(truncated) error = nil, want error")
+	}
+
+	if _, err := Load(bytes.NewReader([]byte("not a model"))); err == nil {
+		t.Error("Load(garbage) error = nil, want error")
+	}
+}
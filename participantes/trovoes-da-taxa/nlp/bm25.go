@@ -0,0 +1,144 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// BM25Vectorizer weights words with Okapi BM25: term frequency saturates
+// with k1 and is normalized by document length relative to the average with b.
+type BM25Vectorizer struct {
+	mu sync.RWMutex
+
+	// vocabulary maps terms to their index in the vector
+	vocabulary map[string]int
+
+	// idf stores the BM25 inverse document frequency for each term
+	idf map[string]float64
+
+	// documentCount is the total number of documents
+	documentCount int
+
+	// avgDocLength is the average number of terms per document
+	avgDocLength float64
+
+	// k1 controls term frequency saturation and b the length normalization
+	k1, b float64
+
+	// normalized indicates if vectors should be normalized (for cosine similarity)
+	normalized bool
+}
+
+// NewBM25Vectorizer creates a BM25 vectorizer with the usual k1 = 1.2 and b = 0.75.
+func NewBM25Vectorizer(normalized bool) *BM25Vectorizer {
+	return &BM25Vectorizer{
+		vocabulary: make(map[string]int),
+		idf:        make(map[string]float64),
+		k1:         1.2,
+		b:          0.75,
+		normalized: normalized,
+	}
+}
+
+// Fit trains the vectorizer on a corpus of documents.
+func (v *BM25Vectorizer) Fit(documents []string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(documents) == 0 {
+		return fmt.Errorf("cannot fit on empty document set")
+	}
+
+	v.documentCount = len(documents)
+
+	termDocFreq := make(map[string]int)
+	totalLength := 0
+
+	for _, doc := range documents {
+		terms := strings.Fields(doc)
+		totalLength += len(terms)
+		seenInDoc := make(map[string]bool)
+
+		for _, term := range terms {
+			if _, exists := v.vocabulary[term]; !exists {
+				v.vocabulary[term] = len(v.vocabulary)
+			}
+
+			if !seenInDoc[term] {
+				termDocFreq[term]++
+				seenInDoc[term] = true
+			}
+		}
+	}
+
+	v.avgDocLength = float64(totalLength) / float64(v.documentCount)
+
+	// IDF(t) = ln(1 + (N - df + 0.5) / (df + 0.5)), positive even for terms in every document
+	n := float64(v.documentCount)
+	for term, df := range termDocFreq {
+		v.idf[term] = math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+	}
+
+	return nil
+}
+
+// TransformSparse converts a document into a BM25 vector holding only the
+// terms of the document that are in the vocabulary.
+func (v *BM25Vectorizer) TransformSparse(document string) (SparseVector, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if len(v.vocabulary) == 0 {
+		return SparseVector{}, fmt.Errorf("vectorizer must be fitted before transform")
+	}
+
+	terms := strings.Fields(document)
+	termFreq := make(map[string]int, len(terms))
+	for _, term := range terms {
+		termFreq[term]++
+	}
+
+	// Length normalization: 1 - b + b * |d| / avgdl
+	lengthNorm := 1 - v.b
+	if v.avgDocLength > 0 {
+		lengthNorm += v.b * float64(len(terms)) / v.avgDocLength
+	}
+
+	entries := make([]sparseEntry, 0, len(termFreq))
+	for term, freq := range termFreq {
+		if idx, exists := v.vocabulary[term]; exists {
+			tf := float64(freq)
+			weight := v.idf[term] * tf * (v.k1 + 1) / (tf + v.k1*lengthNorm)
+			entries = append(entries, sparseEntry{index: int32(idx), value: weight})
+		}
+	}
+
+	return newSparseVector(entries, v.normalized), nil
+}
+
+// FitTransformSparse fits the vectorizer and transforms all documents into sparse vectors.
+func (v *BM25Vectorizer) FitTransformSparse(documents []string) ([]SparseVector, error) {
+	if err := v.Fit(documents); err != nil {
+		return nil, err
+	}
+
+	vectors := make([]SparseVector, len(documents))
+	for i, doc := range documents {
+		vec, err := v.TransformSparse(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error transforming document %d: %w", i, err)
+		}
+		vectors[i] = vec
+	}
+
+	return vectors, nil
+}
+
+// VocabularySize returns the number of unique terms in the vocabulary.
+func (v *BM25Vectorizer) VocabularySize() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.vocabulary)
+}
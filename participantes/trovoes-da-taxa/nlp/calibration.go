@@ -0,0 +1,236 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Calibrator maps a raw classifier score to the probability that the
+// prediction is correct.
+type Calibrator interface {
+	Calibrate(score float64) float64
+}
+
+// FitCalibrator fits a calibrator with the named method, "platt" or
+// "isotonic", on scores labelled with whether the prediction was correct.
+func FitCalibrator(method string, scores []float64, correct []bool) (Calibrator, error) {
+	switch method {
+	case "platt", "":
+		return FitPlatt(scores, correct)
+	case "isotonic":
+		return FitIsotonic(scores, correct)
+	}
+
+	return nil, fmt.Errorf("unknown calibration method %q (available: platt, isotonic)", method)
+}
+
+func checkCalibrationData(scores []float64, correct []bool) error {
+	if len(scores) != len(correct) {
+		return fmt.Errorf("scores and labels must have same length")
+	}
+
+	if len(scores) == 0 {
+		return fmt.Errorf("cannot calibrate on empty data")
+	}
+
+	return nil
+}
+
+// PlattCalibrator is a sigmoid fitted on the scores:
+// P(correct | score) = 1 / (1 + exp(A*score + B)).
+type PlattCalibrator struct {
+	A, B float64
+}
+
+// FitPlatt fits a PlattCalibrator by Newton's method with backtracking line
+// search and Platt's smoothed targets, following Lin, Lin and Weng (2007).
+func FitPlatt(scores []float64, correct []bool) (*PlattCalibrator, error) {
+	if err := checkCalibrationData(scores, correct); err != nil {
+		return nil, err
+	}
+
+	const (
+		maxIterations = 100
+		minStep       = 1e-10
+		sigma         = 1e-12
+		epsilon       = 1e-5
+	)
+
+	var positives, negatives float64
+	for _, c := range correct {
+		if c {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+
+	// Smoothed targets avoid overfitting when a class is small
+	hiTarget := (positives + 1) / (positives + 2)
+	loTarget := 1 / (negatives + 2)
+
+	targets := make([]float64, len(correct))
+	for i, c := range correct {
+		targets[i] = loTarget
+		if c {
+			targets[i] = hiTarget
+		}
+	}
+
+	objective := func(a, b float64) float64 {
+		var f float64
+		for i, s := range scores {
+			fApB := s*a + b
+			if fApB >= 0 {
+				f += targets[i]*fApB + math.Log1p(math.Exp(-fApB))
+			} else {
+				f += (targets[i]-1)*fApB + math.Log1p(math.Exp(fApB))
+			}
+		}
+		return f
+	}
+
+	a, b := 0.0, math.Log((negatives+1)/(positives+1))
+	fval := objective(a, b)
+
+	for range maxIterations {
+		// Gradient and Hessian of the negative log-likelihood
+		h11, h22, h21 := sigma, sigma, 0.0
+		g1, g2 := 0.0, 0.0
+
+		for i, s := range scores {
+			p := sigmoid(-(s*a + b))
+			d2 := p * (1 - p)
+			h11 += s * s * d2
+			h22 += d2
+			h21 += s * d2
+
+			d1 := targets[i] - p
+			g1 += s * d1
+			g2 += d1
+		}
+
+		if math.Abs(g1) < epsilon && math.Abs(g2) < epsilon {
+			break
+		}
+
+		det := h11*h22 - h21*h21
+		dA := -(h22*g1 - h21*g2) / det
+		dB := -(-h21*g1 + h11*g2) / det
+		gd := g1*dA + g2*dB
+
+		step := 1.0
+		for step >= minStep {
+			newA, newB := a+step*dA, b+step*dB
+			if newF := objective(newA, newB); newF < fval+0.0001*step*gd {
+				a, b, fval = newA, newB, newF
+				break
+			}
+			step /= 2
+		}
+
+		if step < minStep {
+			break
+		}
+	}
+
+	return &PlattCalibrator{A: a, B: b}, nil
+}
+
+// Calibrate implements Calibrator.
+func (c *PlattCalibrator) Calibrate(score float64) float64 {
+	return sigmoid(-(c.A*score + c.B))
+}
+
+// sigmoid computes 1 / (1 + exp(-x)) without overflowing.
+func sigmoid(x float64) float64 {
+	if x >= 0 {
+		return 1 / (1 + math.Exp(-x))
+	}
+	e := math.Exp(x)
+	return e / (1 + e)
+}
+
+// IsotonicCalibrator is a non-decreasing step function fitted on the scores
+// with the pool adjacent violators algorithm.
+type IsotonicCalibrator struct {
+	// Thresholds holds the highest score of each step, in ascending order
+	Thresholds []float64
+
+	// Probabilities holds the probability of each step
+	Probabilities []float64
+}
+
+// FitIsotonic fits an IsotonicCalibrator.
+func FitIsotonic(scores []float64, correct []bool) (*IsotonicCalibrator, error) {
+	if err := checkCalibrationData(scores, correct); err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		switch {
+		case scores[i] < scores[j]:
+			return -1
+		case scores[i] > scores[j]:
+			return 1
+		}
+		return 0
+	})
+
+	type block struct {
+		maxScore float64
+		sum      float64
+		count    float64
+	}
+
+	blocks := make([]block, 0, len(order))
+	for _, i := range order {
+		value := 0.0
+		if correct[i] {
+			value = 1
+		}
+
+		// Equal scores always share a block so the step function is well defined
+		if n := len(blocks); n > 0 && blocks[n-1].maxScore == scores[i] {
+			blocks[n-1].sum += value
+			blocks[n-1].count++
+		} else {
+			blocks = append(blocks, block{maxScore: scores[i], sum: value, count: 1})
+		}
+
+		// Pool while the last block breaks monotonicity
+		for n := len(blocks); n > 1 && blocks[n-2].sum/blocks[n-2].count >= blocks[n-1].sum/blocks[n-1].count; n-- {
+			blocks[n-2].sum += blocks[n-1].sum
+			blocks[n-2].count += blocks[n-1].count
+			blocks[n-2].maxScore = blocks[n-1].maxScore
+			blocks = blocks[:n-1]
+		}
+	}
+
+	c := &IsotonicCalibrator{
+		Thresholds:    make([]float64, len(blocks)),
+		Probabilities: make([]float64, len(blocks)),
+	}
+	for i, b := range blocks {
+		c.Thresholds[i] = b.maxScore
+		c.Probabilities[i] = b.sum / b.count
+	}
+
+	return c, nil
+}
+
+// Calibrate implements Calibrator. Scores above the last threshold get the
+// probability of the last step.
+func (c *IsotonicCalibrator) Calibrate(score float64) float64 {
+	i := sort.SearchFloat64s(c.Thresholds, score)
+	if i == len(c.Thresholds) {
+		i--
+	}
+	return c.Probabilities[i]
+}
@@ -0,0 +1,78 @@
+package nlp
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// syntheticCalibrationData draws scores uniformly in [0, 1] where a score s
+// is correct with probability s.
+func syntheticCalibrationData(n int) ([]float64, []bool) {
+	rng := rand.New(rand.NewSource(1))
+
+	scores := make([]float64, n)
+	correct := make([]bool, n)
+	for i := range scores {
+		scores[i] = rng.Float64()
+		correct[i] = rng.Float64() < scores[i]
+	}
+
+	return scores, correct
+}
+
+func TestCalibrators(t *testing.T) {
+	scores, correct := syntheticCalibrationData(5000)
+
+	for _, method := range []string{"platt", "isotonic"} {
+		t.Run(method, func(t *testing.T) {
+			calibrator, err := FitCalibrator(method, scores, correct)
+			if err != nil {
+				t.Fatalf("FitCalibrator() error = %v", err)
+			}
+
+			// Calibrated probabilities must be monotonic and close to the true rate
+			prev := 0.0
+			for _, score := range []float64{0.1, 0.3, 0.5, 0.7, 0.9} {
+				p := calibrator.Calibrate(score)
+				if p < prev {
+					t.Errorf("Calibrate(%v) = %v, not monotonic", score, p)
+				}
+				if p < score-0.1 || p > score+0.1 {
+					t.Errorf("Calibrate(%v) = %v, want about %v", score, p, score)
+				}
+				prev = p
+			}
+		})
+	}
+}
+
+func TestIsotonicCalibratorSteps(t *testing.T) {
+	calibrator, err := FitIsotonic([]float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}, []bool{false, true, false, true, true, true})
+	if err != nil {
+		t.Fatalf("FitIsotonic() error = %v", err)
+	}
+
+	// 0.2 and 0.3 violate monotonicity and are pooled to 0.5
+	tests := map[float64]float64{0.05: 0, 0.1: 0, 0.25: 0.5, 0.3: 0.5, 0.4: 1, 0.9: 1}
+	for score, want := range tests {
+		if got := calibrator.Calibrate(score); got != want {
+			t.Errorf("Calibrate(%v) = %v, want %v", score, got, want)
+		}
+	}
+}
+
+func TestFitCalibratorErrors(t *testing.T) {
+	if _, err := FitCalibrator("unknown", []float64{0.5}, []bool{true}); err == nil {
+		t.Error("FitCalibrator(unknown) error = nil, want error")
+	}
+
+	for _, method := range []string{"platt", "isotonic"} {
+		if _, err := FitCalibrator(method, nil, nil); err == nil {
+			t.Errorf("FitCalibrator(%s, empty) error = nil, want error", method)
+		}
+
+		if _, err := FitCalibrator(method, []float64{0.5}, nil); err == nil {
+			t.Errorf("FitCalibrator(%s, mismatched) error = nil, want error", method)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+)
+
+// HybridVectorizer concatenates the features of a word vectorizer and a
+// character n-gram vectorizer. Each part is scaled to unit length and then by
+// the square root of its weight, so the cosine similarity of two hybrid
+// vectors is the weighted sum of the word and character similarities.
+type HybridVectorizer struct {
+	word, char Vectorizer
+
+	// wordWeight is the share of the word features, in [0, 1]
+	wordWeight float64
+}
+
+// NewHybridVectorizer combines word and char features, giving wordWeight to
+// the word similarity and the rest to the character similarity.
+func NewHybridVectorizer(word, char Vectorizer, wordWeight float64) *HybridVectorizer {
+	return &HybridVectorizer{
+		word:       word,
+		char:       char,
+		wordWeight: wordWeight,
+	}
+}
+
+// FitTransformSparse fits both vectorizers and returns the combined vectors.
+func (h *HybridVectorizer) FitTransformSparse(documents []string) ([]SparseVector, error) {
+	words, err := h.word.FitTransformSparse(documents)
+	if err != nil {
+		return nil, fmt.Errorf("error fitting word features: %w", err)
+	}
+
+	chars, err := h.char.FitTransformSparse(documents)
+	if err != nil {
+		return nil, fmt.Errorf("error fitting char features: %w", err)
+	}
+
+	offset := int32(h.word.VocabularySize())
+
+	vectors := make([]SparseVector, len(documents))
+	for i := range documents {
+		vectors[i] = h.combine(words[i], chars[i], offset)
+	}
+
+	return vectors, nil
+}
+
+// TransformSparse converts a document into combined word and char features.
+func (h *HybridVectorizer) TransformSparse(document string) (SparseVector, error) {
+	word, err := h.word.TransformSparse(document)
+	if err != nil {
+		return SparseVector{}, err
+	}
+
+	char, err := h.char.TransformSparse(document)
+	if err != nil {
+		return SparseVector{}, err
+	}
+
+	return h.combine(word, char, int32(h.word.VocabularySize())), nil
+}
+
+// VocabularySize returns the number of word and char features.
+func (h *HybridVectorizer) VocabularySize() int {
+	return h.word.VocabularySize() + h.char.VocabularySize()
+}
+
+// combine appends the char features after the word vocabulary, each part
+// weighted as described on HybridVectorizer.
+func (h *HybridVectorizer) combine(word, char SparseVector, offset int32) SparseVector {
+	vector := SparseVector{
+		Indices: make([]int32, 0, word.Len()+char.Len()),
+		Values:  make([]float64, 0, word.Len()+char.Len()),
+	}
+
+	parts := []struct {
+		v      SparseVector
+		offset int32
+		weight float64
+	}{
+		{word, 0, h.wordWeight},
+		{char, offset, 1 - h.wordWeight},
+	}
+
+	for _, part := range parts {
+		norm := part.v.Norm()
+		if norm == 0 || part.weight == 0 {
+			continue
+		}
+
+		scale := math.Sqrt(part.weight) / norm
+		for i, idx := range part.v.Indices {
+			vector.Indices = append(vector.Indices, idx+part.offset)
+			vector.Values = append(vector.Values, part.v.Values[i]*scale)
+		}
+	}
+
+	return vector
+}
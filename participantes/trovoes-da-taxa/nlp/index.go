@@ -0,0 +1,201 @@
+package nlp
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// posting is the weight of a term in one indexed document.
+type posting struct {
+	doc    int32
+	weight float64
+}
+
+// InvertedIndex finds the documents most similar to a query by visiting only
+// the postings of the query terms, instead of scanning every document vector.
+type InvertedIndex struct {
+	// postings holds, for each vocabulary index, the documents containing the term
+	postings [][]posting
+
+	// norms stores the Euclidean norm of each document vector
+	norms []float64
+}
+
+// NewInvertedIndex creates an index over the given document vectors.
+func NewInvertedIndex(vectors []SparseVector) *InvertedIndex {
+	ix := &InvertedIndex{
+		norms: make([]float64, 0, len(vectors)),
+	}
+
+	for _, v := range vectors {
+		ix.Add(v)
+	}
+
+	return ix
+}
+
+// Add indexes a document vector and returns its document index.
+func (ix *InvertedIndex) Add(v SparseVector) int {
+	doc := int32(len(ix.norms))
+
+	for i, term := range v.Indices {
+		if int(term) >= len(ix.postings) {
+			ix.postings = append(ix.postings, make([][]posting, int(term)+1-len(ix.postings))...)
+		}
+		ix.postings[term] = append(ix.postings[term], posting{doc: doc, weight: v.Values[i]})
+	}
+
+	ix.norms = append(ix.norms, v.Norm())
+
+	return int(doc)
+}
+
+// Len returns the number of indexed documents.
+func (ix *InvertedIndex) Len() int {
+	return len(ix.norms)
+}
+
+// Search returns the k documents with the highest cosine similarity to the
+// query, sorted by similarity (highest first). Ties keep the lowest document
+// index first, and documents sharing no term with the query are returned with
+// similarity 0 when fewer than k documents match, so results match a full
+// FindTopKSimilar scan.
+func (ix *InvertedIndex) Search(query SparseVector, k int) ([]SimilarityResult, error) {
+	if ix.Len() == 0 {
+		return nil, fmt.Errorf("vector collection is empty")
+	}
+
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+
+	k = min(k, ix.Len())
+
+	acc := accumulatorPool.Get().(*accumulator)
+	defer accumulatorPool.Put(acc)
+	acc.reset(ix.Len())
+
+	// Accumulate dot products only for documents sharing a term with the query
+	for i, term := range query.Indices {
+		if int(term) >= len(ix.postings) {
+			continue
+		}
+
+		weight := query.Values[i]
+		for _, p := range ix.postings[term] {
+			acc.add(p.doc, weight*p.weight)
+		}
+	}
+
+	queryNorm := query.Norm()
+
+	top := make(topKHeap, 0, k)
+	for _, doc := range acc.touched {
+		similarity := 0.0
+		if queryNorm > 0 && ix.norms[doc] > 0 {
+			similarity = clampSimilarity(acc.scores[doc] / (queryNorm * ix.norms[doc]))
+		}
+
+		top.offer(SimilarityResult{Index: int(doc), Similarity: similarity}, k)
+	}
+
+	// Documents without shared terms score 0; they only enter the top k while
+	// it is not full or holds a zero score with a higher document index
+	for doc := 0; doc < ix.Len(); doc++ {
+		if len(top) == k && (top[0].Similarity > 0 || top[0].Index < doc) {
+			break
+		}
+
+		if !acc.seen[doc] {
+			top.offer(SimilarityResult{Index: doc}, k)
+		}
+	}
+
+	return top.sorted(), nil
+}
+
+// accumulator holds per-document dot products during a search. It is pooled
+// and only the touched entries are cleared, so a query costs no allocation
+// proportional to the index size.
+type accumulator struct {
+	scores  []float64
+	seen    []bool
+	touched []int32
+}
+
+var accumulatorPool = sync.Pool{New: func() any { return new(accumulator) }}
+
+func (a *accumulator) reset(size int) {
+	for _, doc := range a.touched {
+		a.scores[doc] = 0
+		a.seen[doc] = false
+	}
+	a.touched = a.touched[:0]
+
+	if len(a.scores) < size {
+		a.scores = make([]float64, size)
+		a.seen = make([]bool, size)
+	}
+}
+
+func (a *accumulator) add(doc int32, value float64) {
+	if !a.seen[doc] {
+		a.seen[doc] = true
+		a.touched = append(a.touched, doc)
+	}
+	a.scores[doc] += value
+}
+
+// topKHeap is a min-heap keeping the best k results seen; the root is the
+// worst of them.
+type topKHeap []SimilarityResult
+
+// better reports whether a ranks before b: higher similarity, then lower index.
+func better(a, b SimilarityResult) bool {
+	if a.Similarity != b.Similarity {
+		return a.Similarity > b.Similarity
+	}
+	return a.Index < b.Index
+}
+
+func (h topKHeap) Len() int           { return len(h) }
+func (h topKHeap) Less(i, j int) bool { return better(h[j], h[i]) }
+func (h topKHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *topKHeap) Push(x any)        { *h = append(*h, x.(SimilarityResult)) }
+func (h *topKHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// offer adds r when the heap holds fewer than k results or r beats the worst one.
+func (h *topKHeap) offer(r SimilarityResult, k int) {
+	if len(*h) < k {
+		heap.Push(h, r)
+		return
+	}
+
+	if better(r, (*h)[0]) {
+		(*h)[0] = r
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted returns the results ordered best first.
+func (h topKHeap) sorted() []SimilarityResult {
+	results := []SimilarityResult(h)
+	slices.SortFunc(results, func(a, b SimilarityResult) int {
+		if better(a, b) {
+			return -1
+		}
+		if better(b, a) {
+			return 1
+		}
+		return 0
+	})
+	return results
+}
@@ -0,0 +1,140 @@
+package nlp
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// benchmarkSizes are the example set sizes compared by the benchmarks.
+var benchmarkSizes = []int{580, 10_000, 100_000}
+
+// syntheticCorpus returns n preprocessed documents. The first ones are the
+// data-set.csv intents; the rest mix their words with synthetic terms so the
+// vocabulary keeps growing with the corpus, as it would with real examples.
+func syntheticCorpus(tb testing.TB, n int) []string {
+	tb.Helper()
+
+	intents, _ := loadDataset(tb)
+
+	p, err := NewPreprocessor("portuguese")
+	if err != nil {
+		tb.Fatalf("NewPreprocessor() error = %v", err)
+	}
+
+	processed := p.ProcessBatch(intents)
+
+	var words []string
+	for _, doc := range processed {
+		words = append(words, strings.Fields(doc)...)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	syntheticVocabulary := max(n/5, 1)
+
+	docs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if i < len(processed) {
+			docs = append(docs, processed[i])
+			continue
+		}
+
+		terms := make([]string, 0, 6)
+		for range 2 + rng.IntN(3) {
+			terms = append(terms, words[rng.IntN(len(words))])
+		}
+		for range 1 + rng.IntN(2) {
+			terms = append(terms, fmt.Sprintf("t%d", rng.IntN(syntheticVocabulary)))
+		}
+
+		docs = append(docs, strings.Join(terms, " "))
+	}
+
+	return docs
+}
+
+func BenchmarkSearch(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		docs := syntheticCorpus(b, n)
+
+		v := NewTFIDFVectorizer(true)
+		sparse, err := v.FitTransformSparse(docs)
+		if err != nil {
+			b.Fatalf("FitTransformSparse() error = %v", err)
+		}
+
+		queries := make([]SparseVector, 100)
+		for i := range queries {
+			queries[i], _ = v.TransformSparse(docs[(i*7919)%len(docs)])
+		}
+
+		b.Run(fmt.Sprintf("index/n=%d", n), func(b *testing.B) {
+			index := NewInvertedIndex(sparse)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := index.Search(queries[i%len(queries)], 5); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("dense/n=%d", n), func(b *testing.B) {
+			// Dense vectors need n × vocabulary × 8 bytes
+			if n > 10_000 {
+				b.Skipf("dense vectors for %d examples and %d terms need %d MB", n, v.VocabularySize(), n*v.VocabularySize()*8>>20)
+			}
+
+			dense := make([][]float64, len(sparse))
+			for i, vec := range sparse {
+				dense[i] = vec.Dense(v.VocabularySize())
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)].Dense(v.VocabularySize())
+				if _, err := FindTopKSimilar(q, dense, 5); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPipelinePredict(b *testing.B) {
+	intents, _ := loadDataset(b)
+
+	for _, n := range benchmarkSizes {
+		docs := syntheticCorpus(b, n)
+		categories := make([]string, n)
+		for i := range categories {
+			categories[i] = fmt.Sprint(i%16 + 1)
+		}
+
+		pipeline, err := NewPipeline("portuguese", true)
+		if err != nil {
+			b.Fatalf("NewPipeline() error = %v", err)
+		}
+		pipeline.Preprocessor.SetStemmer(NoopStemmer{})
+
+		if err := pipeline.Train(docs, categories); err != nil {
+			b.Fatalf("Train() error = %v", err)
+		}
+
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, _, err := pipeline.Predict(intents[i%len(intents)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package nlp
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"testing"
+)
+
+// loadDataset reads the intents of data-set.csv.
+func loadDataset(tb testing.TB) (intents, categories []string) {
+	tb.Helper()
+
+	file, err := os.Open("data-set.csv")
+	if err != nil {
+		tb.Fatalf("open data-set.csv: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = ';'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		tb.Fatalf("read data-set.csv: %v", err)
+	}
+
+	for _, record := range records[1:] {
+		categories = append(categories, record[0])
+		intents = append(intents, record[2])
+	}
+
+	return intents, categories
+}
+
+func TestSparseVector(t *testing.T) {
+	a := SparseVector{Indices: []int32{0, 2, 5}, Values: []float64{1, 2, 3}}
+	b := SparseVector{Indices: []int32{2, 3, 5}, Values: []float64{4, 1, 2}}
+
+	if got := a.Dot(b); got != 14 {
+		t.Errorf("Dot() = %v, want 14", got)
+	}
+
+	if got := a.Norm(); math.Abs(got-math.Sqrt(14)) > 1e-12 {
+		t.Errorf("Norm() = %v, want %v", got, math.Sqrt(14))
+	}
+
+	dense := a.Dense(6)
+	want := []float64{1, 0, 2, 0, 0, 3}
+	for i := range want {
+		if dense[i] != want[i] {
+			t.Fatalf("Dense() = %v, want %v", dense, want)
+		}
+	}
+
+	denseSim, err := CosineSimilarity(a.Dense(6), b.Dense(6))
+	if err != nil {
+		t.Fatalf("CosineSimilarity() error = %v", err)
+	}
+
+	if got := SparseCosineSimilarity(a, b); math.Abs(got-denseSim) > 1e-12 {
+		t.Errorf("SparseCosineSimilarity() = %v, want %v", got, denseSim)
+	}
+}
+
+func TestTransformSparseMatchesDense(t *testing.T) {
+	intents, _ := loadDataset(t)
+
+	v := NewTFIDFVectorizer(true)
+	if err := v.Fit(intents); err != nil {
+		t.Fatalf("Fit() error = %v", err)
+	}
+
+	for _, doc := range intents[:50] {
+		dense, err := v.Transform(doc)
+		if err != nil {
+			t.Fatalf("Transform() error = %v", err)
+		}
+
+		sparse, err := v.TransformSparse(doc)
+		if err != nil {
+			t.Fatalf("TransformSparse() error = %v", err)
+		}
+
+		for i := 1; i < sparse.Len(); i++ {
+			if sparse.Indices[i-1] >= sparse.Indices[i] {
+				t.Fatalf("TransformSparse(%q) indices not sorted: %v", doc, sparse.Indices)
+			}
+		}
+
+		expanded := sparse.Dense(len(dense))
+		for i := range dense {
+			if math.Abs(dense[i]-expanded[i]) > 1e-12 {
+				t.Fatalf("TransformSparse(%q) differs from Transform at %d: %v != %v", doc, i, expanded[i], dense[i])
+			}
+		}
+	}
+}
+
+func TestInvertedIndexMatchesBruteForce(t *testing.T) {
+	intents, _ := loadDataset(t)
+
+	v := NewTFIDFVectorizer(true)
+	sparse, err := v.FitTransformSparse(intents)
+	if err != nil {
+		t.Fatalf("FitTransformSparse() error = %v", err)
+	}
+
+	dense := make([][]float64, len(sparse))
+	for i, vec := range sparse {
+		dense[i] = vec.Dense(v.VocabularySize())
+	}
+
+	index := NewInvertedIndex(sparse)
+
+	queries := append([]string{"", "palavra desconhecida"}, intents[:100]...)
+	for _, k := range []int{1, 5} {
+		for _, query := range queries {
+			q, err := v.TransformSparse(query)
+			if err != nil {
+				t.Fatalf("TransformSparse() error = %v", err)
+			}
+
+			want, err := FindTopKSimilar(q.Dense(v.VocabularySize()), dense, k)
+			if err != nil {
+				t.Fatalf("FindTopKSimilar() error = %v", err)
+			}
+
+			got, err := index.Search(q, k)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("Search(%q, %d) returned %d results, want %d", query, k, len(got), len(want))
+			}
+
+			for i := range want {
+				if got[i].Index != want[i].Index || math.Abs(got[i].Similarity-want[i].Similarity) > 1e-9 {
+					t.Errorf("Search(%q, %d)[%d] = %+v, want %+v", query, k, i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestInvertedIndexErrors(t *testing.T) {
+	if _, err := NewInvertedIndex(nil).Search(SparseVector{}, 1); err == nil {
+		t.Error("Search() on empty index error = nil, want error")
+	}
+
+	index := NewInvertedIndex([]SparseVector{{Indices: []int32{0}, Values: []float64{1}}})
+	if _, err := index.Search(SparseVector{}, 0); err == nil {
+		t.Error("Search() with k = 0 error = nil, want error")
+	}
+}
+
+func TestPipelinePredict(t *testing.T) {
+	intents, categories := loadDataset(t)
+
+	pipeline, err := NewPipeline("portuguese", true)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	if _, _, err := pipeline.Predict("fatura"); err == nil {
+		t.Error("Predict() before Train error = nil, want error")
+	}
+
+	if err := pipeline.Train(intents, categories); err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+
+	match, similarity, err := pipeline.Predict(intents[0])
+	if err != nil {
+		t.Fatalf("Predict() error = %v", err)
+	}
+
+	if match.Category != categories[0] || math.Abs(similarity-1) > 1e-9 {
+		t.Errorf("Predict(%q) = %s (%.3f), want %s (1.000)", intents[0], match.Category, similarity, categories[0])
+	}
+
+	matches, similarities, err := pipeline.PredictTopK(intents[0], 3)
+	if err != nil {
+		t.Fatalf("PredictTopK() error = %v", err)
+	}
+
+	if len(matches) != 3 || similarities[0] < similarities[1] || similarities[1] < similarities[2] {
+		t.Errorf("PredictTopK() similarities = %v, want 3 in descending order", similarities)
+	}
+}
+
+func TestPipelineRetrain(t *testing.T) {
+	intents, categories := loadDataset(t)
+	half := len(intents) / 2
+
+	pipeline, err := NewPipeline("portuguese", true, WithVectorizer(NewCharNGramVectorizer(3, 5, true)))
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	pipeline.Labels["1"] = "one"
+
+	if err := pipeline.Train(intents[:half], categories[:half]); err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+	vocabulary := pipeline.Vectorizer.VocabularySize()
+
+	retrained, err := pipeline.Retrain(intents, categories)
+	if err != nil {
+		t.Fatalf("Retrain() error = %v", err)
+	}
+
+	// The original pipeline keeps serving the old model
+	if pipeline.Vectorizer.VocabularySize() != vocabulary || len(pipeline.IntentVectors) != half {
+		t.Errorf("Retrain() modified the original pipeline")
+	}
+
+	if _, ok := retrained.Vectorizer.(*TFIDFVectorizer); !ok || retrained.Labels["1"] != "one" {
+		t.Errorf("Retrain() vectorizer = %T, labels = %v, want the original configuration", retrained.Vectorizer, retrained.Labels)
+	}
+
+	last := len(intents) - 1
+	match, similarity, err := retrained.Predict(intents[last])
+	if err != nil {
+		t.Fatalf("Predict() error = %v", err)
+	}
+
+	if match.Category != categories[last] || math.Abs(similarity-1) > 1e-9 {
+		t.Errorf("Predict(%q) = %s (%.3f), want %s (1.000)", intents[last], match.Category, similarity, categories[last])
+	}
+}
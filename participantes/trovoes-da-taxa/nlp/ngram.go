@@ -0,0 +1,28 @@
+package nlp
+
+import "strings"
+
+// charNGrams returns the character n-grams of each word of the document, with
+// sizes from minN to maxN. Words are padded with a space on each side so
+// n-grams at word boundaries differ from those inside words; a padded word
+// shorter than n yields itself once.
+func charNGrams(document string, minN, maxN int) []string {
+	var ngrams []string
+
+	for _, word := range strings.Fields(document) {
+		padded := []rune(" " + word + " ")
+
+		for n := minN; n <= maxN; n++ {
+			if len(padded) <= n {
+				ngrams = append(ngrams, string(padded))
+				break
+			}
+
+			for i := 0; i+n <= len(padded); i++ {
+				ngrams = append(ngrams, string(padded[i:i+n]))
+			}
+		}
+	}
+
+	return ngrams
+}
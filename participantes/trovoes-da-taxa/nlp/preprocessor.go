@@ -9,17 +9,25 @@ import (
 
 // Preprocessor handles text preprocessing operations like stemming and stopword removal.
 type Preprocessor struct {
-	lang string
+	lang    string
+	stemmer Stemmer
 }
 
 // NewPreprocessor creates a new preprocessor for the specified language.
 // Supported languages: "portuguese", "english", etc.
+// Words are stemmed with RSLPStemmer unless another stemmer is set with SetStemmer.
 func NewPreprocessor(lang string) (*Preprocessor, error) {
 	return &Preprocessor{
-		lang: mapLanguage(lang),
+		lang:    mapLanguage(lang),
+		stemmer: NewRSLPStemmer(),
 	}, nil
 }
 
+// SetStemmer replaces the stemmer used by Stem and Process.
+func (p *Preprocessor) SetStemmer(stemmer Stemmer) {
+	p.stemmer = stemmer
+}
+
 // mapLanguage maps full language names to stopwords package codes.
 func mapLanguage(lang string) string {
 	mapping := map[string]string{
@@ -46,14 +54,13 @@ func stemPortuguese(word string) string {
 	// List of common Portuguese suffixes ordered by length (longest first)
 	suffixes := []string{
 		"amentos", "imentos", "amento", "imento", "adora", "ância",
-		"ância", "ências", "amento", "imento", "adora", "antes",
-		"ância", "ência", "adora", "mente", "idade", "eiras",
+		"ências", "antes", "ência", "mente", "idade", "eiras",
 		"ador", "ante", "ível", "eira", "osos", "osas", "ação",
 		"ções", "ente", "ista", "ezas", "eza", "ica", "ico",
-		"ada", "ado", "ida", "ido", "ura", "ura", "ara",
-		"ira", "ava", "iam", "ado", "ido", "oso", "osa",
-		"osa", "oso", "ção", "são", "vel", "eis", "ais",
-		"amos", "emos", "imos", "emos", "emos", "ia",
+		"ada", "ado", "ida", "ido", "ura", "ara",
+		"ira", "ava", "iam", "oso", "osa",
+		"ção", "são", "vel", "eis", "ais",
+		"amos", "emos", "imos", "ia",
 		"as", "es", "is", "os", "us", "a", "e", "i", "o", "u",
 	}
 
@@ -76,8 +83,8 @@ func stemPortuguese(word string) string {
 	return word
 }
 
-// Stem reduces words to their root form using a simplified stemming algorithm.
-// Example: "pagamento" -> "pag", "correção" -> "corre"
+// Stem reduces words to their root form using the configured stemmer.
+// Example with RSLP: "cancelamento", "cancelei" and "cancelar" -> "cancel"
 func (p *Preprocessor) Stem(text string) string {
 	words := strings.Fields(text)
 	stemmedWords := make([]string, 0, len(words))
@@ -99,7 +106,7 @@ func (p *Preprocessor) Stem(text string) string {
 			continue
 		}
 
-		stemmed := stemPortuguese(cleaned)
+		stemmed := p.stemmer.Stem(cleaned)
 		stemmedWords = append(stemmedWords, stemmed)
 	}
 
@@ -0,0 +1,373 @@
+package nlp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// rslpRule removes suffix from words whose remaining stem has at least
+// minStem letters, appending replacement. Words listed in exceptions are kept.
+type rslpRule struct {
+	suffix      string
+	minStem     int
+	replacement string
+	exceptions  map[string]struct{}
+}
+
+// rslpStep is an ordered list of rules; only the first applicable rule is used.
+type rslpStep []rslpRule
+
+func newRSLPRule(suffix string, minStem int, replacement string, exceptions ...string) rslpRule {
+	r := rslpRule{
+		suffix:      suffix,
+		minStem:     minStem,
+		replacement: replacement,
+		exceptions:  make(map[string]struct{}, len(exceptions)),
+	}
+
+	for _, e := range exceptions {
+		r.exceptions[e] = struct{}{}
+	}
+
+	return r
+}
+
+// apply runs the first rule whose suffix, minimum stem size and exceptions
+// match the word. It reports whether the word was changed.
+func (s rslpStep) apply(word string) (string, bool) {
+	for _, r := range s {
+		if !strings.HasSuffix(word, r.suffix) {
+			continue
+		}
+
+		stem := word[:len(word)-len(r.suffix)]
+		if utf8.RuneCountInString(stem) < r.minStem {
+			continue
+		}
+
+		if _, ok := r.exceptions[word]; ok {
+			continue
+		}
+
+		return stem + r.replacement, true
+	}
+
+	return word, false
+}
+
+// RSLPStemmer implements the Removedor de Sufixos da Língua Portuguesa
+// (Orengo & Huyck, 2001). Words go through plural, feminine, augmentative,
+// adverb, noun, verb and vowel reduction steps, each with its own exception
+// list. Verb and vowel reduction only run when no noun suffix was removed.
+type RSLPStemmer struct{}
+
+// NewRSLPStemmer creates a Portuguese RSLP stemmer.
+func NewRSLPStemmer() *RSLPStemmer {
+	return &RSLPStemmer{}
+}
+
+// Name implements Stemmer.
+func (s *RSLPStemmer) Name() string {
+	return "rslp"
+}
+
+// Stem implements Stemmer. The word is expected in lowercase.
+func (s *RSLPStemmer) Stem(word string) string {
+	if strings.HasSuffix(word, "s") {
+		word, _ = rslpPlural.apply(word)
+	}
+
+	if strings.HasSuffix(word, "a") {
+		word, _ = rslpFeminine.apply(word)
+	}
+
+	word, _ = rslpAugmentative.apply(word)
+	word, _ = rslpAdverb.apply(word)
+
+	var changed bool
+	if word, changed = rslpNoun.apply(word); changed {
+		return word
+	}
+
+	if word, changed = rslpVerb.apply(word); changed {
+		return word
+	}
+
+	word, _ = rslpVowel.apply(word)
+
+	return word
+}
+
+var rslpPlural = rslpStep{
+	newRSLPRule("ns", 1, "m"),
+	newRSLPRule("ões", 3, "ão"),
+	newRSLPRule("ães", 1, "ão", "mães"),
+	newRSLPRule("ais", 1, "al", "cais", "mais"),
+	newRSLPRule("éis", 2, "el"),
+	newRSLPRule("eis", 2, "el"),
+	newRSLPRule("óis", 2, "ol"),
+	newRSLPRule("is", 2, "il", "lápis", "cais", "mais", "crúcis", "biquínis", "pois", "depois", "dois", "leis"),
+	newRSLPRule("les", 3, "l"),
+	newRSLPRule("res", 3, "r", "árvores"),
+	newRSLPRule("s", 2, "", "aliás", "pires", "lápis", "cais", "mais", "mas", "menos", "férias", "fezes", "pêsames",
+		"crúcis", "gás", "atrás", "moisés", "através", "convés", "ês", "país", "após", "ambas", "ambos",
+		"messias", "depois"),
+}
+
+var rslpFeminine = rslpStep{
+	newRSLPRule("ona", 3, "ão", "abandona", "lona", "iona", "cortisona", "monótona", "maratona", "acetona", "detona", "carona"),
+	newRSLPRule("ora", 3, "or"),
+	newRSLPRule("na", 4, "no", "carona", "abandona", "lona", "iona", "cortisona", "monótona", "maratona", "acetona",
+		"detona", "guiana", "campana", "grana", "caravana", "banana", "paisana"),
+	newRSLPRule("inha", 3, "inho", "rainha", "linha", "minha"),
+	newRSLPRule("esa", 3, "ês", "mesa", "obesa", "princesa", "turquesa", "ilesa", "pesa", "presa"),
+	newRSLPRule("osa", 3, "oso", "mucosa", "prosa"),
+	newRSLPRule("íaca", 3, "íaco"),
+	newRSLPRule("ica", 3, "ico", "dica"),
+	newRSLPRule("ada", 2, "ado", "pitada"),
+	newRSLPRule("ida", 3, "ido", "vida"),
+	newRSLPRule("ída", 3, "ido", "recaída", "saída", "dúvida"),
+	newRSLPRule("ima", 3, "imo", "vítima"),
+	newRSLPRule("iva", 3, "ivo", "saliva", "oliva"),
+	newRSLPRule("eira", 3, "eiro", "beira", "cadeira", "frigideira", "bandeira", "feira", "capoeira", "barreira",
+		"fronteira", "besteira", "poeira"),
+}
+
+var rslpAugmentative = rslpStep{
+	newRSLPRule("díssimo", 5, ""),
+	newRSLPRule("abilíssimo", 5, ""),
+	newRSLPRule("íssimo", 3, ""),
+	newRSLPRule("ésimo", 3, ""),
+	newRSLPRule("érrimo", 4, ""),
+	newRSLPRule("zinho", 2, ""),
+	newRSLPRule("quinho", 4, "c"),
+	newRSLPRule("uinho", 4, ""),
+	newRSLPRule("adinho", 3, ""),
+	newRSLPRule("inho", 3, "", "caminho", "cominho"),
+	newRSLPRule("alhão", 4, ""),
+	newRSLPRule("uça", 4, ""),
+	newRSLPRule("aço", 4, "", "antebraço"),
+	newRSLPRule("aça", 4, ""),
+	newRSLPRule("adão", 4, ""),
+	newRSLPRule("idão", 4, ""),
+	newRSLPRule("ázio", 3, "", "topázio"),
+	newRSLPRule("arraz", 4, ""),
+	newRSLPRule("zarrão", 3, ""),
+	newRSLPRule("arrão", 4, ""),
+	newRSLPRule("zão", 2, "", "coalizão"),
+	newRSLPRule("ão", 3, "", "camarão", "chimarrão", "canção", "coração", "embrião", "grotão", "glutão", "ficção",
+		"fogão", "feição", "furacão", "gamão", "lampião", "leão", "macacão", "nação", "órfão", "orgão",
+		"patrão", "portão", "quinhão", "rincão", "tração", "falcão", "espião", "mamão", "folião", "cordão",
+		"aptidão", "campeão", "colchão", "limão", "leilão", "melão", "barão", "milhão", "bilhão", "fusão",
+		"cristão", "ilusão", "capitão", "estação", "senão"),
+}
+
+var rslpAdverb = rslpStep{
+	newRSLPRule("mente", 4, "", "experimente"),
+}
+
+var rslpNoun = rslpStep{
+	newRSLPRule("encialista", 4, ""),
+	newRSLPRule("alista", 5, ""),
+	newRSLPRule("agem", 3, "", "coragem", "chantagem", "vantagem", "carruagem"),
+	newRSLPRule("iamento", 4, ""),
+	newRSLPRule("amento", 3, "", "firmamento", "fundamento", "departamento"),
+	newRSLPRule("imento", 3, ""),
+	newRSLPRule("mento", 6, "", "firmamento", "elemento", "complemento", "instrumento", "departamento"),
+	newRSLPRule("alizado", 4, ""),
+	newRSLPRule("atizado", 4, ""),
+	newRSLPRule("tizado", 4, "", "alfabetizado"),
+	newRSLPRule("izado", 5, "", "organizado", "pulverizado"),
+	newRSLPRule("ativo", 4, "", "pejorativo", "relativo"),
+	newRSLPRule("tivo", 4, "", "relativo"),
+	newRSLPRule("ivo", 4, "", "passivo", "possessivo", "pejorativo", "positivo"),
+	newRSLPRule("ado", 2, "", "grado"),
+	newRSLPRule("ido", 3, "", "cândido", "consolido", "rápido", "decido", "tímido", "duvido", "marido"),
+	newRSLPRule("ador", 3, ""),
+	newRSLPRule("edor", 3, ""),
+	newRSLPRule("idor", 4, "", "ouvidor"),
+	newRSLPRule("dor", 4, "", "ouvidor"),
+	newRSLPRule("sor", 4, "", "assessor"),
+	newRSLPRule("atoria", 5, ""),
+	newRSLPRule("tor", 3, "", "benfeitor", "leitor", "editor", "pastor", "produtor", "promotor", "consultor"),
+	newRSLPRule("or", 2, "", "motor", "melhor", "redor", "rigor", "sensor", "tambor", "tumor", "assessor", "benfeitor",
+		"leitor", "editor", "pastor", "produtor", "promotor", "consultor"),
+	newRSLPRule("abilidade", 5, ""),
+	newRSLPRule("icionista", 4, ""),
+	newRSLPRule("cionista", 5, ""),
+	newRSLPRule("ionista", 5, ""),
+	newRSLPRule("ionar", 5, ""),
+	newRSLPRule("ional", 4, ""),
+	newRSLPRule("ência", 3, ""),
+	newRSLPRule("ância", 4, "", "ambulância"),
+	newRSLPRule("edouro", 3, ""),
+	newRSLPRule("queiro", 3, "c"),
+	newRSLPRule("adeiro", 4, "", "desfiladeiro"),
+	newRSLPRule("eiro", 3, "", "desfiladeiro", "pioneiro", "mosteiro"),
+	newRSLPRule("uoso", 3, ""),
+	newRSLPRule("oso", 3, "", "precioso"),
+	newRSLPRule("alizaç", 5, ""),
+	newRSLPRule("atizaç", 5, ""),
+	newRSLPRule("tizaç", 5, ""),
+	newRSLPRule("izaç", 5, "", "organizaç"),
+	newRSLPRule("aç", 3, "", "equaç", "relaç"),
+	newRSLPRule("iç", 3, "", "eleiç"),
+	newRSLPRule("ário", 3, "", "voluntário", "salário", "aniversário", "diário", "lionário", "armário"),
+	newRSLPRule("atório", 3, ""),
+	newRSLPRule("rio", 5, "", "voluntário", "salário", "aniversário", "diário", "compulsório", "lionário", "próprio",
+		"stério", "armário"),
+	newRSLPRule("ério", 6, ""),
+	newRSLPRule("ês", 4, ""),
+	newRSLPRule("eza", 3, ""),
+	newRSLPRule("ez", 4, ""),
+	newRSLPRule("esco", 4, ""),
+	newRSLPRule("ante", 2, "", "gigante", "elefante", "adiante", "possante", "instante", "restaurante"),
+	newRSLPRule("ástico", 4, "", "eclesiástico"),
+	newRSLPRule("alístico", 3, ""),
+	newRSLPRule("áutico", 4, ""),
+	newRSLPRule("êutico", 4, ""),
+	newRSLPRule("tico", 3, "", "político", "eclesiástico", "diagnostico", "prático", "doméstico", "diagnóstico",
+		"idêntico", "alopático", "artístico", "autêntico", "eclético", "crítico", "critico"),
+	newRSLPRule("ico", 4, "", "tico", "público", "explico"),
+	newRSLPRule("ividade", 5, ""),
+	newRSLPRule("idade", 4, "", "autoridade", "comunidade"),
+	newRSLPRule("oria", 4, "", "categoria"),
+	newRSLPRule("encial", 5, ""),
+	newRSLPRule("ista", 4, ""),
+	newRSLPRule("auta", 5, ""),
+	newRSLPRule("quice", 4, "c"),
+	newRSLPRule("ice", 4, "", "cúmplice"),
+	newRSLPRule("íaco", 3, ""),
+	newRSLPRule("ente", 4, "", "freqüente", "alimente", "acrescente", "permanente", "oriente", "aparente"),
+	newRSLPRule("ense", 5, ""),
+	newRSLPRule("inal", 3, ""),
+	newRSLPRule("ano", 4, ""),
+	newRSLPRule("ável", 2, "", "afável", "razoável", "potável", "vulnerável"),
+	newRSLPRule("ível", 3, "", "possível"),
+	newRSLPRule("vel", 5, "", "possível", "vulnerável", "solúvel"),
+	newRSLPRule("bil", 3, "vel"),
+	newRSLPRule("ura", 4, "", "imatura", "acupuntura", "costura"),
+	newRSLPRule("ural", 4, ""),
+	newRSLPRule("ual", 3, "", "bissexual", "virtual", "visual", "pontual"),
+	newRSLPRule("ial", 3, ""),
+	newRSLPRule("al", 4, "", "afinal", "animal", "estatal", "bissexual", "desleal", "fiscal", "formal", "pessoal",
+		"liberal", "postal", "virtual", "visual", "pontual", "sideral", "sucursal"),
+	newRSLPRule("alismo", 4, ""),
+	newRSLPRule("ivismo", 4, ""),
+	newRSLPRule("ismo", 3, "", "cinismo"),
+}
+
+var rslpVerb = rslpStep{
+	newRSLPRule("aríamo", 2, ""),
+	newRSLPRule("ássemo", 2, ""),
+	newRSLPRule("eríamo", 2, ""),
+	newRSLPRule("êssemo", 2, ""),
+	newRSLPRule("iríamo", 3, ""),
+	newRSLPRule("íssemo", 3, ""),
+	newRSLPRule("áramo", 2, ""),
+	newRSLPRule("árei", 2, ""),
+	newRSLPRule("aremo", 2, ""),
+	newRSLPRule("ariam", 2, ""),
+	newRSLPRule("aríei", 2, ""),
+	newRSLPRule("ássei", 2, ""),
+	newRSLPRule("assem", 2, ""),
+	newRSLPRule("ávamo", 2, ""),
+	newRSLPRule("êramo", 3, ""),
+	newRSLPRule("eremo", 3, ""),
+	newRSLPRule("eriam", 3, ""),
+	newRSLPRule("eríei", 3, ""),
+	newRSLPRule("êssei", 3, ""),
+	newRSLPRule("essem", 3, ""),
+	newRSLPRule("íramo", 3, ""),
+	newRSLPRule("iremo", 3, ""),
+	newRSLPRule("iriam", 3, ""),
+	newRSLPRule("iríei", 3, ""),
+	newRSLPRule("íssei", 3, ""),
+	newRSLPRule("issem", 3, ""),
+	newRSLPRule("ando", 2, ""),
+	newRSLPRule("endo", 3, ""),
+	newRSLPRule("indo", 3, ""),
+	newRSLPRule("ondo", 3, ""),
+	newRSLPRule("aram", 2, ""),
+	newRSLPRule("arão", 2, ""),
+	newRSLPRule("arde", 2, ""),
+	newRSLPRule("arei", 2, ""),
+	newRSLPRule("arem", 2, ""),
+	newRSLPRule("aria", 2, ""),
+	newRSLPRule("armo", 2, ""),
+	newRSLPRule("asse", 2, ""),
+	newRSLPRule("aste", 2, ""),
+	newRSLPRule("avam", 2, "", "agravam"),
+	newRSLPRule("ávei", 2, ""),
+	newRSLPRule("eram", 3, ""),
+	newRSLPRule("erão", 3, ""),
+	newRSLPRule("erde", 3, ""),
+	newRSLPRule("erei", 3, ""),
+	newRSLPRule("êrei", 3, ""),
+	newRSLPRule("erem", 3, ""),
+	newRSLPRule("eria", 3, ""),
+	newRSLPRule("ermo", 3, ""),
+	newRSLPRule("esse", 3, ""),
+	newRSLPRule("este", 3, "", "faroeste", "agreste"),
+	newRSLPRule("íamo", 3, ""),
+	newRSLPRule("iram", 3, ""),
+	newRSLPRule("íram", 3, ""),
+	newRSLPRule("irão", 2, ""),
+	newRSLPRule("irde", 2, ""),
+	newRSLPRule("irei", 3, "", "admirei"),
+	newRSLPRule("irem", 3, "", "adquirem"),
+	newRSLPRule("iria", 3, ""),
+	newRSLPRule("irmo", 3, ""),
+	newRSLPRule("isse", 3, ""),
+	newRSLPRule("iste", 4, ""),
+	newRSLPRule("iava", 4, "", "ampliava"),
+	newRSLPRule("amo", 2, ""),
+	newRSLPRule("iona", 3, ""),
+	newRSLPRule("ara", 2, "", "arara", "prepara"),
+	newRSLPRule("ará", 2, "", "alvará"),
+	newRSLPRule("are", 2, "", "prepare"),
+	newRSLPRule("ava", 2, "", "agrava"),
+	newRSLPRule("emo", 2, ""),
+	newRSLPRule("era", 3, "", "acelera", "espera"),
+	newRSLPRule("erá", 3, ""),
+	newRSLPRule("ere", 3, "", "espere"),
+	newRSLPRule("iam", 3, "", "enfiam", "ampliam", "elogiam", "ensaiam"),
+	newRSLPRule("íei", 3, ""),
+	newRSLPRule("imo", 3, "", "reprimo", "intimo", "íntimo", "nimo", "queimo", "ximo"),
+	newRSLPRule("ira", 3, ""),
+	newRSLPRule("ído", 3, ""),
+	newRSLPRule("irá", 3, ""),
+	newRSLPRule("tizar", 4, "", "alfabetizar"),
+	newRSLPRule("izar", 5, "", "organizar"),
+	newRSLPRule("itar", 5, "", "acreditar", "explicitar", "estreitar"),
+	newRSLPRule("ire", 3, "", "adquire"),
+	newRSLPRule("omos", 3, ""),
+	newRSLPRule("ai", 2, ""),
+	newRSLPRule("am", 2, ""),
+	newRSLPRule("ear", 4, "", "alardear", "nuclear"),
+	newRSLPRule("ar", 2, "", "azar", "bazaar", "patamar"),
+	newRSLPRule("uei", 3, ""),
+	newRSLPRule("uía", 5, "u"),
+	newRSLPRule("ei", 3, ""),
+	newRSLPRule("guem", 3, "g"),
+	newRSLPRule("em", 2, "", "alem", "virgem"),
+	newRSLPRule("er", 2, "", "éter", "pier"),
+	newRSLPRule("eu", 3, "", "chapeu"),
+	newRSLPRule("ia", 3, "", "estória", "fatia", "acia", "praia", "elogia", "mania", "lábia", "aprecia", "polícia",
+		"arredia", "cheia", "ásia"),
+	newRSLPRule("ir", 3, "", "freir"),
+	newRSLPRule("iu", 3, ""),
+	newRSLPRule("eou", 5, ""),
+	newRSLPRule("ou", 3, ""),
+	newRSLPRule("i", 3, ""),
+}
+
+var rslpVowel = rslpStep{
+	newRSLPRule("bil", 2, "vel"),
+	newRSLPRule("gue", 2, "g", "gangue", "jegue"),
+	newRSLPRule("á", 3, ""),
+	newRSLPRule("ê", 3, "", "bebê"),
+	newRSLPRule("a", 3, "", "ásia"),
+	newRSLPRule("e", 3, ""),
+	newRSLPRule("o", 3, "", "ão"),
+}
@@ -0,0 +1,140 @@
+package nlp
+
+import "testing"
+
+func TestRSLPStemmer(t *testing.T) {
+	tests := []struct {
+		step string
+		word string
+		want string
+	}{
+		// plural
+		{"plural", "cartões", "cart"},
+		{"plural", "pães", "pão"},
+		{"plural", "papéis", "papel"},
+		{"plural", "anéis", "anel"},
+		{"plural", "gatos", "gat"},
+		{"plural", "limites", "limit"},
+		{"plural exception", "lápis", "lápis"},
+
+		// feminine
+		{"feminine", "meninas", "menin"},
+		{"feminine", "cancelada", "cancel"},
+		{"feminine exception", "mesa", "mes"},
+		{"feminine exception", "rainha", "rainh"},
+
+		// augmentative and diminutive
+		{"augmentative", "gatinhos", "gat"},
+		{"augmentative", "cartão", "cart"},
+		{"augmentative exception", "nações", "naçã"},
+
+		// adverb
+		{"adverb", "felizmente", "feliz"},
+		{"adverb", "carinhosamente", "carinhos"},
+
+		// noun
+		{"noun", "cancelamento", "cancel"},
+		{"noun", "pagamento", "pag"},
+		{"noun", "atendimento", "atend"},
+		{"noun", "informação", "inform"},
+		{"noun", "reclamação", "reclam"},
+		{"noun", "beleza", "bel"},
+		{"noun", "tradicionalista", "tradicion"},
+		{"noun", "roubado", "roub"},
+		{"noun exception", "ambulância", "ambulânc"},
+
+		// verb
+		{"verb", "cancelei", "cancel"},
+		{"verb", "cancelar", "cancel"},
+		{"verb", "cantando", "cant"},
+		{"verb", "pagar", "pag"},
+		{"verb", "perdi", "perd"},
+		{"verb", "esqueci", "esquec"},
+		{"verb", "bloquear", "bloqu"},
+
+		// vowel
+		{"vowel", "fatura", "fatur"},
+		{"vowel", "boletos", "bolet"},
+		{"vowel", "senha", "senh"},
+		{"vowel", "saldo", "sald"},
+		{"vowel", "proposta", "propost"},
+	}
+
+	stemmer := NewRSLPStemmer()
+
+	for _, tt := range tests {
+		t.Run(tt.step+"/"+tt.word, func(t *testing.T) {
+			if got := stemmer.Stem(tt.word); got != tt.want {
+				t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRSLPStemmerInflectedVariants(t *testing.T) {
+	groups := [][]string{
+		{"cancelei", "cancelamento", "cancelar", "cancelado", "cancelada"},
+		{"cartão", "cartões"},
+		{"pagamento", "pagar"},
+		{"fatura", "faturas"},
+	}
+
+	stemmer := NewRSLPStemmer()
+
+	for _, group := range groups {
+		want := stemmer.Stem(group[0])
+		for _, word := range group[1:] {
+			if got := stemmer.Stem(word); got != want {
+				t.Errorf("Stem(%q) = %q, want %q like %q", word, got, want, group[0])
+			}
+		}
+	}
+}
+
+func TestNewStemmer(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Stemmer
+		wantErr bool
+	}{
+		{"", NewRSLPStemmer(), false},
+		{"rslp", NewRSLPStemmer(), false},
+		{"simple", SimpleStemmer{}, false},
+		{"none", NoopStemmer{}, false},
+		{"porter", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewStemmer(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStemmer(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.Stem("cancelamento") != tt.want.Stem("cancelamento") {
+				t.Errorf("NewStemmer(%q) returned %T, want %T", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessorSetStemmer(t *testing.T) {
+	p, err := NewPreprocessor("portuguese")
+	if err != nil {
+		t.Fatalf("NewPreprocessor() error = %v", err)
+	}
+
+	if got := p.Stem("Cancelei cartões"); got != "cancel cart" {
+		t.Errorf("Stem() with RSLP = %q, want %q", got, "cancel cart")
+	}
+
+	p.SetStemmer(NoopStemmer{})
+
+	if got := p.Stem("Cancelei cartões!"); got != "cancelei cartões" {
+		t.Errorf("Stem() without stemming = %q, want %q", got, "cancelei cartões")
+	}
+}
@@ -99,28 +99,18 @@ func FindTopKSimilar(query []float64, vectors [][]float64, k int) ([]SimilarityR
 		k = len(vectors)
 	}
 
-	// Calculate all similarities
-	results := make([]SimilarityResult, len(vectors))
+	// Keep the best K similarities in a min-heap
+	top := make(topKHeap, 0, k)
 	for i, vec := range vectors {
 		similarity, err := CosineSimilarity(query, vec)
 		if err != nil {
 			return nil, fmt.Errorf("error calculating similarity with vector %d: %w", i, err)
 		}
-		results[i] = SimilarityResult{
+		top.offer(SimilarityResult{
 			Index:      i,
 			Similarity: similarity,
-		}
-	}
-
-	// Sort by similarity (descending)
-	// Using a simple bubble sort for the top K elements (efficient for small K)
-	for i := 0; i < k; i++ {
-		for j := i + 1; j < len(results); j++ {
-			if results[j].Similarity > results[i].Similarity {
-				results[i], results[j] = results[j], results[i]
-			}
-		}
+		}, k)
 	}
 
-	return results[:k], nil
+	return top.sorted(), nil
 }
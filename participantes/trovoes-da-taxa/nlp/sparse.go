@@ -0,0 +1,76 @@
+package nlp
+
+import "math"
+
+// SparseVector stores only the non-zero entries of a vector.
+// Indices are vocabulary indices sorted in ascending order.
+type SparseVector struct {
+	Indices []int32
+	Values  []float64
+}
+
+// Len returns the number of non-zero entries.
+func (v SparseVector) Len() int {
+	return len(v.Indices)
+}
+
+// Dot returns the dot product of two sparse vectors.
+func (v SparseVector) Dot(other SparseVector) float64 {
+	var dot float64
+
+	i, j := 0, 0
+	for i < len(v.Indices) && j < len(other.Indices) {
+		switch {
+		case v.Indices[i] == other.Indices[j]:
+			dot += v.Values[i] * other.Values[j]
+			i++
+			j++
+		case v.Indices[i] < other.Indices[j]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return dot
+}
+
+// Norm returns the Euclidean norm of the vector.
+func (v SparseVector) Norm() float64 {
+	var sum float64
+	for _, value := range v.Values {
+		sum += value * value
+	}
+	return math.Sqrt(sum)
+}
+
+// Dense expands the vector to a dense slice of the given size.
+func (v SparseVector) Dense(size int) []float64 {
+	dense := make([]float64, size)
+	for i, idx := range v.Indices {
+		if int(idx) < size {
+			dense[idx] = v.Values[i]
+		}
+	}
+	return dense
+}
+
+// SparseCosineSimilarity calculates the cosine similarity between two sparse
+// vectors, clamped to [0, 1] like CosineSimilarity.
+func SparseCosineSimilarity(vec1, vec2 SparseVector) float64 {
+	norm1, norm2 := vec1.Norm(), vec2.Norm()
+	if norm1 == 0 || norm2 == 0 {
+		return 0
+	}
+
+	return clampSimilarity(vec1.Dot(vec2) / (norm1 * norm2))
+}
+
+func clampSimilarity(similarity float64) float64 {
+	if similarity > 1.0 {
+		return 1.0
+	} else if similarity < 0.0 {
+		return 0.0
+	}
+	return similarity
+}
@@ -0,0 +1,52 @@
+package nlp
+
+import "fmt"
+
+// Stemmer reduces a single lowercase word to its stem.
+type Stemmer interface {
+	Stem(word string) string
+
+	// Name returns the name the stemmer is registered under in NewStemmer.
+	Name() string
+}
+
+// SimpleStemmer strips the first matching suffix of a fixed list.
+// It is kept for comparison with RSLPStemmer.
+type SimpleStemmer struct{}
+
+// Stem implements Stemmer.
+func (SimpleStemmer) Stem(word string) string {
+	return stemPortuguese(word)
+}
+
+// Name implements Stemmer.
+func (SimpleStemmer) Name() string {
+	return "simple"
+}
+
+// NoopStemmer returns words unchanged.
+type NoopStemmer struct{}
+
+// Stem implements Stemmer.
+func (NoopStemmer) Stem(word string) string {
+	return word
+}
+
+// Name implements Stemmer.
+func (NoopStemmer) Name() string {
+	return "none"
+}
+
+// NewStemmer returns the stemmer registered under name: "rslp", "simple" or "none".
+func NewStemmer(name string) (Stemmer, error) {
+	switch name {
+	case "rslp", "":
+		return NewRSLPStemmer(), nil
+	case "simple":
+		return SimpleStemmer{}, nil
+	case "none":
+		return NoopStemmer{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown stemmer %q (available: rslp, simple, none)", name)
+}
@@ -1,6 +1,9 @@
 package nlp
 
-import "fmt"
+import (
+	"fmt"
+	"maps"
+)
 
 // IntentVector represents a preprocessed intent with its TF-IDF vector.
 type IntentVector struct {
@@ -10,8 +13,8 @@ type IntentVector struct {
 	// Processed is the text after preprocessing (lowercase, no stopwords, stemmed)
 	Processed string
 
-	// Vector is the TF-IDF vector representation
-	Vector []float64
+	// Vector is the sparse TF-IDF vector representation
+	Vector SparseVector
 
 	// Category is the service category for this intent
 	Category string
@@ -23,24 +26,54 @@ type IntentVector struct {
 // Pipeline combines all NLP operations into a single workflow.
 type Pipeline struct {
 	Preprocessor  *Preprocessor
-	Vectorizer    *TFIDFVectorizer
+	Vectorizer    Vectorizer
 	IntentVectors []IntentVector
+
+	// Labels optionally maps each category to a display name.
+	// It is saved with the model artifact.
+	Labels map[string]string
+
+	// index searches IntentVectors by the terms of the query
+	index *InvertedIndex
+}
+
+// PipelineOption configures a Pipeline created by NewPipeline.
+type PipelineOption func(*Pipeline)
+
+// WithVectorizer replaces the default word TF-IDF vectorizer.
+func WithVectorizer(vectorizer Vectorizer) PipelineOption {
+	return func(p *Pipeline) {
+		p.Vectorizer = vectorizer
+	}
+}
+
+// WithStemmer replaces the default RSLP stemmer.
+func WithStemmer(stemmer Stemmer) PipelineOption {
+	return func(p *Pipeline) {
+		p.Preprocessor.SetStemmer(stemmer)
+	}
 }
 
 // NewPipeline creates a new NLP pipeline with the specified language.
-func NewPipeline(language string, normalized bool) (*Pipeline, error) {
+// By default intents are vectorized with word TF-IDF, normalized as requested.
+func NewPipeline(language string, normalized bool, opts ...PipelineOption) (*Pipeline, error) {
 	preprocessor, err := NewPreprocessor(language)
 	if err != nil {
 		return nil, err
 	}
 
-	vectorizer := NewTFIDFVectorizer(normalized)
-
-	return &Pipeline{
+	p := &Pipeline{
 		Preprocessor:  preprocessor,
-		Vectorizer:    vectorizer,
+		Vectorizer:    NewTFIDFVectorizer(normalized),
 		IntentVectors: make([]IntentVector, 0),
-	}, nil
+		Labels:        make(map[string]string),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
 }
 
 // Train trains the pipeline on a set of intents.
@@ -53,7 +86,7 @@ func (p *Pipeline) Train(intents []string, categories []string) error {
 	processed := p.Preprocessor.ProcessBatch(intents)
 
 	// Step 2: Fit and transform with TF-IDF
-	vectors, err := p.Vectorizer.FitTransform(processed)
+	vectors, err := p.Vectorizer.FitTransformSparse(processed)
 	if err != nil {
 		return fmt.Errorf("error during vectorization: %w", err)
 	}
@@ -70,66 +103,112 @@ func (p *Pipeline) Train(intents []string, categories []string) error {
 		}
 	}
 
+	// Step 4: Index the vectors for similarity search
+	p.index = NewInvertedIndex(vectors)
+
 	return nil
 }
 
 // Predict finds the most similar intent for a given query.
 func (p *Pipeline) Predict(query string) (*IntentVector, float64, error) {
-	// Preprocess the query
-	processed := p.Preprocessor.Process(query)
-
-	// Transform to vector
-	queryVector, err := p.Vectorizer.Transform(processed)
+	results, err := p.search(query, 1)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error transforming query: %w", err)
+		return nil, 0, err
 	}
 
-	// Extract just the vectors for comparison
-	vectors := make([][]float64, len(p.IntentVectors))
-	for i := range p.IntentVectors {
-		vectors[i] = p.IntentVectors[i].Vector
-	}
+	return &p.IntentVectors[results[0].Index], results[0].Similarity, nil
+}
 
-	// Find most similar
-	idx, similarity, err := FindMostSimilar(queryVector, vectors)
+// PredictTopK finds the top K most similar intents for a given query.
+func (p *Pipeline) PredictTopK(query string, k int) ([]IntentVector, []float64, error) {
+	results, err := p.search(query, k)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error finding similar intent: %w", err)
+		return nil, nil, err
+	}
+
+	// Extract intent vectors and similarities
+	topIntents := make([]IntentVector, len(results))
+	similarities := make([]float64, len(results))
+
+	for i, result := range results {
+		topIntents[i] = p.IntentVectors[result.Index]
+		similarities[i] = result.Similarity
 	}
 
-	return &p.IntentVectors[idx], similarity, nil
+	return topIntents, similarities, nil
 }
 
-// PredictTopK finds the top K most similar intents for a given query.
-func (p *Pipeline) PredictTopK(query string, k int) ([]IntentVector, []float64, error) {
+// search preprocesses the query and looks up the k most similar intents in the index.
+func (p *Pipeline) search(query string, k int) ([]SimilarityResult, error) {
+	if p.index == nil {
+		return nil, fmt.Errorf("pipeline must be trained before prediction")
+	}
+
 	// Preprocess the query
 	processed := p.Preprocessor.Process(query)
 
 	// Transform to vector
-	queryVector, err := p.Vectorizer.Transform(processed)
+	queryVector, err := p.Vectorizer.TransformSparse(processed)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error transforming query: %w", err)
+		return nil, fmt.Errorf("error transforming query: %w", err)
 	}
 
-	// Extract just the vectors for comparison
-	vectors := make([][]float64, len(p.IntentVectors))
-	for i := range p.IntentVectors {
-		vectors[i] = p.IntentVectors[i].Vector
+	results, err := p.index.Search(queryVector, k)
+	if err != nil {
+		return nil, fmt.Errorf("error finding similar intents: %w", err)
 	}
 
-	// Find top K similar
-	results, err := FindTopKSimilar(queryVector, vectors, k)
+	return results, nil
+}
+
+// Retrain returns a new pipeline with the same preprocessing, vectorizer
+// configuration and labels, trained on the given intents. p is not modified,
+// so it can keep serving predictions while the new pipeline is built.
+func (p *Pipeline) Retrain(intents []string, categories []string) (*Pipeline, error) {
+	vectorizer, err := unfitted(p.Vectorizer)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error finding similar intents: %w", err)
+		return nil, err
 	}
 
-	// Extract intent vectors and similarities
-	topIntents := make([]IntentVector, len(results))
-	similarities := make([]float64, len(results))
+	retrained := &Pipeline{
+		Preprocessor:  p.Preprocessor,
+		Vectorizer:    vectorizer,
+		IntentVectors: make([]IntentVector, 0),
+		Labels:        maps.Clone(p.Labels),
+	}
+	if retrained.Labels == nil {
+		retrained.Labels = make(map[string]string)
+	}
 
-	for i, result := range results {
-		topIntents[i] = p.IntentVectors[result.Index]
-		similarities[i] = result.Similarity
+	if err := retrained.Train(intents, categories); err != nil {
+		return nil, err
 	}
 
-	return topIntents, similarities, nil
+	return retrained, nil
+}
+
+// unfitted returns an empty vectorizer configured like v.
+func unfitted(v Vectorizer) (Vectorizer, error) {
+	switch v := v.(type) {
+	case *TFIDFVectorizer:
+		return NewCharNGramVectorizer(v.ngramMin, v.ngramMax, v.normalized), nil
+
+	case *BM25Vectorizer:
+		bm25 := NewBM25Vectorizer(v.normalized)
+		bm25.k1, bm25.b = v.k1, v.b
+		return bm25, nil
+
+	case *HybridVectorizer:
+		word, err := unfitted(v.word)
+		if err != nil {
+			return nil, err
+		}
+		char, err := unfitted(v.char)
+		if err != nil {
+			return nil, err
+		}
+		return NewHybridVectorizer(word, char, v.wordWeight), nil
+	}
+
+	return nil, fmt.Errorf("vectorizer %T cannot be retrained", v)
 }
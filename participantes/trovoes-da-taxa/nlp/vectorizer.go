@@ -1,16 +1,54 @@
 package nlp
 
 import (
+	"cmp"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"sync"
 )
 
+// Vectorizer turns preprocessed documents into sparse vectors for similarity search.
+type Vectorizer interface {
+	// FitTransformSparse learns the vocabulary and weights from the documents
+	// and returns their vectors.
+	FitTransformSparse(documents []string) ([]SparseVector, error)
+
+	// TransformSparse converts a document using the fitted vocabulary.
+	TransformSparse(document string) (SparseVector, error)
+
+	// VocabularySize returns the number of features.
+	VocabularySize() int
+}
+
+// NewVectorizer returns the vectorizer registered under name: "tfidf" (word
+// TF-IDF), "char" (character 3-5-gram TF-IDF), "bm25" or "hybrid" (word and
+// character features).
+func NewVectorizer(name string, normalized bool) (Vectorizer, error) {
+	switch name {
+	case "tfidf", "":
+		return NewTFIDFVectorizer(normalized), nil
+	case "char":
+		return NewCharNGramVectorizer(3, 5, normalized), nil
+	case "bm25":
+		return NewBM25Vectorizer(normalized), nil
+	case "hybrid":
+		return NewHybridVectorizer(NewTFIDFVectorizer(true), NewCharNGramVectorizer(3, 5, true), 0.5), nil
+	}
+
+	return nil, fmt.Errorf("unknown vectorizer %q (available: tfidf, char, bm25, hybrid)", name)
+}
+
 // TFIDFVectorizer implements TF-IDF (Term Frequency-Inverse Document Frequency) vectorization.
+// Terms are whitespace-separated words, or character n-grams when created with
+// NewCharNGramVectorizer.
 type TFIDFVectorizer struct {
 	mu sync.RWMutex
 
+	// ngramMin and ngramMax are the character n-gram sizes; zero means word terms
+	ngramMin, ngramMax int
+
 	// vocabulary maps terms to their index in the vector
 	vocabulary map[string]int
 
@@ -34,6 +72,23 @@ func NewTFIDFVectorizer(normalized bool) *TFIDFVectorizer {
 	}
 }
 
+// NewCharNGramVectorizer creates a TF-IDF vectorizer over the character
+// n-grams of each word, with sizes from minN to maxN. N-grams survive typos
+// and joined words that break word-level matching.
+func NewCharNGramVectorizer(minN, maxN int, normalized bool) *TFIDFVectorizer {
+	v := NewTFIDFVectorizer(normalized)
+	v.ngramMin, v.ngramMax = minN, maxN
+	return v
+}
+
+// terms splits a document into the vectorizer's terms.
+func (v *TFIDFVectorizer) terms(document string) []string {
+	if v.ngramMax == 0 {
+		return strings.Fields(document)
+	}
+	return charNGrams(document, v.ngramMin, v.ngramMax)
+}
+
 // Fit trains the vectorizer on a corpus of documents.
 func (v *TFIDFVectorizer) Fit(documents []string) error {
 	v.mu.Lock()
@@ -49,7 +104,7 @@ func (v *TFIDFVectorizer) Fit(documents []string) error {
 	termDocFreq := make(map[string]int)
 
 	for _, doc := range documents {
-		terms := strings.Fields(doc)
+		terms := v.terms(doc)
 		seenInDoc := make(map[string]bool)
 
 		for _, term := range terms {
@@ -79,21 +134,29 @@ func (v *TFIDFVectorizer) Fit(documents []string) error {
 	return nil
 }
 
-// Transform converts a document into a TF-IDF vector.
+// Transform converts a document into a dense TF-IDF vector sized to the vocabulary.
 func (v *TFIDFVectorizer) Transform(document string) ([]float64, error) {
+	sparse, err := v.TransformSparse(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return sparse.Dense(v.VocabularySize()), nil
+}
+
+// TransformSparse converts a document into a TF-IDF vector holding only the
+// terms of the document that are in the vocabulary.
+func (v *TFIDFVectorizer) TransformSparse(document string) (SparseVector, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
 	if len(v.vocabulary) == 0 {
-		return nil, fmt.Errorf("vectorizer must be fitted before transform")
+		return SparseVector{}, fmt.Errorf("vectorizer must be fitted before transform")
 	}
 
-	// Initialize vector with zeros
-	vector := make([]float64, len(v.vocabulary))
-
 	// Calculate term frequencies in this document
-	terms := strings.Fields(document)
-	termFreq := make(map[string]int)
+	terms := v.terms(document)
+	termFreq := make(map[string]int, len(terms))
 
 	for _, term := range terms {
 		if term != "" {
@@ -104,10 +167,10 @@ func (v *TFIDFVectorizer) Transform(document string) ([]float64, error) {
 	// Calculate TF-IDF for each term
 	docLength := float64(len(terms))
 	if docLength == 0 {
-		return vector, nil
+		return SparseVector{}, nil
 	}
 
-	var norm float64
+	entries := make([]sparseEntry, 0, len(termFreq))
 
 	for term, freq := range termFreq {
 		if idx, exists := v.vocabulary[term]; exists {
@@ -116,23 +179,48 @@ func (v *TFIDFVectorizer) Transform(document string) ([]float64, error) {
 
 			// TF-IDF = TF * IDF
 			tfidf := tf * v.idf[term]
-			vector[idx] = tfidf
+			entries = append(entries, sparseEntry{index: int32(idx), value: tfidf})
+		}
+	}
 
-			if v.normalized {
-				norm += tfidf * tfidf
-			}
+	return newSparseVector(entries, v.normalized), nil
+}
+
+type sparseEntry struct {
+	index int32
+	value float64
+}
+
+// newSparseVector sorts the entries by index and optionally scales them to unit length.
+func newSparseVector(entries []sparseEntry, normalized bool) SparseVector {
+	// Sort before summing the norm so the result does not depend on map order
+	slices.SortFunc(entries, func(a, b sparseEntry) int {
+		return cmp.Compare(a.index, b.index)
+	})
+
+	var norm float64
+	if normalized {
+		for _, e := range entries {
+			norm += e.value * e.value
 		}
 	}
 
+	vector := SparseVector{
+		Indices: make([]int32, len(entries)),
+		Values:  make([]float64, len(entries)),
+	}
+
 	// Normalize vector (for cosine similarity)
-	if v.normalized && norm > 0 {
-		norm = math.Sqrt(norm)
-		for i := range vector {
-			vector[i] /= norm
+	norm = math.Sqrt(norm)
+	for i, e := range entries {
+		vector.Indices[i] = e.index
+		vector.Values[i] = e.value
+		if normalized && norm > 0 {
+			vector.Values[i] /= norm
 		}
 	}
 
-	return vector, nil
+	return vector
 }
 
 // FitTransform fits the vectorizer and transforms all documents in one step.
@@ -153,6 +241,24 @@ func (v *TFIDFVectorizer) FitTransform(documents []string) ([][]float64, error)
 	return vectors, nil
 }
 
+// FitTransformSparse fits the vectorizer and transforms all documents into sparse vectors.
+func (v *TFIDFVectorizer) FitTransformSparse(documents []string) ([]SparseVector, error) {
+	if err := v.Fit(documents); err != nil {
+		return nil, err
+	}
+
+	vectors := make([]SparseVector, len(documents))
+	for i, doc := range documents {
+		vec, err := v.TransformSparse(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error transforming document %d: %w", i, err)
+		}
+		vectors[i] = vec
+	}
+
+	return vectors, nil
+}
+
 // VocabularySize returns the number of unique terms in the vocabulary.
 func (v *TFIDFVectorizer) VocabularySize() int {
 	v.mu.RLock()
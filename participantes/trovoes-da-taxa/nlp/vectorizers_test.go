@@ -0,0 +1,124 @@
+package nlp
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestCharNGrams(t *testing.T) {
+	got := charNGrams("boleto pix", 3, 4)
+	want := []string{
+		" bo", "bol", "ole", "let", "eto", "to ", " bol", "bole", "olet", "leto", "eto ",
+		" pi", "pix", "ix ", " pix", "pix ",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("charNGrams() = %q, want %q", got, want)
+	}
+
+	// A padded word shorter than n yields itself once
+	if got := charNGrams("a", 3, 5); !slices.Equal(got, []string{" a "}) {
+		t.Errorf("charNGrams(a) = %q, want [\" a \"]", got)
+	}
+}
+
+func TestCharNGramVectorizerMatchesTypos(t *testing.T) {
+	documents := []string{"codigo barras boleto", "desbloquear cartao", "aumentar limite"}
+
+	for _, name := range []string{"tfidf", "char"} {
+		v, err := NewVectorizer(name, true)
+		if err != nil {
+			t.Fatalf("NewVectorizer(%s) error = %v", name, err)
+		}
+
+		vectors, err := v.FitTransformSparse(documents)
+		if err != nil {
+			t.Fatalf("FitTransformSparse() error = %v", err)
+		}
+
+		for i, query := range []string{"codbarras", "desbloquia"} {
+			q, err := v.TransformSparse(query)
+			if err != nil {
+				t.Fatalf("TransformSparse() error = %v", err)
+			}
+
+			similarity := SparseCosineSimilarity(q, vectors[i])
+			switch {
+			case name == "tfidf" && similarity != 0:
+				t.Errorf("word similarity(%q) = %v, want 0", query, similarity)
+			case name == "char" && similarity < 0.2:
+				t.Errorf("char similarity(%q) = %v, want >= 0.2", query, similarity)
+			}
+		}
+	}
+}
+
+func TestBM25Vectorizer(t *testing.T) {
+	v := NewBM25Vectorizer(false)
+
+	vectors, err := v.FitTransformSparse([]string{"pix pix pix pix boleto", "pix fatura", "boleto"})
+	if err != nil {
+		t.Fatalf("FitTransformSparse() error = %v", err)
+	}
+
+	// Terms in most documents keep a positive IDF, unlike log(N/df)
+	for term, idf := range v.idf {
+		if idf <= 0 {
+			t.Errorf("idf[%q] = %v, want > 0", term, idf)
+		}
+	}
+
+	// Term frequency saturates below k1 + 1 times the IDF
+	pix := v.vocabulary["pix"]
+	weight := vectors[0].Values[slices.Index(vectors[0].Indices, int32(pix))]
+	if limit := v.idf["pix"] * (v.k1 + 1); weight >= limit {
+		t.Errorf("weight(pix) = %v, want < %v", weight, limit)
+	}
+
+	if _, err := NewBM25Vectorizer(true).TransformSparse("pix"); err == nil {
+		t.Error("TransformSparse() before Fit error = nil, want error")
+	}
+}
+
+func TestHybridVectorizerSimilarity(t *testing.T) {
+	documents := []string{"codigo barras boleto", "desbloquear cartao", "aumentar limite cartao"}
+	const wordWeight = 0.3
+
+	word, char := NewTFIDFVectorizer(true), NewCharNGramVectorizer(3, 5, true)
+	hybrid := NewHybridVectorizer(word, char, wordWeight)
+
+	vectors, err := hybrid.FitTransformSparse(documents)
+	if err != nil {
+		t.Fatalf("FitTransformSparse() error = %v", err)
+	}
+
+	if got, want := hybrid.VocabularySize(), word.VocabularySize()+char.VocabularySize(); got != want {
+		t.Errorf("VocabularySize() = %d, want %d", got, want)
+	}
+
+	query := "desbloquear cartao credito"
+	q, err := hybrid.TransformSparse(query)
+	if err != nil {
+		t.Fatalf("TransformSparse() error = %v", err)
+	}
+	qWord, _ := word.TransformSparse(query)
+	qChar, _ := char.TransformSparse(query)
+
+	// The hybrid cosine is the weighted sum of the word and char cosines
+	for i, doc := range documents {
+		dWord, _ := word.TransformSparse(doc)
+		dChar, _ := char.TransformSparse(doc)
+
+		want := wordWeight*SparseCosineSimilarity(qWord, dWord) + (1-wordWeight)*SparseCosineSimilarity(qChar, dChar)
+		if got := q.Dot(vectors[i]); math.Abs(got-want) > 1e-12 {
+			t.Errorf("similarity(%q) = %v, want %v", doc, got, want)
+		}
+	}
+}
+
+func TestNewVectorizerUnknown(t *testing.T) {
+	if _, err := NewVectorizer("word2vec", true); err == nil {
+		t.Error("NewVectorizer(word2vec) error = nil, want error")
+	}
+}
@@ -0,0 +1,86 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Voter aggregates the k nearest intents into per-category scores.
+type Voter struct {
+	// K is the number of neighbours that vote
+	K int
+
+	// Power weights each vote by similarity^Power: 0 is a plain majority vote,
+	// 1 weights by similarity and larger values favour the closest neighbours
+	Power float64
+}
+
+// CategoryScore is the aggregated vote of one category.
+type CategoryScore struct {
+	Category string
+
+	// Score is the sum of the category's vote weights divided by K, in [0, 1].
+	// With K = 1 and Power = 1 it is the similarity of the nearest intent.
+	Score float64
+
+	// Votes is the number of neighbours of the category
+	Votes int
+}
+
+// Vote aggregates the first K matches, sorted by similarity, into category
+// scores ordered by score (highest first). Ties keep the category of the
+// closest neighbour first. Neighbours with zero similarity do not vote.
+func (v Voter) Vote(matches []IntentVector, similarities []float64) []CategoryScore {
+	k := min(v.K, len(matches), len(similarities))
+
+	scores := make([]CategoryScore, 0, k)
+	for i := 0; i < k; i++ {
+		if similarities[i] <= 0 {
+			continue
+		}
+
+		weight := math.Pow(similarities[i], v.Power)
+
+		j := slices.IndexFunc(scores, func(s CategoryScore) bool { return s.Category == matches[i].Category })
+		if j < 0 {
+			scores = append(scores, CategoryScore{Category: matches[i].Category})
+			j = len(scores) - 1
+		}
+
+		scores[j].Score += weight
+		scores[j].Votes++
+	}
+
+	for i := range scores {
+		scores[i].Score /= float64(v.K)
+	}
+
+	// Stable sort keeps first-seen (closest) categories ahead on ties
+	slices.SortStableFunc(scores, func(a, b CategoryScore) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+
+	return scores
+}
+
+// Vote classifies a query by letting its K nearest intents vote.
+// It returns no scores when no intent shares a term with the query.
+func (p *Pipeline) Vote(query string, voter Voter) ([]CategoryScore, error) {
+	if voter.K <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+
+	matches, similarities, err := p.PredictTopK(query, voter.K)
+	if err != nil {
+		return nil, err
+	}
+
+	return voter.Vote(matches, similarities), nil
+}
@@ -0,0 +1,93 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVoterVote(t *testing.T) {
+	matches := []IntentVector{{Category: "1"}, {Category: "2"}, {Category: "2"}, {Category: "3"}}
+	similarities := []float64{0.9, 0.6, 0.5, 0}
+
+	tests := []struct {
+		name  string
+		voter Voter
+		want  []CategoryScore
+	}{
+		{
+			name:  "nearest neighbour",
+			voter: Voter{K: 1, Power: 1},
+			want:  []CategoryScore{{Category: "1", Score: 0.9, Votes: 1}},
+		},
+		{
+			name:  "majority",
+			voter: Voter{K: 3, Power: 0},
+			want:  []CategoryScore{{Category: "2", Score: 2.0 / 3, Votes: 2}, {Category: "1", Score: 1.0 / 3, Votes: 1}},
+		},
+		{
+			name:  "similarity weighted",
+			voter: Voter{K: 3, Power: 1},
+			want:  []CategoryScore{{Category: "2", Score: 1.1 / 3, Votes: 2}, {Category: "1", Score: 0.9 / 3, Votes: 1}},
+		},
+		{
+			name:  "squared similarity",
+			voter: Voter{K: 3, Power: 2},
+			want:  []CategoryScore{{Category: "1", Score: 0.81 / 3, Votes: 1}, {Category: "2", Score: 0.61 / 3, Votes: 2}},
+		},
+		{
+			name:  "zero similarity does not vote",
+			voter: Voter{K: 4, Power: 0},
+			want:  []CategoryScore{{Category: "2", Score: 2.0 / 4, Votes: 2}, {Category: "1", Score: 1.0 / 4, Votes: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.voter.Vote(matches, similarities)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Vote() = %+v, want %+v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i].Category != tt.want[i].Category || got[i].Votes != tt.want[i].Votes ||
+					math.Abs(got[i].Score-tt.want[i].Score) > 1e-12 {
+					t.Errorf("Vote()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineVoteMatchesPredict(t *testing.T) {
+	intents, categories := loadDataset(t)
+
+	pipeline, err := NewPipeline("portuguese", true)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	if err := pipeline.Train(intents, categories); err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+
+	if _, err := pipeline.Vote(intents[0], Voter{K: 0}); err == nil {
+		t.Error("Vote() with K = 0 error = nil, want error")
+	}
+
+	// A single neighbour weighted by similarity is the nearest intent
+	for _, query := range intents[:50] {
+		match, similarity, err := pipeline.Predict(query)
+		if err != nil {
+			t.Fatalf("Predict() error = %v", err)
+		}
+
+		scores, err := pipeline.Vote(query, Voter{K: 1, Power: 1})
+		if err != nil {
+			t.Fatalf("Vote() error = %v", err)
+		}
+
+		if len(scores) != 1 || scores[0].Category != match.Category || scores[0].Score != similarity {
+			t.Errorf("Vote(%q) = %+v, want %s (%v)", query, scores, match.Category, similarity)
+		}
+	}
+}
@@ -2,21 +2,80 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/credsystem/hackathon/knn/nlp"
 )
 
 // KNNService encapsula o pipeline NLP e fornece métodos de alto nível
 type KNNService struct {
+	// mu protege pipeline, intents e calibrator, que AddExamples substitui
+	// por novas versões sem alterar as anteriores
+	mu         sync.RWMutex
 	pipeline   *nlp.Pipeline
 	intents    []Intent
 	serviceMap map[int]string
+	calibrator nlp.Calibrator
+
+	// updateMu serializa as atualizações de AddExamples
+	updateMu sync.Mutex
+
+	config KNNConfig
+
+	// holdout e calibrationMethod guardam a última calibração, reajustada a
+	// cada retreino de AddExamples (protegidos por updateMu)
+	holdout           []Intent
+	calibrationMethod string
+}
+
+// KNNConfig configura a votação k-NN e a regra de decisão local
+type KNNConfig struct {
+	// K é o número de vizinhos que votam
+	K int
+	// WeightPower pondera cada voto por similaridade^WeightPower (0 = maioria simples)
+	WeightPower float64
+	// ConfidenceThreshold é a confiança mínima (calibrada, se houver calibração)
+	ConfidenceThreshold float64
+	// AmbiguityMargin é a diferença mínima de score entre o serviço vencedor e o segundo
+	AmbiguityMargin float64
+}
+
+// DefaultKNNConfig retorna o vizinho mais próximo com as constantes originais da regra de decisão.
+// O melhor K e thresholds dependem do CSV de treino; use o comando sweep para ajustá-los.
+func DefaultKNNConfig() KNNConfig {
+	return KNNConfig{
+		K:                   1,
+		WeightPower:         1,
+		ConfidenceThreshold: 0.55,
+		AmbiguityMargin:     0.25,
+	}
 }
 
-// NewKNNService cria um novo serviço KNN com o pipeline NLP
-func NewKNNService() (*KNNService, error) {
+// voter retorna o votador k-NN da configuração
+func (c KNNConfig) voter() nlp.Voter {
+	return nlp.Voter{K: c.K, Power: c.WeightPower}
+}
+
+// NewKNNService cria um novo serviço KNN com o pipeline NLP.
+// stemmer seleciona o stemmer do pré-processamento: "rslp" (padrão), "simple" ou "none".
+// vectorizer seleciona a vetorização: "tfidf" (padrão), "char", "bm25" ou "hybrid".
+func NewKNNService(stemmer, vectorizer string) (*KNNService, error) {
+	st, err := nlp.NewStemmer(stemmer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stemmer: %w", err)
+	}
+
+	vec, err := nlp.NewVectorizer(vectorizer, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vectorizer: %w", err)
+	}
+
 	// Criar pipeline NLP otimizado para português
-	pipeline, err := nlp.NewPipeline("portuguese", true)
+	pipeline, err := nlp.NewPipeline("portuguese", true, nlp.WithStemmer(st), nlp.WithVectorizer(vec))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create NLP pipeline: %w", err)
 	}
@@ -25,18 +84,39 @@ func NewKNNService() (*KNNService, error) {
 		pipeline:   pipeline,
 		intents:    make([]Intent, 0),
 		serviceMap: make(map[int]string),
+		config:     DefaultKNNConfig(),
 	}, nil
 }
 
+// SetConfig substitui a configuração da votação e da regra de decisão
+func (s *KNNService) SetConfig(config KNNConfig) error {
+	if config.K <= 0 {
+		return fmt.Errorf("k must be positive")
+	}
+
+	s.config = config
+	return nil
+}
+
+// Config retorna a configuração atual
+func (s *KNNService) Config() KNNConfig {
+	return s.config
+}
+
 // LoadIntents carrega e treina o modelo com as intents do CSV
 func (s *KNNService) LoadIntents(intents []Intent) error {
 	if len(intents) == 0 {
 		return fmt.Errorf("no intents provided")
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Criar mapa de serviços para lookup rápido
 	for _, intent := range intents {
 		s.serviceMap[intent.ServiceID] = intent.ServiceName
+		// Os nomes vão junto com o artefato do modelo
+		s.pipeline.Labels[strconv.Itoa(intent.ServiceID)] = intent.ServiceName
 	}
 
 	// Extrair textos e categorias para treinar o pipeline
@@ -68,12 +148,218 @@ func (s *KNNService) LoadIntents(intents []Intent) error {
 	return nil
 }
 
-// Classify classifica uma intenção do usuário
-func (s *KNNService) Classify(intentText string) ClassificationResult {
-	// Predição usando o pipeline NLP
-	match, confidence, err := s.pipeline.Predict(intentText)
+// LoadModel cria um serviço KNN a partir de um artefato gerado pelo comando train,
+// sem precisar do CSV nem retreinar o pipeline
+func LoadModel(r io.Reader) (*KNNService, error) {
+	pipeline, err := nlp.Load(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model: %w", err)
+	}
+
+	s := &KNNService{
+		pipeline:   pipeline,
+		intents:    make([]Intent, len(pipeline.IntentVectors)),
+		serviceMap: make(map[int]string),
+		config:     DefaultKNNConfig(),
+	}
+
+	// Reconstruir as intents e o mapa de serviços a partir do artefato
+	for i, iv := range pipeline.IntentVectors {
+		serviceID, err := strconv.Atoi(iv.Category)
+		if err != nil {
+			return nil, fmt.Errorf("invalid category %q in model: %w", iv.Category, err)
+		}
+
+		s.serviceMap[serviceID] = pipeline.Labels[iv.Category]
+		s.intents[i] = Intent{
+			ServiceID:   serviceID,
+			ServiceName: pipeline.Labels[iv.Category],
+			IntentText:  iv.Original,
+			Vector:      iv.Vector,
+		}
+	}
+
+	return s, nil
+}
+
+// SaveModel grava o pipeline treinado como artefato versionado
+func (s *KNNService) SaveModel(w io.Writer) error {
+	pipeline, _ := s.snapshot()
+	return pipeline.Save(w)
+}
+
+// Intents retorna as intents usadas no treino do modelo
+func (s *KNNService) Intents() []Intent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.intents
+}
+
+// snapshot retorna o pipeline e o mapa de serviços atuais. AddExamples troca
+// o pipeline por uma nova versão, então ele pode ser usado sem manter o lock
+func (s *KNNService) snapshot() (*nlp.Pipeline, map[int]string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pipeline, s.serviceMap
+}
+
+// validateExamples confere e completa exemplos rotulados:
+// a intenção não pode ser vazia e o serviço deve existir no modelo.
+// ServiceName é preenchido com o nome conhecido do serviço. Erros são ValidationError
+func (s *KNNService) validateExamples(examples []Intent) ([]Intent, error) {
+	if len(examples) == 0 {
+		return nil, NewValidationError("no examples provided")
+	}
+
+	_, serviceMap := s.snapshot()
+
+	validated := make([]Intent, len(examples))
+	for i, example := range examples {
+		text := strings.TrimSpace(example.IntentText)
+		if text == "" {
+			return nil, NewValidationError(fmt.Sprintf("example %d: intent cannot be empty", i+1))
+		}
+
+		name, ok := serviceMap[example.ServiceID]
+		if !ok {
+			return nil, NewValidationError(fmt.Sprintf("example %d: unknown service_id %d", i+1, example.ServiceID))
+		}
+
+		validated[i] = Intent{ServiceID: example.ServiceID, ServiceName: name, IntentText: text}
+	}
+
+	return validated, nil
+}
+
+// AddExamples adiciona exemplos rotulados ao modelo em execução. Um novo
+// pipeline com IDF e vetores recalculados é treinado em paralelo às consultas,
+// que continuam usando o modelo anterior até a troca atômica. Os scores do
+// novo pipeline mudam, então a calibração, se houver, é reajustada nele com o
+// mesmo holdout. persist é chamado com os exemplos validados depois do treino
+// e antes da troca; se falhar, o modelo não muda. Retorna os exemplos adicionados
+func (s *KNNService) AddExamples(examples []Intent, persist func([]Intent) error) ([]Intent, error) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	examples, err := s.validateExamples(examples)
 	if err != nil {
-		// Em caso de erro, retornar resultado vazio com confiança zero
+		return nil, err
+	}
+
+	pipeline, _ := s.snapshot()
+	all := append(slices.Clone(s.Intents()), examples...)
+
+	documents := make([]string, len(all))
+	categories := make([]string, len(all))
+	for i, intent := range all {
+		documents[i] = intent.IntentText
+		categories[i] = strconv.Itoa(intent.ServiceID)
+	}
+
+	retrained, err := pipeline.Retrain(documents, categories)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrain pipeline: %w", err)
+	}
+
+	for i := range all {
+		all[i].Vector = retrained.IntentVectors[i].Vector
+	}
+
+	var calibrator nlp.Calibrator
+	if s.holdout != nil {
+		calibrator, err = s.fitCalibrator(retrained, s.holdout, s.calibrationMethod)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recalibrate: %w", err)
+		}
+	}
+
+	if persist != nil {
+		if err := persist(examples); err != nil {
+			return nil, fmt.Errorf("failed to persist examples: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.pipeline = retrained
+	s.intents = all
+	s.calibrator = calibrator
+	s.mu.Unlock()
+
+	return examples, nil
+}
+
+// Calibrate ajusta a calibração da confiança com intents de validação (fora do treino),
+// para que Confidence passe a ser a probabilidade de a predição estar correta.
+// method seleciona o método: "platt" (padrão) ou "isotonic".
+func (s *KNNService) Calibrate(holdout []Intent, method string) error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	pipeline, _ := s.snapshot()
+
+	calibrator, err := s.fitCalibrator(pipeline, holdout, method)
+	if err != nil {
+		return err
+	}
+
+	s.holdout = slices.Clone(holdout)
+	s.calibrationMethod = method
+
+	s.mu.Lock()
+	s.calibrator = calibrator
+	s.mu.Unlock()
+
+	return nil
+}
+
+// fitCalibrator ajusta a calibração dos scores de pipeline no holdout
+func (s *KNNService) fitCalibrator(pipeline *nlp.Pipeline, holdout []Intent, method string) (nlp.Calibrator, error) {
+	scores := make([]float64, 0, len(holdout))
+	correct := make([]bool, 0, len(holdout))
+
+	for _, intent := range holdout {
+		votes, err := pipeline.Vote(intent.IntentText, s.config.voter())
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify holdout intent: %w", err)
+		}
+
+		score, predictedID := 0.0, 0
+		if len(votes) > 0 {
+			score = votes[0].Score
+			predictedID, _ = strconv.Atoi(votes[0].Category)
+		}
+
+		scores = append(scores, score)
+		correct = append(correct, predictedID == intent.ServiceID)
+	}
+
+	calibrator, err := nlp.FitCalibrator(method, scores, correct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fit calibration: %w", err)
+	}
+
+	return calibrator, nil
+}
+
+// confidence converte o score da votação em confiança, calibrada quando houver calibração
+func (s *KNNService) confidence(score float64) float64 {
+	s.mu.RLock()
+	calibrator := s.calibrator
+	s.mu.RUnlock()
+
+	if calibrator == nil {
+		return score
+	}
+	return calibrator.Calibrate(score)
+}
+
+// Classify classifica uma intenção do usuário pela votação dos K vizinhos mais próximos
+func (s *KNNService) Classify(intentText string) ClassificationResult {
+	pipeline, serviceMap := s.snapshot()
+
+	votes, err := pipeline.Vote(intentText, s.config.voter())
+	if err != nil || len(votes) == 0 {
+		// Em caso de erro ou sem vizinhos similares, retornar resultado vazio com confiança zero
 		return ClassificationResult{
 			ServiceID:   0,
 			ServiceName: "",
@@ -82,22 +368,20 @@ func (s *KNNService) Classify(intentText string) ClassificationResult {
 	}
 
 	// Converter a categoria (ServiceID em string) de volta para int
-	var serviceID int
-	fmt.Sscanf(match.Category, "%d", &serviceID)
-
-	// Buscar o nome do serviço
-	serviceName := s.serviceMap[serviceID]
+	serviceID, _ := strconv.Atoi(votes[0].Category)
 
 	return ClassificationResult{
 		ServiceID:   serviceID,
-		ServiceName: serviceName,
-		Confidence:  confidence,
+		ServiceName: serviceMap[serviceID],
+		Confidence:  s.confidence(votes[0].Score),
 	}
 }
 
 // ClassifyTopK retorna os K melhores matches
 func (s *KNNService) ClassifyTopK(intentText string, k int) []ClassificationResult {
-	matches, confidences, err := s.pipeline.PredictTopK(intentText, k)
+	pipeline, serviceMap := s.snapshot()
+
+	matches, confidences, err := pipeline.PredictTopK(intentText, k)
 	if err != nil {
 		return []ClassificationResult{}
 	}
@@ -109,7 +393,7 @@ func (s *KNNService) ClassifyTopK(intentText string, k int) []ClassificationResu
 
 		results[i] = ClassificationResult{
 			ServiceID:   serviceID,
-			ServiceName: s.serviceMap[serviceID],
+			ServiceName: serviceMap[serviceID],
 			Confidence:  confidences[i],
 		}
 	}
@@ -119,87 +403,51 @@ func (s *KNNService) ClassifyTopK(intentText string, k int) []ClassificationResu
 
 // VocabularySize retorna o tamanho do vocabulário treinado
 func (s *KNNService) VocabularySize() int {
-	return s.pipeline.Vectorizer.VocabularySize()
+	pipeline, _ := s.snapshot()
+	return pipeline.Vectorizer.VocabularySize()
 }
 
 // ClassifyWithSafetyCheck classifica uma intenção e indica se o resultado é confiável.
-// Esta é a interface pública que primeiro transforma o texto em vetor e então aplica a verificação de segurança.
+// Esta é a interface pública que faz a votação dos K vizinhos no índice e então aplica a verificação de segurança.
 func (s *KNNService) ClassifyWithSafetyCheck(intentText string) (predictedID int, predictedName string, confidence float64, isSafe bool, err error) {
-	// Pré-processar a intenção
-	processed := s.pipeline.Preprocessor.Process(intentText)
+	pipeline, serviceMap := s.snapshot()
 
-	// Transformar em vetor
-	vector, err := s.pipeline.Vectorizer.Transform(processed)
+	votes, err := pipeline.Vote(intentText, s.config.voter())
 	if err != nil {
-		return 0, "", 0.0, false, fmt.Errorf("error transforming intent: %w", err)
+		return 0, "", 0.0, false, fmt.Errorf("error classifying intent: %w", err)
 	}
 
 	// Aplicar a classificação com verificação de segurança
-	predictedID, predictedName, confidence, isSafe = s.classifyLocallyWithSafetyCheck(vector)
+	predictedID, predictedName, confidence, isSafe = s.classifyLocallyWithSafetyCheck(votes, serviceMap)
 
 	return predictedID, predictedName, confidence, isSafe, nil
 }
 
 // classifyLocallyWithSafetyCheck implementa uma regra de decisão avançada para prevenir ambiguidades.
+// Recebe os scores por serviço da votação ordenados do maior para o menor.
 // Retorna a melhor predição e um booleano `isSafe` que indica se o resultado passou pelos critérios de segurança:
-// 1. CRITÉRIO DE CONFIANÇA MÍNIMA: A melhor correspondência deve ter uma pontuação acima do threshold mínimo
-// 2. CRITÉRIO DE MARGEM DE AMBIGUIDADE: A diferença entre a melhor e a segunda melhor deve ser significativa
-func (s *KNNService) classifyLocallyWithSafetyCheck(newIntentVector []float64) (predictedID int, predictedName string, confidence float64, isSafe bool) {
-	// Constantes finais definidas com base na análise de todos os ciclos de teste.
-	// Elas são otimizadas para maximizar a precisão e evitar os erros de -50 pontos.
-	const confidenceThreshold = 0.55
-	const ambiguityMargin = 0.25
-
-	// Inicializar as duas melhores correspondências
-	var bestMatch struct {
-		serviceID   int
-		serviceName string
-		confidence  float64
+// 1. CRITÉRIO DE CONFIANÇA MÍNIMA: A confiança (calibrada) do vencedor deve estar acima do threshold mínimo
+// 2. CRITÉRIO DE MARGEM DE AMBIGUIDADE: A diferença de score entre o vencedor e o segundo serviço deve ser significativa
+func (s *KNNService) classifyLocallyWithSafetyCheck(votes []nlp.CategoryScore, serviceMap map[int]string) (predictedID int, predictedName string, confidence float64, isSafe bool) {
+	if len(votes) == 0 {
+		return 0, "", 0.0, false
 	}
 
-	var secondBestMatch struct {
-		confidence float64
-	}
-
-	// Inicializar com valores que garantem que qualquer correspondência válida seja melhor
-	bestMatch.confidence = -1.0
-	secondBestMatch.confidence = -1.0
+	// O melhor serviço é o primeiro; sem segundo serviço a margem é o próprio score
+	predictedID, _ = strconv.Atoi(votes[0].Category)
+	predictedName = serviceMap[predictedID]
+	confidence = s.confidence(votes[0].Score)
 
-	// Iterar por todas as intenções pré-carregadas
-	for _, intent := range s.intents {
-		// Calcular a similaridade de cossenos entre o vetor da nova intenção e o vetor da intenção atual
-		similarity, err := nlp.CosineSimilarity(newIntentVector, intent.Vector)
-		if err != nil {
-			// Em caso de erro no cálculo, continuar para a próxima intenção
-			continue
-		}
-
-		// Atualizar as duas melhores correspondências
-		if similarity > bestMatch.confidence {
-			// A similaridade atual é melhor que a melhor correspondência
-			// A antiga melhor correspondência se torna a segunda melhor
-			secondBestMatch.confidence = bestMatch.confidence
-
-			// Atualizar a melhor correspondência
-			bestMatch.serviceID = intent.ServiceID
-			bestMatch.serviceName = intent.ServiceName
-			bestMatch.confidence = similarity
-		} else if similarity > secondBestMatch.confidence {
-			// A similaridade atual não é a melhor, mas é melhor que a segunda melhor
-			secondBestMatch.confidence = similarity
-		}
+	secondBestScore := 0.0
+	if len(votes) > 1 {
+		secondBestScore = votes[1].Score
 	}
 
-	// Aplicar a regra de decisão final usando as constantes definidas
-	// Um resultado é considerado "seguro" se:
-	// 1. A confiança da melhor correspondência está acima do threshold mínimo
-	// 2. A margem entre a melhor e a segunda melhor é suficientemente grande
-
-	confidenceCheckPassed := bestMatch.confidence >= confidenceThreshold
-	ambiguityCheckPassed := (bestMatch.confidence - secondBestMatch.confidence) >= ambiguityMargin
+	confidenceCheckPassed := confidence >= s.config.ConfidenceThreshold
+	ambiguityCheckPassed := (votes[0].Score - secondBestScore) >= s.config.AmbiguityMargin
 
 	isSafe = confidenceCheckPassed && ambiguityCheckPassed
 
 	// Retornar a melhor predição encontrada e o indicador de segurança
-	return bestMatch.serviceID, bestMatch.serviceName, bestMatch.confidence, isSafe
+	return predictedID, predictedName, confidence, isSafe
 }
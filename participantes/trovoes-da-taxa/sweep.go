@@ -0,0 +1,294 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/credsystem/hackathon/knn/nlp"
+)
+
+// Pontuação do hackathon por requisição
+const (
+	scoreCorrect   = 10.0
+	scoreIncorrect = -50.0
+	scorePerMs     = -0.01
+)
+
+// sweepResult é a pontuação de uma configuração no conjunto de validação
+type sweepResult struct {
+	config      KNNConfig
+	score       float64
+	localHits   int
+	localMisses int
+	fallbacks   int
+}
+
+// sweepSample é a votação de uma intent de validação para um K e peso
+type sweepSample struct {
+	score       float64
+	margin      float64
+	confidence  float64
+	correct     bool
+	localMillis float64
+}
+
+// runSweep varre K, peso dos votos, threshold e margem no CSV de validação e
+// imprime as configurações que maximizam a pontuação do hackathon
+// (+10 acerto, -50 erro, -0,01 por ms). Intents que não passam pela
+// verificação de segurança são pontuadas como fallback para a IA.
+func runSweep(args []string) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	csvPath := fs.String("csv", defaultCSVPath(), "CSV de intenções usado no treino")
+	holdoutPath := fs.String("holdout", filepath.Join("..", "..", "assets", "extra_intents.csv"), "CSV de validação, fora do treino")
+	stemmer := fs.String("stemmer", os.Getenv("NLP_STEMMER"), "Stemmer do pré-processamento: rslp, simple ou none")
+	vectorizer := fs.String("vectorizer", os.Getenv("NLP_VECTORIZER"), "Vetorização: tfidf, char, bm25 ou hybrid")
+	kList := fs.String("k", "1,3,5,7,9", "Valores de K (lista separada por vírgula)")
+	powerList := fs.String("power", "0,1,2,4", "Expoentes do peso dos votos (lista ou início:fim:passo)")
+	thresholdList := fs.String("thresholds", "0:0.95:0.05", "Thresholds de confiança (lista ou início:fim:passo)")
+	marginList := fs.String("margins", "0:0.5:0.05", "Margens de ambiguidade (lista ou início:fim:passo)")
+	calibration := fs.String("calibration", "platt", "Calibração da confiança: none, platt ou isotonic")
+	aiAccuracy := fs.Float64("ai-accuracy", 0.9, "Taxa de acerto esperada do fallback de IA")
+	aiLatency := fs.Float64("ai-latency-ms", 1500, "Latência esperada do fallback de IA em ms")
+	top := fs.Int("top", 10, "Quantidade de configurações exibidas")
+	fs.Parse(args)
+
+	ks, err := parseGrid(*kList)
+	if err != nil {
+		log.Fatalf("Invalid -k: %v", err)
+	}
+	for _, k := range ks {
+		if k < 1 || k != math.Trunc(k) {
+			log.Fatalf("Invalid -k: %v is not a positive integer", k)
+		}
+	}
+	powers, err := parseGrid(*powerList)
+	if err != nil {
+		log.Fatalf("Invalid -power: %v", err)
+	}
+	thresholds, err := parseGrid(*thresholdList)
+	if err != nil {
+		log.Fatalf("Invalid -thresholds: %v", err)
+	}
+	margins, err := parseGrid(*marginList)
+	if err != nil {
+		log.Fatalf("Invalid -margins: %v", err)
+	}
+
+	intents, err := loadIntentsFromCSV(*csvPath)
+	if err != nil {
+		log.Fatalf("Failed to load intents: %v", err)
+	}
+
+	holdout, err := loadIntentsFromCSV(*holdoutPath)
+	if err != nil {
+		log.Fatalf("Failed to load holdout: %v", err)
+	}
+
+	knnService, err := NewKNNService(*stemmer, *vectorizer)
+	if err != nil {
+		log.Fatalf("Failed to create KNN service: %v", err)
+	}
+
+	if err := knnService.LoadIntents(intents); err != nil {
+		log.Fatalf("Failed to train pipeline: %v", err)
+	}
+
+	// Os vizinhos de cada intent são buscados uma única vez com o maior K
+	maxK := int(slices.Max(ks))
+	matches := make([][]nlp.IntentVector, len(holdout))
+	similarities := make([][]float64, len(holdout))
+	millis := make([]float64, len(holdout))
+
+	for i, intent := range holdout {
+		start := time.Now()
+		matches[i], similarities[i], err = knnService.pipeline.PredictTopK(intent.IntentText, maxK)
+		if err != nil {
+			log.Fatalf("Failed to classify holdout intent: %v", err)
+		}
+		millis[i] = float64(time.Since(start).Microseconds()) / 1000
+	}
+
+	fallbackScore := *aiAccuracy*scoreCorrect + (1-*aiAccuracy)*scoreIncorrect + scorePerMs**aiLatency
+
+	var results []sweepResult
+	for _, k := range ks {
+		for _, power := range powers {
+			voter := nlp.Voter{K: int(k), Power: power}
+
+			samples := make([]sweepSample, len(holdout))
+			for i, intent := range holdout {
+				samples[i] = newSweepSample(voter.Vote(matches[i], similarities[i]), intent.ServiceID, millis[i])
+			}
+
+			if err := calibrateSamples(samples, *calibration); err != nil {
+				log.Fatalf("Failed to calibrate: %v", err)
+			}
+
+			for _, threshold := range thresholds {
+				for _, margin := range margins {
+					result := sweepResult{config: KNNConfig{K: int(k), WeightPower: power, ConfidenceThreshold: threshold, AmbiguityMargin: margin}}
+
+					for _, sample := range samples {
+						switch {
+						case sample.score == 0 || sample.confidence < threshold || sample.margin < margin:
+							result.fallbacks++
+							result.score += fallbackScore
+						case sample.correct:
+							result.localHits++
+							result.score += scoreCorrect + scorePerMs*sample.localMillis
+						default:
+							result.localMisses++
+							result.score += scoreIncorrect + scorePerMs*sample.localMillis
+						}
+					}
+
+					results = append(results, result)
+				}
+			}
+		}
+	}
+
+	// Empates favorecem K menor e thresholds mais altos (mais conservadores)
+	slices.SortStableFunc(results, func(a, b sweepResult) int {
+		if a.score != b.score {
+			return cmpDesc(a.score, b.score)
+		}
+		if a.config.K != b.config.K {
+			return a.config.K - b.config.K
+		}
+		return cmpDesc(a.config.ConfidenceThreshold, b.config.ConfidenceThreshold)
+	})
+
+	fmt.Printf("Train: %d intents, holdout: %d intents, calibration: %s\n", len(intents), len(holdout), *calibration)
+	fmt.Printf("AI fallback: %.0f%% accuracy, %.0fms -> %.2f points per request\n\n", *aiAccuracy*100, *aiLatency, fallbackScore)
+	fmt.Printf("%-4s %-6s %-10s %-7s %10s %10s %6s %6s %9s\n", "K", "POWER", "THRESHOLD", "MARGIN", "SCORE", "PER REQ", "HITS", "MISS", "FALLBACK")
+
+	for _, r := range results[:min(*top, len(results))] {
+		fmt.Printf("%-4d %-6.2f %-10.2f %-7.2f %10.2f %10.3f %6d %6d %9d\n",
+			r.config.K, r.config.WeightPower, r.config.ConfidenceThreshold, r.config.AmbiguityMargin,
+			r.score, r.score/float64(len(holdout)), r.localHits, r.localMisses, r.fallbacks)
+	}
+
+	best := results[0].config
+	fmt.Printf("\nBest configuration:\n")
+	fmt.Printf("  KNN_K=%d\n", best.K)
+	fmt.Printf("  KNN_WEIGHT_POWER=%g\n", best.WeightPower)
+	fmt.Printf("  KNN_CONFIDENCE_THRESHOLD=%g\n", best.ConfidenceThreshold)
+	fmt.Printf("  KNN_AMBIGUITY_MARGIN=%g\n", best.AmbiguityMargin)
+	if *calibration != "none" {
+		fmt.Printf("  CALIBRATION_METHOD=%s\n", *calibration)
+	}
+}
+
+// newSweepSample resume a votação de uma intent de validação
+func newSweepSample(votes []nlp.CategoryScore, expectedID int, localMillis float64) sweepSample {
+	sample := sweepSample{localMillis: localMillis}
+	if len(votes) == 0 {
+		return sample
+	}
+
+	predictedID, _ := strconv.Atoi(votes[0].Category)
+	sample.score = votes[0].Score
+	sample.confidence = votes[0].Score
+	sample.correct = predictedID == expectedID
+	sample.margin = votes[0].Score
+	if len(votes) > 1 {
+		sample.margin -= votes[1].Score
+	}
+
+	return sample
+}
+
+// calibrateSamples substitui a confiança pela probabilidade calibrada. A
+// calibração de cada metade é ajustada na outra (validação cruzada em 2 folds),
+// para não avaliar o calibrador nos mesmos dados em que foi ajustado.
+func calibrateSamples(samples []sweepSample, method string) error {
+	if method == "none" {
+		return nil
+	}
+
+	if len(samples) < 2 {
+		return fmt.Errorf("calibration needs at least 2 holdout intents")
+	}
+
+	for fold := range 2 {
+		var scores []float64
+		var correct []bool
+		for i, sample := range samples {
+			if i%2 != fold {
+				scores = append(scores, sample.score)
+				correct = append(correct, sample.correct)
+			}
+		}
+
+		calibrator, err := nlp.FitCalibrator(method, scores, correct)
+		if err != nil {
+			return err
+		}
+
+		for i := fold; i < len(samples); i += 2 {
+			samples[i].confidence = calibrator.Calibrate(samples[i].score)
+		}
+	}
+
+	return nil
+}
+
+// parseGrid interpreta uma lista "a,b,c" ou um intervalo "início:fim:passo"
+func parseGrid(s string) ([]float64, error) {
+	if parts := strings.Split(s, ":"); len(parts) == 3 {
+		var bounds [3]float64
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, err
+			}
+			bounds[i] = v
+		}
+
+		start, end, step := bounds[0], bounds[1], bounds[2]
+		if step <= 0 || end < start {
+			return nil, fmt.Errorf("invalid range %q", s)
+		}
+
+		var values []float64
+		// Arredondar evita acumular erro de ponto flutuante no passo
+		for i := 0; start+float64(i)*step <= end+1e-9; i++ {
+			values = append(values, math.Round((start+float64(i)*step)*1e6)/1e6)
+		}
+		return values, nil
+	}
+
+	var values []float64
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("empty list")
+	}
+
+	return values, nil
+}
+
+func cmpDesc(a, b float64) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
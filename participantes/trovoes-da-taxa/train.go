@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// runTrain treina o pipeline a partir do CSV e grava o artefato do modelo,
+// para que o servidor suba com MODEL_PATH sem depender do CSV
+func runTrain(args []string) {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	csvPath := fs.String("csv", defaultCSVPath(), "CSV de intenções usado no treino")
+	output := fs.String("output", "model.knn", "Arquivo de saída do artefato do modelo")
+	stemmer := fs.String("stemmer", os.Getenv("NLP_STEMMER"), "Stemmer do pré-processamento: rslp, simple ou none")
+	vectorizer := fs.String("vectorizer", os.Getenv("NLP_VECTORIZER"), "Vetorização: tfidf, char, bm25 ou hybrid")
+	fs.Parse(args)
+
+	intents, err := loadIntentsFromCSV(*csvPath)
+	if err != nil {
+		log.Fatalf("Failed to load intents: %v", err)
+	}
+
+	knnService, err := NewKNNService(*stemmer, *vectorizer)
+	if err != nil {
+		log.Fatalf("Failed to create KNN service: %v", err)
+	}
+
+	if err := knnService.LoadIntents(intents); err != nil {
+		log.Fatalf("Failed to train pipeline: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := knnService.SaveModel(&buf); err != nil {
+		log.Fatalf("Failed to save model: %v", err)
+	}
+
+	if err := os.WriteFile(*output, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("Failed to write model: %v", err)
+	}
+
+	fmt.Printf("Model written to %s\n", *output)
+	fmt.Printf("  Intents:    %d\n", len(intents))
+	fmt.Printf("  Vocabulary: %d\n", knnService.VocabularySize())
+	fmt.Printf("  Size:       %d bytes\n", buf.Len())
+	fmt.Printf("  SHA-256:    %x\n", sha256.Sum256(buf.Bytes()))
+}
+
+// defaultCSVPath retorna INTENTS_CSV_PATH ou o CSV padrão do projeto
+func defaultCSVPath() string {
+	if csvPath := os.Getenv("INTENTS_CSV_PATH"); csvPath != "" {
+		return csvPath
+	}
+
+	// Caminho padrão relativo ao projeto
+	return filepath.Join("..", "..", "assets", "intents_pre_loaded.csv")
+}
@@ -1,11 +1,17 @@
 package main
 
+import (
+	"time"
+
+	"github.com/credsystem/hackathon/knn/nlp"
+)
+
 // Intent representa uma intenção pré-carregada do CSV
 type Intent struct {
 	ServiceID   int
 	ServiceName string
 	IntentText  string
-	Vector      []float64
+	Vector      nlp.SparseVector
 }
 
 // ClassificationResult representa o resultado da classificação
@@ -46,6 +52,7 @@ type TestBatchRequest struct {
 
 // APITestResult representa o resultado de um teste individual via API
 type APITestResult struct {
+	Index             int     `json:"index"` // Posição do caso de teste no lote
 	Intent            string  `json:"intent"`
 	ExpectedServiceID int     `json:"expected_service_id,omitempty"`
 	PredictedID       int     `json:"predicted_service_id"`
@@ -78,6 +85,9 @@ type TestBatchStats struct {
 	LocalCorrectPredictions int     `json:"local_correct_predictions,omitempty"` // Acertos quando usou NLP local
 	LocalAccuracyRate       float64 `json:"local_accuracy_rate,omitempty"`       // Taxa de acerto do NLP local
 
+	// Decisões da cascata: caminho, chamadas à IA, custo e latência
+	Cascade CascadeStats `json:"cascade"`
+
 	ByService map[int]*ServiceTestStats `json:"by_service,omitempty"`
 }
 
@@ -96,3 +106,65 @@ type TestBatchResponse struct {
 	Results    []APITestResult `json:"results"`
 	Statistics TestBatchStats  `json:"statistics"`
 }
+
+// TestBatchStreamSummary é a última linha da resposta NDJSON de /api/test-batch
+type TestBatchStreamSummary struct {
+	Statistics *TestBatchStats `json:"statistics,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// JobResponse representa o estado de um job de /api/jobs
+type JobResponse struct {
+	ID         string          `json:"id"`
+	Status     JobStatus       `json:"status"`
+	Total      int             `json:"total"`
+	Completed  int             `json:"completed"`
+	Progress   float64         `json:"progress"` // % dos casos de teste classificados
+	Statistics *TestBatchStats `json:"statistics,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// CascadeStatsResponse representa a resposta de /api/cascade-stats
+type CascadeStatsResponse struct {
+	Mode       string       `json:"mode"`
+	HedgeAfter int64        `json:"hedge_after_ms"`
+	AITimeout  int64        `json:"ai_timeout_ms"`
+	Stats      CascadeStats `json:"stats"`
+}
+
+// ExampleRequest representa um exemplo rotulado enviado para /api/examples
+type ExampleRequest struct {
+	ServiceID int    `json:"service_id"`
+	Intent    string `json:"intent"`
+}
+
+// ExamplesRequest representa a requisição de /api/examples: um exemplo ou uma lista
+type ExamplesRequest struct {
+	ExampleRequest
+	Examples []ExampleRequest `json:"examples,omitempty"`
+}
+
+// ExampleRecord representa uma linha do arquivo append-only de exemplos
+type ExampleRecord struct {
+	ServiceID   int       `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	Intent      string    `json:"intent"`
+	AddedAt     time.Time `json:"added_at"`
+}
+
+// ExamplesData representa o estado do modelo após adicionar exemplos
+type ExamplesData struct {
+	Added          int `json:"added"`
+	TotalIntents   int `json:"total_intents"`
+	VocabularySize int `json:"vocabulary_size"`
+}
+
+// ExamplesResponse representa a resposta de /api/examples
+type ExamplesResponse struct {
+	Success bool          `json:"success"`
+	Data    *ExamplesData `json:"data,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
 	"unicode"
@@ -21,6 +22,7 @@ var stopwords = map[string]bool{
 // IntentClassifier mantém intenções conhecidas e cliente HTTP
 type IntentClassifier struct {
 	knownIntents []storage.IntentEntry
+	examples     *tfidfIndex
 	apiKey       string
 	client       *http.Client
 }
@@ -29,6 +31,7 @@ type IntentClassifier struct {
 func NewIntentClassifier(intents []storage.IntentEntry, apiKey string) *IntentClassifier {
 	return &IntentClassifier{
 		knownIntents: intents,
+		examples:     newTFIDFIndex(intents),
 		apiKey:       apiKey,
 		client:       &http.Client{},
 	}
@@ -46,6 +49,18 @@ func normalizeString(s string) string {
 	return b.String()
 }
 
+// contentWords são as palavras da frase normalizada com 3 letras ou mais e
+// fora das stopwords
+func contentWords(s string) []string {
+	words := []string{}
+	for _, w := range strings.Fields(normalizeString(s)) {
+		if len(w) >= 3 && !stopwords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
 // Classify usa abordagem híbrida: match exato → fuzzy → LLM
 func (ic *IntentClassifier) Classify(intent string) (int, string, error) {
 	norm := normalizeString(intent)
@@ -69,25 +84,13 @@ func (ic *IntentClassifier) Classify(intent string) (int, string, error) {
 
 // fuzzyMatch aprimorado
 func (ic *IntentClassifier) fuzzyMatch(intent string) (*storage.IntentEntry, float64) {
-	words := strings.Fields(intent)
-	filtered := []string{}
-	for _, w := range words {
-		if len(w) >= 3 && !stopwords[w] {
-			filtered = append(filtered, w)
-		}
-	}
+	filtered := contentWords(intent)
 
 	var bestMatch *storage.IntentEntry
 	bestScore := 0.0
 
 	for i := range ic.knownIntents {
-		knownWords := strings.Fields(normalizeString(ic.knownIntents[i].Intent))
-		knownFiltered := []string{}
-		for _, w := range knownWords {
-			if len(w) >= 3 && !stopwords[w] {
-				knownFiltered = append(knownFiltered, w)
-			}
-		}
+		knownFiltered := contentWords(ic.knownIntents[i].Intent)
 
 		matchCount := 0
 		for _, w := range filtered {
@@ -116,20 +119,22 @@ func (ic *IntentClassifier) fuzzyMatch(intent string) (*storage.IntentEntry, flo
 // ---------------- LLM ----------------
 
 func (ic *IntentClassifier) classifyWithLLM(intent string) (int, string, error) {
-	prompt := ic.buildPrompt(intent)
-	response, err := ic.callOpenRouter(prompt)
+	prompt, estimated := ic.buildPrompt(intent)
+	response, usage, err := ic.callOpenRouter(prompt)
 	if err != nil {
 		return 0, "", err
 	}
+	log.Printf("🧮 Prompt: %d tokens (estimados %d)", usage.PromptTokens, estimated)
 	return ic.parseResponse(response)
 }
 
-func (ic *IntentClassifier) buildPrompt(intent string) string {
-	serviceExamples := make(map[int][]string)
+// buildPrompt lista os 16 serviços e, como exemplos, só as intenções
+// conhecidas mais parecidas com a solicitação (ou uma por serviço, quando
+// nenhuma se parece). Retorna também a estimativa
+// de tokens do prompt.
+func (ic *IntentClassifier) buildPrompt(intent string) (string, int) {
 	serviceNames := make(map[int]string)
-
 	for _, entry := range ic.knownIntents {
-		serviceExamples[entry.ServiceID] = append(serviceExamples[entry.ServiceID], entry.Intent)
 		serviceNames[entry.ServiceID] = entry.ServiceName
 	}
 
@@ -140,27 +145,24 @@ func (ic *IntentClassifier) buildPrompt(intent string) string {
 	for id := 1; id <= 16; id++ {
 		if name, ok := serviceNames[id]; ok {
 			prompt.WriteString(fmt.Sprintf("ID %d: %s\n", id, name))
-			if examples, ok := serviceExamples[id]; ok && len(examples) > 0 {
-				maxEx := 3
-				if len(examples) < maxEx {
-					maxEx = len(examples)
-				}
-				prompt.WriteString("  Exemplos: ")
-				for i := 0; i < maxEx; i++ {
-					if i > 0 {
-						prompt.WriteString(", ")
-					}
-					prompt.WriteString(fmt.Sprintf("\"%s\"", examples[i]))
-				}
-				prompt.WriteString("\n")
-			}
+		}
+	}
+
+	if examples := ic.examples.topK(intent, promptExamples, promptCandidates); len(examples) > 0 {
+		if examples[0].score > 0 {
+			prompt.WriteString("\nExemplos parecidos:\n")
+		} else {
+			prompt.WriteString("\nUm exemplo de cada serviço:\n")
+		}
+		for _, ex := range examples {
+			prompt.WriteString(fmt.Sprintf("\"%s\" → %d\n", ex.entry.Intent, ex.entry.ServiceID))
 		}
 	}
 
 	prompt.WriteString(fmt.Sprintf("\nSolicitação do cliente: \"%s\"\n", intent))
 	prompt.WriteString("\nResponda APENAS com o número do ID do serviço mais adequado (1-16). Sem texto adicional.")
 
-	return prompt.String()
+	return prompt.String(), estimateTokens(prompt.String())
 }
 
 type OpenRouterRequest struct {
@@ -175,13 +177,19 @@ type Message struct {
 
 type OpenRouterResponse struct {
 	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
 type Choice struct {
 	Message Message `json:"message"`
 }
 
-func (ic *IntentClassifier) callOpenRouter(prompt string) (string, error) {
+func (ic *IntentClassifier) callOpenRouter(prompt string) (string, Usage, error) {
 	reqBody := OpenRouterRequest{
 		Model: "mistralai/mistral-7b-instruct",
 		Messages: []Message{
@@ -191,12 +199,12 @@ func (ic *IntentClassifier) callOpenRouter(prompt string) (string, error) {
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
 	req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -204,29 +212,29 @@ func (ic *IntentClassifier) callOpenRouter(prompt string) (string, error) {
 
 	resp, err := ic.client.Do(req)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenRouter API error: %s - %s", resp.Status, string(body))
+		return "", Usage{}, fmt.Errorf("OpenRouter API error: %s - %s", resp.Status, string(body))
 	}
 
 	var apiResp OpenRouterResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
 	if len(apiResp.Choices) == 0 {
-		return "", fmt.Errorf("nenhuma resposta da API")
+		return "", Usage{}, fmt.Errorf("nenhuma resposta da API")
 	}
 
-	return apiResp.Choices[0].Message.Content, nil
+	return apiResp.Choices[0].Message.Content, apiResp.Usage, nil
 }
 
 // parseResponse mais confiável
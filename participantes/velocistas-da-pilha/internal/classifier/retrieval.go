@@ -0,0 +1,181 @@
+package classifier
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+	"velocistas_da_pilha/internal/storage"
+)
+
+const (
+	// promptExamples é quantos exemplos vão no prompt do LLM
+	promptExamples = 8
+	// promptCandidates é entre quantos serviços os exemplos são divididos
+	promptCandidates = 4
+)
+
+// scoredEntry é uma intenção conhecida com a similaridade com a solicitação
+type scoredEntry struct {
+	entry storage.IntentEntry
+	score float64
+}
+
+// tfidfIndex indexa as intenções conhecidas por TF-IDF; a similaridade entre
+// duas frases é o cosseno entre os vetores
+type tfidfIndex struct {
+	entries []storage.IntentEntry
+	vectors []map[string]float64
+	idf     map[string]float64
+	// perService é a primeira intenção de cada serviço, em ordem de ID
+	perService []scoredEntry
+}
+
+// newTFIDFIndex calcula o IDF dos termos e o vetor de cada intenção
+func newTFIDFIndex(entries []storage.IntentEntry) *tfidfIndex {
+	idx := &tfidfIndex{
+		entries: entries,
+		vectors: make([]map[string]float64, len(entries)),
+		idf:     make(map[string]float64),
+	}
+
+	terms := make([][]string, len(entries))
+	df := make(map[string]int)
+	for i, entry := range entries {
+		terms[i] = tfidfTerms(entry.Intent)
+		seen := make(map[string]bool)
+		for _, t := range terms[i] {
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+
+	// IDF suavizado: "cartao" aparece em muitos serviços e pesa pouco
+	n := float64(len(entries))
+	for t, count := range df {
+		idx.idf[t] = math.Log((1+n)/(1+float64(count))) + 1
+	}
+
+	for i := range entries {
+		idx.vectors[i] = idx.vectorize(terms[i])
+	}
+
+	seen := make(map[int]bool)
+	for _, entry := range entries {
+		if !seen[entry.ServiceID] {
+			seen[entry.ServiceID] = true
+			idx.perService = append(idx.perService, scoredEntry{entry: entry})
+		}
+	}
+	sort.SliceStable(idx.perService, func(a, b int) bool {
+		return idx.perService[a].entry.ServiceID < idx.perService[b].entry.ServiceID
+	})
+
+	return idx
+}
+
+// vectorize monta o vetor TF-IDF normalizado (termos desconhecidos são ignorados)
+func (idx *tfidfIndex) vectorize(terms []string) map[string]float64 {
+	vec := make(map[string]float64, len(terms))
+	for _, t := range terms {
+		if _, ok := idx.idf[t]; ok {
+			vec[t]++
+		}
+	}
+
+	norm := 0.0
+	for t, tf := range vec {
+		w := (1 + math.Log(tf)) * idx.idf[t]
+		vec[t] = w
+		norm += w * w
+	}
+	for t := range vec {
+		vec[t] /= math.Sqrt(norm)
+	}
+
+	return vec
+}
+
+// topK retorna até k intenções conhecidas mais parecidas com a solicitação,
+// balanceadas entre os serviços candidatos: os serviços são ordenados pelo
+// seu exemplo mais parecido e cada um contribui em rodízio, assim serviços
+// que disputam a mesma solicitação (ex.: 2 e 3, 7 e 11) aparecem juntos.
+// Se nenhum termo da solicitação é conhecido, não há como escolher
+// candidatos: retorna uma intenção de cada serviço, com score 0.
+func (idx *tfidfIndex) topK(intent string, k, candidates int) []scoredEntry {
+	query := idx.vectorize(tfidfTerms(intent))
+	if len(query) == 0 {
+		return idx.perService
+	}
+
+	byService := make(map[int][]scoredEntry)
+	for i, vec := range idx.vectors {
+		score := 0.0
+		for t, w := range query {
+			score += w * vec[t]
+		}
+		if score > 0 {
+			id := idx.entries[i].ServiceID
+			byService[id] = append(byService[id], scoredEntry{entry: idx.entries[i], score: score})
+		}
+	}
+
+	services := make([]int, 0, len(byService))
+	for id, list := range byService {
+		sort.SliceStable(list, func(a, b int) bool { return list[a].score > list[b].score })
+		services = append(services, id)
+	}
+	sort.Slice(services, func(a, b int) bool {
+		sa, sb := byService[services[a]][0].score, byService[services[b]][0].score
+		if sa != sb {
+			return sa > sb
+		}
+		return services[a] < services[b]
+	})
+	if len(services) > candidates {
+		services = services[:candidates]
+	}
+
+	selected := make([]scoredEntry, 0, k)
+	for rank := 0; len(selected) < k; rank++ {
+		added := false
+		for _, id := range services {
+			if list := byService[id]; rank < len(list) && len(selected) < k {
+				selected = append(selected, list[rank])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	sort.SliceStable(selected, func(a, b int) bool { return selected[a].score > selected[b].score })
+	return selected
+}
+
+// tfidfTerms usa as mesmas palavras do fuzzyMatch (contentWords) reduzidas
+// a um radical simples: singular e prefixo de 5 letras, assim "cancelar" e
+// "cancelamento" ou "cartão" e "cartões" viram o mesmo termo
+func tfidfTerms(s string) []string {
+	words := contentWords(s)
+	for i, w := range words {
+		if strings.HasSuffix(w, "ões") {
+			w = strings.TrimSuffix(w, "ões") + "ão"
+		} else {
+			w = strings.TrimSuffix(w, "s")
+		}
+		if r := []rune(w); len(r) > 5 {
+			w = string(r[:5])
+		}
+		words[i] = w
+	}
+	return words
+}
+
+// estimateTokens estima os tokens do prompt (~4 caracteres por token)
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
@@ -0,0 +1,94 @@
+package classifier
+
+import (
+	"strings"
+	"testing"
+	"velocistas_da_pilha/internal/storage"
+)
+
+func loadTestIntents(t *testing.T) []storage.IntentEntry {
+	t.Helper()
+	entries, err := storage.LoadIntentsCSV("../../assets/intents_pre_loaded.csv")
+	if err != nil {
+		t.Fatalf("LoadIntentsCSV() error = %v", err)
+	}
+	return entries
+}
+
+func TestTopK(t *testing.T) {
+	idx := newTFIDFIndex(loadTestIntents(t))
+
+	tests := []struct {
+		name    string
+		intent  string
+		wantTop int // serviço da intenção mais parecida
+		// wantLen é quantas intenções voltam; menos que promptExamples quando
+		// poucas compartilham algum termo com a solicitação
+		wantLen   int
+		wantFixed bool // uma intenção por serviço, sem similaridade
+	}{
+		{name: "same stem", intent: "cancelamento do cartão", wantTop: 7, wantLen: promptExamples},
+		{name: "plural", intent: "aumentar limites", wantTop: 6, wantLen: 6},
+		{name: "single candidate", intent: "esqueci a senha", wantTop: 10, wantLen: 6},
+		{name: "close services", intent: "segunda via da fatura", wantTop: 3, wantLen: promptExamples},
+		{name: "unseen word", intent: "meu cartão foi roubado", wantTop: 11, wantLen: promptExamples},
+		{name: "unknown terms", intent: "xpto blá", wantLen: 16, wantFixed: true},
+		{name: "stopwords only", intent: "de para com a", wantLen: 16, wantFixed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			examples := idx.topK(tt.intent, promptExamples, promptCandidates)
+
+			if len(examples) != tt.wantLen {
+				t.Fatalf("topK(%q) = %d intenções, want %d", tt.intent, len(examples), tt.wantLen)
+			}
+			if !tt.wantFixed && examples[0].entry.ServiceID != tt.wantTop {
+				t.Errorf("topK(%q)[0] = %q (serviço %d), want serviço %d", tt.intent, examples[0].entry.Intent, examples[0].entry.ServiceID, tt.wantTop)
+			}
+
+			services := make(map[int]bool)
+			for i, ex := range examples {
+				services[ex.entry.ServiceID] = true
+				if i > 0 && ex.score > examples[i-1].score {
+					t.Errorf("topK()[%d].score = %.3f, acima do anterior %.3f", i, ex.score, examples[i-1].score)
+				}
+				if (ex.score == 0) != tt.wantFixed {
+					t.Errorf("%q com score %.3f, wantFixed %v", ex.entry.Intent, ex.score, tt.wantFixed)
+				}
+			}
+			if !tt.wantFixed && len(services) > promptCandidates {
+				t.Errorf("topK(%q) com %d serviços, want no máximo %d", tt.intent, len(services), promptCandidates)
+			}
+		})
+	}
+}
+
+func TestBuildPrompt(t *testing.T) {
+	ic := NewIntentClassifier(loadTestIntents(t), "")
+
+	tests := []struct {
+		intent     string
+		wantHeader string
+		wantLines  int
+	}{
+		{intent: "quero cancelar meu cartão", wantHeader: "Exemplos parecidos:", wantLines: promptExamples},
+		{intent: "xpto", wantHeader: "Um exemplo de cada serviço:", wantLines: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.intent, func(t *testing.T) {
+			prompt, tokens := ic.buildPrompt(tt.intent)
+
+			if !strings.Contains(prompt, tt.wantHeader) {
+				t.Errorf("prompt sem %q:\n%s", tt.wantHeader, prompt)
+			}
+			if lines := strings.Count(prompt, "\" → "); lines != tt.wantLines {
+				t.Errorf("prompt com %d exemplos, want %d", lines, tt.wantLines)
+			}
+			if want := (len([]rune(prompt)) + 3) / 4; tokens != want {
+				t.Errorf("tokens = %d, want %d", tokens, want)
+			}
+		})
+	}
+}